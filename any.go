@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+)
+
+// TryAny method to run the functions concurrently and succeed as soon as one of them succeeds, with its values.
+// The others are then abandoned as in TryQuorum. Failures are not passed to the error handler one by one: only
+// once every function has failed is it called, with their joined errors wrapping ErrAllFailed and an
+// ExecutionInfo whose Index is -1. A handler asking for a retry runs all the functions again.
+func (fhi *FunctionHandlerImpl) TryAny(handler interface{}, funcs ...func() Result[any]) ([]any, Result[any]) {
+	release, err := fhi.acquireBatch(context.Background())
+	if err != nil {
+		fhi.LogError(err)
+		return nil, Err[any](err)
+	}
+	defer release()
+	handlerFunc := fhi.WrapErrorHandler(handler)
+	if handlerFunc.IsErr() {
+		return nil, Err[any](handlerFunc.Err)
+	}
+	if len(funcs) == 0 {
+		err = fhi.errorf("%w", ErrNoFunctions)
+	} else {
+		err = fhi.checkRetryBound(context.Background())
+	}
+	if err != nil {
+		fhi.LogError(err)
+		return nil, Err[any](err)
+	}
+	ctx := fhi.withBatchID(context.Background())
+	ids, _ := ExecutionIDsFrom(ctx)
+	for handlerRetries := 0; ; handlerRetries++ {
+		results, err := fhi.runAny(ctx, funcs)
+		if err == nil {
+			return results, Ok[any](nil)
+		}
+		retry, fallback, abort := fhi.callHandler(handlerFunc.Values[0], err, ExecutionInfo{BatchID: ids.Batch, Index: -1})
+		switch {
+		case abort != nil:
+			return nil, Err[any](abort)
+		case fallback != nil:
+			return []any{fallback}, Ok[any](nil)
+		case !retry || !fhi.allowHandlerRetry(handlerRetries, err):
+			return []any{}, Ok[any](nil)
+		}
+	}
+}
+
+// runAny method to run the functions concurrently, returning the values of the first to succeed or, once all
+// have failed, their joined errors
+func (fhi *FunctionHandlerImpl) runAny(parent context.Context, funcs []func() Result[any]) ([]any, error) {
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil) // after an earlier cancel this keeps its cause
+	// done is closed on return so goroutines still running can drop their result
+	done := make(chan struct{})
+	defer close(done)
+	resultCh := make(chan outcome)
+	fhi.dispatch(funcs, done, func(i int, fn func() Result[any]) {
+		res, ok := fhi.runStaggered(ctx, i, fn, done)
+		if !ok {
+			return
+		}
+		select {
+		case resultCh <- outcome{index: i, fn: fn, res: res}:
+		case <-done:
+		}
+	})
+	var failed failures
+	for range funcs {
+		o := <-resultCh
+		if o.res.IsOk() {
+			cancel(fmt.Errorf("%w: %s succeeded first", ErrAbandoned, nameOf(o.fn, describe(o.fn))))
+			return fhi.collect([]any{}, o.res.Values), nil
+		}
+		failed.add(o.index, o.res)
+	}
+	return nil, fhi.errorfIn(ctx, "%w: %w", ErrAllFailed, failed.err())
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// anyCall struct to record what the error handler of TryAny was called with
+type anyCall struct {
+	err  error
+	info ExecutionInfo
+}
+
+func TestTryAny(t *testing.T) {
+	errOther := errors.New("other")
+	tests := []struct {
+		name      string
+		handler   func(calls *[]anyCall) interface{}
+		failRuns  int32 // how many runs fail before the functions start to succeed
+		want      []any
+		wantErr   error
+		wantCalls int
+	}{
+		{"first success wins", func(calls *[]anyCall) interface{} {
+			return func(err error, info ExecutionInfo) error { *calls = append(*calls, anyCall{err, info}); return err }
+		}, 0, []any{"fast"}, nil, 0},
+		{"all failed and aborted", func(calls *[]anyCall) interface{} {
+			return func(err error, info ExecutionInfo) error { *calls = append(*calls, anyCall{err, info}); return err }
+		}, 99, nil, ErrAllFailed, 1},
+		{"all failed and ignored", func(calls *[]anyCall) interface{} {
+			return func(err error, info ExecutionInfo) error { *calls = append(*calls, anyCall{err, info}); return nil }
+		}, 99, []any{}, nil, 1},
+		{"fallback", func(calls *[]anyCall) interface{} {
+			return func(err error, info ExecutionInfo) (any, error) {
+				*calls = append(*calls, anyCall{err, info})
+				return "fallback", nil
+			}
+		}, 99, []any{"fallback"}, nil, 1},
+		{"handler retry runs them all again", func(calls *[]anyCall) interface{} {
+			return func(err error, info ExecutionInfo) (bool, error) {
+				*calls = append(*calls, anyCall{err, info})
+				return true, nil
+			}
+		}, 3, []any{"fast"}, nil, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler()
+			var runs atomic.Int32
+			var calls []anyCall
+			wrap := func(value string, err error, pause time.Duration) func() Result[any] {
+				return fh.WrapNamed(value, func(ctx context.Context) (string, error) {
+					if runs.Add(1) <= tt.failRuns {
+						return "", err
+					}
+					select {
+					case <-time.After(pause):
+						return value, nil
+					case <-ctx.Done():
+						return "", ctx.Err()
+					}
+				})
+			}
+			start := time.Now()
+			results, res := fh.TryAny(tt.handler(&calls), wrap("slow", errBoom, time.Hour), wrap("fast", errOther, 0), wrap("slower", errBoom, time.Hour))
+			if !errors.Is(res.Err, tt.wantErr) {
+				t.Fatalf("TryAny = %v, want %v", res.Err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !slices.Equal(results, tt.want) {
+				t.Fatalf("TryAny = %v, want %v", results, tt.want)
+			}
+			if took := time.Since(start); took > time.Second {
+				t.Fatalf("TryAny took %s, want the slow functions abandoned", took)
+			}
+			if len(calls) != tt.wantCalls {
+				t.Fatalf("error handler called %d times, want %d", len(calls), tt.wantCalls)
+			}
+			for _, call := range calls {
+				if !errors.Is(call.err, ErrAllFailed) || !errors.Is(call.err, errBoom) || !errors.Is(call.err, errOther) {
+					t.Fatalf("error handler got %v, want %v joining every failure", call.err, ErrAllFailed)
+				}
+				if call.info.Index != -1 {
+					t.Fatalf("error handler got index %d, want -1 for the whole batch", call.info.Index)
+				}
+			}
+		})
+	}
+}
+
+func TestTryAnyNoFunctions(t *testing.T) {
+	if _, res := NewHandler().TryAny(func(err error) error { return err }); !errors.Is(res.Err, ErrNoFunctions) {
+		t.Fatalf("TryAny = %v, want %v", res.Err, ErrNoFunctions)
+	}
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff interface to compute how long the retry loop waits before a retry, numbered from 1
+type Backoff interface {
+	Delay(retry int) time.Duration
+}
+
+// BackoffFunc type to use a plain function as a Backoff
+type BackoffFunc func(retry int) time.Duration
+
+// Delay method to call the function
+func (f BackoffFunc) Delay(retry int) time.Duration {
+	return f(retry)
+}
+
+// defaultBackoff is the backoff used when none is set
+var defaultBackoff = ConstantBackoff(time.Second)
+
+// ConstantBackoff function to wait d before every retry
+func ConstantBackoff(d time.Duration) Backoff {
+	return BackoffFunc(func(int) time.Duration {
+		return d
+	})
+}
+
+// LinearBackoff function to wait initial before the first retry and step longer before each next one,
+// up to max when it is positive
+func LinearBackoff(initial, step, max time.Duration) Backoff {
+	return BackoffFunc(func(retry int) time.Duration {
+		return capDelay(initial+time.Duration(retry-1)*step, max)
+	})
+}
+
+// ExponentialBackoff function to wait initial before the first retry and twice as long before each next one,
+// up to max when it is positive
+func ExponentialBackoff(initial, max time.Duration) Backoff {
+	return BackoffFunc(func(retry int) time.Duration {
+		delay := initial
+		for i := 1; i < retry; i++ {
+			if delay > time.Duration(1<<62)/2 || (max > 0 && delay >= max) {
+				break // doubling further would overflow or only be capped anyway
+			}
+			delay *= 2
+		}
+		return capDelay(delay, max)
+	})
+}
+
+// FullJitterBackoff function to wait a random time between zero and the delay of b, so clients failing
+// together do not retry together
+func FullJitterBackoff(b Backoff) Backoff {
+	return BackoffFunc(func(retry int) time.Duration {
+		delay := b.Delay(retry)
+		if delay <= 0 {
+			return 0
+		}
+		return time.Duration(rand.Int63n(int64(delay) + 1))
+	})
+}
+
+// capDelay function to limit delay to max when max is positive
+func capDelay(delay, max time.Duration) time.Duration {
+	if max > 0 && delay > max {
+		return max
+	}
+	return delay
+}
+
+// SetBackoff method to set how long the retry loop waits between attempts; nil restores the default of one second
+func (fhi *FunctionHandlerImpl) SetBackoff(backoff Backoff) {
+	fhi.configure(func(s *settings) { s.backoff = backoff })
+}
+
+// backoffFor method to return how long to wait before the given retry after err. A RetryAfter hint takes
+// precedence over the backoff.
+func (fhi *FunctionHandlerImpl) backoffFor(retry int, err error) time.Duration {
+	cfg := fhi.settings()
+	if hint, ok := retryHint(err); ok {
+		return hint
+	}
+	if cfg.backoff == nil {
+		return defaultBackoff.Delay(retry)
+	}
+	return cfg.backoff.Delay(retry)
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	const forever = time.Duration(1<<63 - 1)
+	tests := []struct {
+		name    string
+		backoff Backoff
+		want    []time.Duration // for retries 1, 2, ...
+	}{
+		{"constant", ConstantBackoff(time.Second), []time.Duration{time.Second, time.Second, time.Second}},
+		{"linear", LinearBackoff(time.Second, 2*time.Second, 0), []time.Duration{time.Second, 3 * time.Second, 5 * time.Second}},
+		{"linear capped", LinearBackoff(time.Second, 2*time.Second, 4*time.Second), []time.Duration{time.Second, 3 * time.Second, 4 * time.Second}},
+		{"exponential", ExponentialBackoff(time.Second, 0), []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}},
+		{"exponential capped", ExponentialBackoff(time.Second, 3*time.Second), []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}},
+		{"func", BackoffFunc(func(retry int) time.Duration { return time.Duration(retry) }), []time.Duration{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []time.Duration
+			for retry := 1; retry <= len(tt.want); retry++ {
+				got = append(got, tt.backoff.Delay(retry))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("delays = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	t.Run("exponential does not overflow", func(t *testing.T) {
+		if got := ExponentialBackoff(time.Second, 0).Delay(200); got <= 0 {
+			t.Fatalf("delay of retry 200 = %s, want a positive delay", got)
+		}
+		if got := ExponentialBackoff(time.Second, forever).Delay(200); got <= 0 || got > forever {
+			t.Fatalf("delay of retry 200 = %s, want a positive delay", got)
+		}
+	})
+}
+
+func TestFullJitterBackoff(t *testing.T) {
+	tests := []struct {
+		name  string
+		inner Backoff
+		max   time.Duration
+	}{
+		{"within the delay", ConstantBackoff(10 * time.Millisecond), 10 * time.Millisecond},
+		{"no delay", ConstantBackoff(0), 0},
+		{"negative delay", ConstantBackoff(-time.Second), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := FullJitterBackoff(tt.inner)
+			seen := map[time.Duration]bool{}
+			for i := 0; i < 200; i++ {
+				d := b.Delay(1)
+				if d < 0 || d > tt.max {
+					t.Fatalf("delay = %s, want between 0 and %s", d, tt.max)
+				}
+				seen[d] = true
+			}
+			if tt.max > 0 && len(seen) < 2 {
+				t.Fatalf("delays %v, want them spread", seen)
+			}
+		})
+	}
+}
+
+func TestSetBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff Backoff
+		want    []time.Duration
+	}{
+		{"default", nil, []time.Duration{time.Second, time.Second, time.Second}},
+		{"exponential", ExponentialBackoff(10*time.Millisecond, 0), []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond}},
+		{"linear", LinearBackoff(time.Millisecond, time.Millisecond, 0), []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Now()}
+			fh := NewHandler(WithRetries(3))
+			fh.SetClock(clock)
+			fh.SetBackoff(tt.backoff)
+			fh.Try(func(err error) error { return err }, fh.WrapFunction(func() error { return errBoom }))
+			if got := clock.waited(); !slices.Equal(got, tt.want) {
+				t.Fatalf("waited %v between attempts, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"time"
+)
+
+// BatchMetrics interface that a Metrics implementation can also implement to observe batches
+// waiting for a slot under SetMaxConcurrentBatches
+type BatchMetrics interface {
+	// BatchWaited reports a batch that had to wait, with the number of batches waiting when it
+	// arrived including itself, and how long it waited
+	BatchWaited(handler string, position int, waited time.Duration)
+}
+
+// SetMaxConcurrentBatches method to let at most n Try, TryChan and Group.Run calls run at once on this
+// handler; further calls wait for a slot, bounded by their context. Zero or less removes the limit.
+// Unlike a per-batch concurrency limit this caps the handler as a whole across goroutines.
+func (fhi *FunctionHandlerImpl) SetMaxConcurrentBatches(n int) {
+	fhi.configure(func(s *settings) { s.batchSlots = newBatchSlots(n) })
+}
+
+// newBatchSlots function to create the slots of SetMaxConcurrentBatches, nil when there is no limit
+func newBatchSlots(n int) chan struct{} {
+	if n <= 0 {
+		return nil
+	}
+	return make(chan struct{}, n)
+}
+
+// SetRejectWhenBusy method to make batches fail with ErrBusy instead of waiting when no slot is free
+func (fhi *FunctionHandlerImpl) SetRejectWhenBusy(reject bool) {
+	fhi.configure(func(s *settings) { s.rejectWhenBusy = reject })
+}
+
+// acquireBatch method to take a batch slot of the handler and of every ancestor, waiting for them if needed.
+// release must be called when the batch ends.
+func (fhi *FunctionHandlerImpl) acquireBatch(ctx context.Context) (release func(), err error) {
+	releaseOwn, err := fhi.acquireSlot(ctx)
+	if err != nil || fhi.parent == nil {
+		return releaseOwn, err
+	}
+	releaseParent, err := fhi.parent.acquireBatch(ctx)
+	if err != nil {
+		releaseOwn()
+		return nil, err
+	}
+	return func() {
+		releaseParent()
+		releaseOwn()
+	}, nil
+}
+
+// acquireSlot method to take a batch slot of the handler itself, waiting for one if needed
+func (fhi *FunctionHandlerImpl) acquireSlot(ctx context.Context) (release func(), err error) {
+	cfg := fhi.settings()
+	slots := cfg.batchSlots
+	if slots == nil {
+		return func() {}, nil
+	}
+	release = func() { <-slots }
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+	if cfg.rejectWhenBusy {
+		return nil, fhi.errorf("%w: %d batches already running", ErrBusy, cap(slots))
+	}
+	position := fhi.waitingBatches.Add(1)
+	defer fhi.waitingBatches.Add(-1)
+	start := fhi.getClock().Now()
+	select {
+	case slots <- struct{}{}:
+		if metrics, ok := fhi.getMetrics().(BatchMetrics); ok {
+			metrics.BatchWaited(cfg.name, int(position), fhi.getClock().Now().Sub(start))
+		}
+		return release, nil
+	case <-ctx.Done():
+		return nil, fhi.errorf("waiting for a batch slot: %w", ctx.Err())
+	}
+}
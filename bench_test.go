@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"fmt"
+	"testing"
+)
+
+// BenchmarkTry runs a 3-argument, 2-result function through Try, the path whose argument and result buffers
+// are pooled
+func BenchmarkTry(b *testing.B) {
+	fh := NewHandler()
+	fn := fh.WrapFunction(func(a, b, c int) (int, error) { return a + b + c, nil }, 1, 2, 3)
+	handler := func(err error) error { return err }
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, res := fh.Try(handler, fn); res.IsErr() {
+			b.Fatal(res.Err)
+		}
+	}
+}
+
+// BenchmarkTryDiscardValues compares a large batch collecting its values with one discarding them
+func BenchmarkTryDiscardValues(b *testing.B) {
+	for _, discard := range []bool{false, true} {
+		b.Run(fmt.Sprintf("discard=%t", discard), func(b *testing.B) {
+			fh := NewHandler()
+			fh.SetDiscardValues(discard)
+			funcs := make([]func() Result[any], 10000)
+			for i := range funcs {
+				funcs[i] = fh.WrapFunction(func(i int) (int, string) { return i, "done" }, i)
+			}
+			handler := func(err error) error { return err }
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, res := fh.Try(handler, funcs...); res.IsErr() {
+					b.Fatal(res.Err)
+				}
+			}
+		})
+	}
+}
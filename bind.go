@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"unsafe"
+)
+
+// wrapped struct to describe a function created by one of the Wrap methods
+type wrapped struct {
+	name       string
+	run        func(exec *execution) Result[any]
+	onResult   func(Result[any])
+	function   interface{}                      // the wrapped function, when known, for Describe
+	args       []interface{}                    // its bound arguments
+	lateArgs   bool                             // the arguments are computed at every attempt, so there are none to check up front
+	validators []func(args []interface{}) error // run on the bound arguments before every call
+	opts       []Option                         // per-function overrides of the handler's settings
+	canceler   Canceler                         // stops the function when it times out, when it is not the function itself
+}
+
+// execution struct to hold the state of one attempt at running a wrapped function
+type execution struct {
+	ctx     context.Context
+	attempt int
+	prevErr error
+}
+
+// bind function to turn a wrapped description into the closure handed out by the Wrap methods.
+// Called directly the closure runs a single attempt; describe returns its description, which lets the
+// handler run it with the state of each attempt.
+func bind(w *wrapped) func() Result[any] {
+	// the closure holds the only reference to token, so token is collected with it and its cleanup
+	// removes the closure's entry
+	token := &bindToken{w: w}
+	fn := func() Result[any] {
+		return token.w.run(&execution{ctx: context.Background(), attempt: 1})
+	}
+	key := uintptr(funcAddr(fn))
+	descriptions.Store(key, w)
+	releaseWith(token, key)
+	return fn
+}
+
+// descriptions maps the address of every live closure created by bind to its description. The address is
+// kept as a uintptr so the map does not keep the closure alive.
+var descriptions sync.Map
+
+// bindToken struct to tie the lifetime of a description's entry to the closure created by bind. It holds a
+// pointer so it is allocated on its own, where a cleanup can be attached to it.
+type bindToken struct {
+	w *wrapped
+}
+
+// release function to remove the entry of a collected closure. The address may already belong to a newer
+// closure that stored its own entry, which is kept.
+func release(key uintptr, w *wrapped) {
+	descriptions.CompareAndDelete(key, w)
+}
+
+// funcAddr function to return the address of the function value fn refers to, which identifies a closure
+func funcAddr(fn func() Result[any]) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&fn))
+}
+
+// describe function to return the description of a closure created by bind, or nil for any other function.
+// fn is never called.
+func describe(fn func() Result[any]) *wrapped {
+	if fn == nil {
+		return nil
+	}
+	if w, ok := descriptions.Load(uintptr(funcAddr(fn))); ok {
+		return w.(*wrapped)
+	}
+	return nil
+}
+
+// nameOf function to return the name of fn, taken from its description when it has one
+func nameOf(fn func() Result[any], w *wrapped) string {
+	if w != nil {
+		return w.name
+	}
+	return funcName(fn)
+}
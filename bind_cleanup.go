@@ -0,0 +1,16 @@
+//go:build go1.24
+
+package handler
+
+import "runtime"
+
+// releaseWith function to release the entry at key once token is collected
+func releaseWith(token *bindToken, key uintptr) {
+	runtime.AddCleanup(token, func(e entry) { release(e.key, e.w) }, entry{key: key, w: token.w})
+}
+
+// entry struct to pass what a cleanup needs without referring to the collected token
+type entry struct {
+	key uintptr
+	w   *wrapped
+}
@@ -0,0 +1,10 @@
+//go:build !go1.24
+
+package handler
+
+import "runtime"
+
+// releaseWith function to release the entry at key once token is collected
+func releaseWith(token *bindToken, key uintptr) {
+	runtime.SetFinalizer(token, func(t *bindToken) { release(key, t.w) })
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDescribeDoesNotCallFunctions(t *testing.T) {
+	fh := NewHandler()
+	calls := 0
+	plain := func() Result[any] { calls++; return Ok[any]() }
+	if w := describe(plain); w != nil {
+		t.Fatalf("describe(plain function) = %+v, want nil", w)
+	}
+	wrapped := fh.WrapNamed("count", func() { calls++ })
+	if w := describe(wrapped); w == nil || w.name != "count" {
+		t.Fatalf("describe(wrapped) = %+v, want the description named count", w)
+	}
+	if calls != 0 {
+		t.Fatalf("describe called the functions %d times", calls)
+	}
+	if res := wrapped(); res.IsErr() || calls != 1 {
+		t.Fatalf("calling the wrapped function = %v with %d calls, want one call", res.Err, calls)
+	}
+}
+
+func TestDescribeTellsClosuresApart(t *testing.T) {
+	fh := NewHandler()
+	a, b := fh.WrapNamed("a", func() {}), fh.WrapNamed("b", func() {})
+	if describe(a).name != "a" || describe(b).name != "b" {
+		t.Fatalf("describe = %q, %q; want a, b", describe(a).name, describe(b).name)
+	}
+}
+
+func TestDescriptionsAreReleased(t *testing.T) {
+	// count only this test's entries: those of other tests are released while it runs
+	count := func() int {
+		n := 0
+		descriptions.Range(func(_, w any) bool {
+			if strings.HasPrefix(w.(*wrapped).name, "released-") {
+				n++
+			}
+			return true
+		})
+		return n
+	}
+	fh := NewHandler()
+	kept := make([]func() Result[any], 10)
+	for i := 0; i < 100; i++ {
+		fn := fh.WrapNamed(fmt.Sprint("released-", i), func(int) {}, i)
+		if i < len(kept) {
+			kept[i] = fn
+		}
+	}
+	deadline := time.Now().Add(time.Second)
+	for count() > len(kept) && time.Now().Before(deadline) {
+		runtime.GC() // the cleanups removing the entries run after the collection that found the closures
+		time.Sleep(time.Millisecond)
+	}
+	if n := count(); n != len(kept) {
+		t.Fatalf("%d descriptions left after all but %d wrapped functions were dropped", n, len(kept))
+	}
+	// the closures still in use keep their descriptions, even when a collected one had the same address
+	for i, fn := range kept {
+		if w := describe(fn); w == nil || w.name != fmt.Sprint("released-", i) {
+			t.Fatalf("describe(released-%d) = %+v after the collection", i, w)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"container/list"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// defaultCacheSize is how many results WrapCached keeps by default
+const defaultCacheSize = 1000
+
+// WrapCached method to create a function like WrapFunction whose successful results are kept for ttl and
+// returned by the functions wrapped with the same function and arguments instead of calling it again.
+// Entries are keyed by the identity of the function value and the arguments' Go syntax: closures and method
+// values only share entries with the same closure or method value, not with others of the same code. The
+// cache belongs to this handler and its relatives and is bounded by SetCacheSize.
+func (fhi *FunctionHandlerImpl) WrapCached(function interface{}, ttl time.Duration, args ...interface{}) func() Result[any] {
+	w := fhi.wrapFunction(function, args)
+	run := w.run
+	cache := &fhi.root().cache
+	key := fmt.Sprintf("%s%#v", funcIdentity(function), w.args)
+	w.run = func(exec *execution) Result[any] {
+		if values, ok := cache.get(key, fhi.getClock().Now()); ok {
+			return Ok(values...)
+		}
+		res := run(exec)
+		if res.IsOk() {
+			cache.put(key, function, res.Values, fhi.getClock().Now().Add(ttl))
+		}
+		return res
+	}
+	return bind(w)
+}
+
+// funcIdentity function to return a key for the function value itself: its name and the address of its
+// closure, which holds the captured variables of a closure and the receiver of a method value
+func funcIdentity(function interface{}) string {
+	closure := (*[2]unsafe.Pointer)(unsafe.Pointer(&function))[1] // the data word of the interface
+	return fmt.Sprintf("%s@%p", funcName(function), closure)
+}
+
+// SetCacheSize method to cap how many results WrapCached keeps for this handler and its relatives, dropping
+// the least recently used first. The size defaults to 1000 and a negative size removes the cap.
+func (fhi *FunctionHandlerImpl) SetCacheSize(n int) {
+	cache := &fhi.root().cache
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.size = n
+	cache.trimLocked()
+}
+
+// ClearCache method to drop every result kept by WrapCached
+func (fhi *FunctionHandlerImpl) ClearCache() {
+	cache := &fhi.root().cache
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.order, cache.entries = nil, nil
+}
+
+// resultCache struct to hold the results of cached functions, most recently used first
+type resultCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// cacheEntry struct to hold one cached result and when it expires
+type cacheEntry struct {
+	key      string
+	function interface{} // keeps the closure alive, so its address in key is not reused while cached
+	values   []any
+	expires  time.Time
+}
+
+// get method to return a copy of the values cached under key, unless they expired by now
+func (c *resultCache) get(key string, now time.Time) ([]any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !now.Before(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return slices.Clone(entry.values), true
+}
+
+// put method to cache a copy of the values of function under key until expires
+func (c *resultCache) put(key string, function interface{}, values []any, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.order, c.entries = list.New(), map[string]*list.Element{}
+	}
+	entry := &cacheEntry{key: key, function: function, values: slices.Clone(values), expires: expires}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	c.trimLocked()
+}
+
+// trimLocked method to drop the least recently used entries beyond the size; c.mu must be held
+func (c *resultCache) trimLocked() {
+	size := c.size
+	switch {
+	case size == 0:
+		size = defaultCacheSize
+	case size < 0:
+		return
+	}
+	for c.order != nil && c.order.Len() > size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
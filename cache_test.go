@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"testing"
+	"time"
+)
+
+type cachedGetter struct{ name string }
+
+func (g *cachedGetter) Get() string { return g.name }
+
+func TestWrapCachedKeysOnFunctionIdentity(t *testing.T) {
+	fh := NewHandler()
+	handler := func(err error) error { return err }
+	constant := func(v string) func() string {
+		return func() string { return v }
+	}
+	a, b := constant("a"), constant("b")
+	values, res := fh.Try(handler, fh.WrapCached(a, time.Minute), fh.WrapCached(b, time.Minute))
+	if res.IsErr() || len(values) != 2 || values[0] != "a" || values[1] != "b" {
+		t.Fatalf("closures of one literal: got %v, %v; want [a b]", values, res.Err)
+	}
+	ga, gb := &cachedGetter{"A"}, &cachedGetter{"B"}
+	values, res = fh.Try(handler, fh.WrapCached(ga.Get, time.Minute), fh.WrapCached(gb.Get, time.Minute))
+	if res.IsErr() || len(values) != 2 || values[0] != "A" || values[1] != "B" {
+		t.Fatalf("method values on different receivers: got %v, %v; want [A B]", values, res.Err)
+	}
+}
+
+func TestWrapCachedSharesEntriesOfOneFunction(t *testing.T) {
+	fh := NewHandler()
+	calls := 0
+	count := func(n int) int {
+		calls++
+		return n
+	}
+	for i := 0; i < 3; i++ {
+		if _, res := fh.Try(func(err error) error { return err }, fh.WrapCached(count, time.Minute, 1)); res.IsErr() {
+			t.Fatal(res.Err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+	if _, res := fh.Try(func(err error) error { return err }, fh.WrapCached(count, time.Minute, 2)); res.IsErr() {
+		t.Fatal(res.Err)
+	}
+	if calls != 2 {
+		t.Fatalf("calls with other arguments = %d, want 2", calls)
+	}
+}
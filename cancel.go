@@ -0,0 +1,36 @@
+package handler
+
+// Canceler interface that a wrapped function, such as a value of a named func type, or the receiver of a method
+// wrapped with WrapMethodByName can implement to be stopped when it times out. The handler stops waiting for a
+// timed out call either way; Cancel lets work that does not watch an injected context stop too, instead of
+// running on in the background.
+type Canceler interface {
+	// Cancel asks the running call to stop, with the timeout as cause; it may be called while the call runs
+	Cancel(cause error)
+}
+
+// cancelerOf function to return the Canceler of a wrapped function, nil when it has none
+func cancelerOf(w *wrapped) Canceler {
+	if w == nil {
+		return nil
+	}
+	if w.canceler != nil {
+		return w.canceler
+	}
+	canceler, _ := w.function.(Canceler)
+	return canceler
+}
+
+// cancelTimedOut method to ask a timed out function to stop, logging a panic in its Cancel method
+func (fhi *FunctionHandlerImpl) cancelTimedOut(w *wrapped, cause error) {
+	canceler := cancelerOf(w)
+	if canceler == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			fhi.LogError(fhi.errorf("cancel of %s panicked: %v", w.name, r))
+		}
+	}()
+	canceler.Cancel(cause)
+}
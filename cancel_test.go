@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingJob struct to run until it is cancelled, implementing Canceler
+type blockingJob struct {
+	once    sync.Once
+	stop    chan struct{}
+	cause   chan error
+	panics  bool
+	runTime time.Duration // how long Run takes when it is not cancelled
+}
+
+func newBlockingJob(runTime time.Duration, panics bool) *blockingJob {
+	return &blockingJob{stop: make(chan struct{}), cause: make(chan error, 1), runTime: runTime, panics: panics}
+}
+
+func (j *blockingJob) Run() {
+	select {
+	case <-j.stop:
+	case <-time.After(j.runTime):
+	}
+}
+
+func (j *blockingJob) Cancel(cause error) {
+	j.once.Do(func() {
+		j.cause <- cause
+		close(j.stop)
+	})
+	if j.panics {
+		panic("cancel failed")
+	}
+}
+
+func TestCancelOnTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		runTime    time.Duration
+		panics     bool
+		want       error
+		wantCancel bool
+	}{
+		{"cancelled on timeout", time.Minute, false, ErrTimeout, true},
+		{"panicking Cancel is logged", time.Minute, true, ErrTimeout, true},
+		{"finished in time", 0, false, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			fh := NewHandler(WithTimeout(20*time.Millisecond), WithLogger(logger))
+			job := newBlockingJob(tt.runTime, tt.panics)
+			fn, err := fh.WrapMethodByName(job, "Run")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, res := fh.Try(func(err error) error { return err }, fn)
+			if !errors.Is(res.Err, tt.want) {
+				t.Fatalf("Try = %v, want %v", res.Err, tt.want)
+			}
+			select {
+			case cause := <-job.cause:
+				if !tt.wantCancel {
+					t.Fatalf("cancelled with %v, want no cancel", cause)
+				}
+				if !errors.Is(cause, ErrTimeout) {
+					t.Fatalf("cancelled with %v, want %v", cause, ErrTimeout)
+				}
+			default:
+				if tt.wantCancel {
+					t.Fatal("the timed out job was not cancelled")
+				}
+			}
+			if tt.panics && len(logger.logged()) < 2 {
+				t.Fatalf("logged %d messages, want the timeout and the panic in Cancel", len(logger.logged()))
+			}
+		})
+	}
+}
+
+func TestInjectedContextCancelledOnTimeout(t *testing.T) {
+	fh := NewHandler(WithTimeout(20*time.Millisecond), WithLogger(&recordingLogger{}))
+	stopped := make(chan error, 1)
+	_, res := fh.Try(func(err error) error { return err }, fh.WrapFunction(func(ctx context.Context) {
+		<-ctx.Done()
+		stopped <- context.Cause(ctx)
+	}))
+	if !errors.Is(res.Err, ErrTimeout) {
+		t.Fatalf("Try = %v, want %v", res.Err, ErrTimeout)
+	}
+	select {
+	case cause := <-stopped:
+		if !errors.Is(cause, ErrTimeout) {
+			t.Fatalf("context ended with %v, want %v", cause, ErrTimeout)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the timed out function's context was not cancelled")
+	}
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Option type to configure a handler created by NewHandler or Child, or a single function when passed after the
+// arguments of WrapFunction, where it overrides the settings of the handler running the function
+type Option func(*FunctionHandlerImpl)
+
+// NewHandler function to create a handler configured by opts, applied in order, ready to be shared between goroutines
+func NewHandler(opts ...Option) *FunctionHandlerImpl {
+	fhi := &FunctionHandlerImpl{}
+	for _, opt := range opts {
+		opt(fhi)
+	}
+	return fhi
+}
+
+// WithName function to set the name of the child handler, or the name of the function when passed to WrapFunction
+func WithName(name string) Option {
+	return func(fhi *FunctionHandlerImpl) {
+		fhi.SetName(name)
+	}
+}
+
+// WithTimeout function to set the timeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(fhi *FunctionHandlerImpl) {
+		fhi.SetTimeout(timeout)
+	}
+}
+
+// WithRetries function to set the retries
+func WithRetries(retries int) Option {
+	return func(fhi *FunctionHandlerImpl) {
+		fhi.SetRetry(retries)
+	}
+}
+
+// WithBackoff function to set the backoff between retries
+func WithBackoff(backoff Backoff) Option {
+	return func(fhi *FunctionHandlerImpl) {
+		fhi.SetBackoff(backoff)
+	}
+}
+
+// WithMode function to set the execution mode of the handler
+func WithMode(mode ExecutionMode) Option {
+	return func(fhi *FunctionHandlerImpl) {
+		fhi.SetMode(mode)
+	}
+}
+
+// WithParallel function to set the mode of the handler to ModeParallel, or to ModeSequential when isParallel is false
+func WithParallel(isParallel bool) Option {
+	return func(fhi *FunctionHandlerImpl) {
+		if isParallel {
+			fhi.SetMode(ModeParallel)
+		} else {
+			fhi.SetMode(ModeSequential)
+		}
+	}
+}
+
+// WithLogger function to set the Logger of the handler
+func WithLogger(logger Logger) Option {
+	return func(fhi *FunctionHandlerImpl) {
+		fhi.SetLogger(logger)
+	}
+}
+
+// children struct to hold the handlers created by Child, so Close can close them too
+type children struct {
+	mu       sync.Mutex
+	handlers []*FunctionHandlerImpl
+}
+
+// Child method to create a handler for a subsystem that shares this handler's sinks and limits, then apply opts.
+//
+// Shared by reference, so later changes to this handler are seen by the child unless it sets its own:
+// the metrics, the Logger, the Tracer, the asynchronous logger, the circuit breakers, the rate limit and the
+// concurrent batch limit, which a child's batches count against in addition to any limit of its own. The
+// lifecycle hooks of this handler also fire for the child, after its own.
+//
+// Copied by value, so later changes on either side stay separate: every other setting, such as the timeout,
+// retries, mode, name, stagger, clock, error handlers and argument transformers.
+//
+// Closing this handler closes its children.
+func (fhi *FunctionHandlerImpl) Child(opts ...Option) *FunctionHandlerImpl {
+	child := fhi.derive(opts)
+	fhi.children.mu.Lock()
+	fhi.children.handlers = append(fhi.children.handlers, child)
+	fhi.children.mu.Unlock()
+	return child
+}
+
+// With method to create a handler like Child for a single batch or call site, such as one needing a longer
+// timeout, then apply opts. Unlike a child it is not remembered, so it is collected once no longer used, and
+// Close on this handler does not close it.
+func (fhi *FunctionHandlerImpl) With(opts ...Option) *FunctionHandlerImpl {
+	return fhi.derive(opts)
+}
+
+// Clone method to create a handler like With without options
+func (fhi *FunctionHandlerImpl) Clone() *FunctionHandlerImpl {
+	return fhi.derive(nil)
+}
+
+// derive method to create a handler sharing this one's sinks and limits with a copy of its other settings,
+// then apply opts
+func (fhi *FunctionHandlerImpl) derive(opts []Option) *FunctionHandlerImpl {
+	s := *fhi.settings()
+	// the shared sinks and limits are resolved through the parent, so the child only keeps its own
+	s.metrics, s.logSink, s.tracer, s.circuits, s.rateLimiter, s.batchSlots = nil, nil, nil, nil, nil, nil
+	s.hooks = [hookKinds][]func(e HookEvent){}
+	child := &FunctionHandlerImpl{parent: fhi}
+	child.current.Store(&s)
+	for _, opt := range opts {
+		opt(child)
+	}
+	return child
+}
+
+// getMetrics method to return the handler's metrics, or the nearest ancestor's
+func (fhi *FunctionHandlerImpl) getMetrics() Metrics {
+	for h := fhi; h != nil; h = h.parent {
+		if metrics := h.settings().metrics; metrics != nil {
+			return metrics
+		}
+	}
+	return nil
+}
+
+// getLogger method to return the handler's asynchronous logger, or the nearest ancestor's
+func (fhi *FunctionHandlerImpl) getLogger() *asyncLogger {
+	for h := fhi; h != nil; h = h.parent {
+		if logger := h.logger.Load(); logger != nil {
+			return logger
+		}
+	}
+	return nil
+}
+
+// closeChildren method to close every handler created by Child
+func (fhi *FunctionHandlerImpl) closeChildren() {
+	fhi.children.mu.Lock()
+	handlers := slices.Clone(fhi.children.handlers)
+	fhi.children.mu.Unlock()
+	for _, child := range handlers {
+		child.Close()
+	}
+}
+
+// optionType is the reflect type of an Option passed among the arguments of WrapFunction
+var optionType = reflect.TypeOf(Option(nil))
+
+// splitOptions function to separate the trailing options from the arguments of function.
+// Nothing is split off when function itself takes an Option last.
+func splitOptions(function interface{}, args []interface{}) ([]interface{}, []Option) {
+	if funcType := reflect.TypeOf(function); funcType != nil && funcType.Kind() == reflect.Func && funcType.NumIn() > 0 {
+		if last := funcType.In(funcType.NumIn() - 1); last == optionType || (funcType.IsVariadic() && last.Elem() == optionType) {
+			return args, nil
+		}
+	}
+	i := len(args)
+	for i > 0 {
+		if _, ok := args[i-1].(Option); !ok {
+			break
+		}
+		i--
+	}
+	if i == len(args) {
+		return args, nil
+	}
+	opts := make([]Option, 0, len(args)-i)
+	for _, arg := range args[i:] {
+		opts = append(opts, arg.(Option))
+	}
+	return args[:i:i], opts
+}
+
+// overridden method to return the handler a function with per-function options runs with. It keeps this
+// handler's name, since WithName names the function.
+func (fhi *FunctionHandlerImpl) overridden(opts []Option) *FunctionHandlerImpl {
+	override := fhi.derive(opts)
+	override.SetName(fhi.settings().name)
+	return override
+}
+
+// optionsName function to return the name set by WithName among opts, or ""
+func optionsName(opts []Option) string {
+	probe := &FunctionHandlerImpl{}
+	for _, opt := range opts {
+		opt(probe)
+	}
+	return probe.settings().name
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingMetrics struct to count the functions started through it
+type countingMetrics struct{ started atomic.Int32 }
+
+func (m *countingMetrics) Started(handler, function string)                                       { m.started.Add(1) }
+func (m *countingMetrics) Retried(handler, function string)                                       {}
+func (m *countingMetrics) Finished(handler, function string, outcome Outcome, took time.Duration) {}
+
+// TestChildSharesSinksAndLimits pins what a child sees of changes made to its parent after it was created
+func TestChildSharesSinksAndLimits(t *testing.T) {
+	pass := func(err error) error { return err }
+	parent := NewHandler()
+	child := parent.Child(WithName("child"))
+	metrics, logger := &countingMetrics{}, &recordingLogger{}
+	var hooked atomic.Int32
+	parent.SetMetrics(metrics)
+	parent.SetLogger(logger)
+	parent.SetRateLimit(1000, 1)
+	parent.OnStart(func(HookEvent) { hooked.Add(1) })
+
+	if _, res := child.Try(pass, child.WrapFunction(func() error { return errBoom })); !errors.Is(res.Err, errBoom) {
+		t.Fatalf("got %v, want %v", res.Err, errBoom)
+	}
+	if metrics.started.Load() != 1 || hooked.Load() != 1 {
+		t.Fatalf("parent's metrics saw %d starts and hooks %d, want 1 each", metrics.started.Load(), hooked.Load())
+	}
+	if len(logger.attrs) == 0 {
+		t.Fatal("child did not log through the parent's logger")
+	}
+	if child.getRateLimiter() != parent.getRateLimiter() {
+		t.Fatal("child does not share the parent's rate limiter")
+	}
+
+	// a child's own sink takes the place of the parent's, for the child only
+	own := &countingMetrics{}
+	child.SetMetrics(own)
+	child.Try(pass, child.WrapFunction(func() {}))
+	parent.Try(pass, parent.WrapFunction(func() {}))
+	if own.started.Load() != 1 || metrics.started.Load() != 2 {
+		t.Fatalf("child's metrics saw %d starts and parent's %d, want 1 and 2", own.started.Load(), metrics.started.Load())
+	}
+}
+
+func TestChildCountsAgainstParentBatchLimit(t *testing.T) {
+	pass := func(err error) error { return err }
+	parent := NewHandler()
+	child := parent.Child()
+	parent.SetMaxConcurrentBatches(1)
+	release := make(chan struct{})
+	running := make(chan struct{})
+	go parent.Try(pass, parent.WrapFunction(func() {
+		close(running)
+		<-release
+	}))
+	<-running
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, res := child.TryContext(ctx, pass, child.WrapFunction(func() {})); !errors.Is(res.Err, context.DeadlineExceeded) {
+		t.Fatalf("child ran while the parent's only slot was taken: %v", res.Err)
+	}
+	close(release)
+}
+
+// TestChildIsolatesSettings pins that the copied settings change on one side only
+func TestChildIsolatesSettings(t *testing.T) {
+	parent := NewHandler(WithTimeout(time.Second), WithRetries(1), WithMode(ModeParallel))
+	child := parent.Child(WithRetries(4))
+	parent.SetTimeout(time.Minute)
+	parent.SetMode(ModeSequential)
+	child.SetTimeout(time.Hour)
+	got, want := child.Config(), Config{Timeout: time.Hour, Retries: 4, Mode: ModeParallel}
+	if got.Timeout != want.Timeout || got.Retries != want.Retries || got.Mode != want.Mode {
+		t.Fatalf("child has timeout %s, retries %d, mode %s, want %s, %d, %s", got.Timeout, got.Retries, got.Mode, want.Timeout, want.Retries, want.Mode)
+	}
+	got, want = parent.Config(), Config{Timeout: time.Minute, Retries: 1, Mode: ModeSequential}
+	if got.Timeout != want.Timeout || got.Retries != want.Retries || got.Mode != want.Mode {
+		t.Fatalf("parent has timeout %s, retries %d, mode %s, want %s, %d, %s", got.Timeout, got.Retries, got.Mode, want.Timeout, want.Retries, want.Mode)
+	}
+}
+
+func TestCloseClosesChildren(t *testing.T) {
+	root := NewHandler()
+	child := root.Child()
+	grandchild := child.Child()
+	detached := root.With()
+	for _, h := range []*FunctionHandlerImpl{child, grandchild, detached} {
+		h.SetAsyncLogging(8)
+	}
+	root.Close()
+	if child.logger.Load() != nil || grandchild.logger.Load() != nil {
+		t.Fatal("Close left a child's asynchronous logger running")
+	}
+	if detached.logger.Load() == nil {
+		t.Fatal("Close closed a handler created by With")
+	}
+	detached.Close()
+}
+
+func TestCloneAndWith(t *testing.T) {
+	tests := []struct {
+		name   string
+		derive func(base *FunctionHandlerImpl) *FunctionHandlerImpl
+		want   Config
+	}{
+		{"clone", (*FunctionHandlerImpl).Clone, Config{Timeout: time.Second, Retries: 1, Mode: ModeParallel}},
+		{"with nothing", func(base *FunctionHandlerImpl) *FunctionHandlerImpl { return base.With() }, Config{Timeout: time.Second, Retries: 1, Mode: ModeParallel}},
+		{"with a longer timeout", func(base *FunctionHandlerImpl) *FunctionHandlerImpl {
+			return base.With(WithTimeout(time.Minute))
+		}, Config{Timeout: time.Minute, Retries: 1, Mode: ModeParallel}},
+		{"with options in order", func(base *FunctionHandlerImpl) *FunctionHandlerImpl {
+			return base.With(WithRetries(3), WithMode(ModeSequential), WithRetries(5))
+		}, Config{Timeout: time.Second, Retries: 5, Mode: ModeSequential}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := NewHandler(WithTimeout(time.Second), WithRetries(1), WithMode(ModeParallel))
+			derived := tt.derive(base)
+			// changing the derived handler afterwards leaves the base alone too
+			derived.UseArgTransformer(func(funcName string, args []interface{}) ([]interface{}, error) { return args, nil })
+			got := derived.Config()
+			if got.Timeout != tt.want.Timeout || got.Retries != tt.want.Retries || got.Mode != tt.want.Mode {
+				t.Fatalf("derived has timeout %s, retries %d, mode %s, want %s, %d, %s", got.Timeout, got.Retries, got.Mode, tt.want.Timeout, tt.want.Retries, tt.want.Mode)
+			}
+			got = base.Config()
+			if got.Timeout != time.Second || got.Retries != 1 || got.Mode != ModeParallel || len(base.settings().argTransformers) != 0 {
+				t.Fatalf("deriving changed the base to timeout %s, retries %d, mode %s", got.Timeout, got.Retries, got.Mode)
+			}
+		})
+	}
+}
+
+func TestWithRunsBatchesWithItsSettings(t *testing.T) {
+	base := NewHandler(WithTimeout(10*time.Millisecond), WithLogger(&recordingLogger{}))
+	patient := base.With(WithTimeout(time.Second))
+	slow := func() { time.Sleep(50 * time.Millisecond) }
+	pass := func(err error) error { return err }
+	if _, res := patient.Try(pass, patient.WrapFunction(slow)); res.IsErr() {
+		t.Fatalf("derived handler with the longer timeout = %v", res.Err)
+	}
+	if _, res := base.Try(pass, base.WrapFunction(slow)); !errors.Is(res.Err, ErrTimeout) {
+		t.Fatalf("base handler = %v, want %v", res.Err, ErrTimeout)
+	}
+}
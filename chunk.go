@@ -0,0 +1,30 @@
+package handler
+
+// TryChunked method to run a large batch in chunks of chunkSize functions, calling onChunk after each one.
+// onChunk receives the chunk's results and error; returning an error from it aborts the remaining chunks.
+// Results are only returned across chunks when SetAccumulateChunks(true) was called, to bound memory.
+func (fhi *FunctionHandlerImpl) TryChunked(chunkSize int, onChunk func(chunkIndex int, results []any, err error) error, handler interface{}, funcs ...func() Result[any]) ([]any, Result[any]) {
+	if chunkSize <= 0 {
+		err := fhi.errorf("chunk size must be positive, got %d", chunkSize)
+		fhi.LogError(err)
+		return nil, Err[any](err)
+	}
+	if len(funcs) == 0 {
+		err := fhi.errorf("%w", ErrNoFunctions)
+		fhi.LogError(err)
+		return nil, Err[any](err)
+	}
+	results := []any{}
+	for chunkIndex, start := 0, 0; start < len(funcs); chunkIndex, start = chunkIndex+1, start+chunkSize {
+		end := min(start+chunkSize, len(funcs))
+		chunkResults, res := fhi.Try(handler, funcs[start:end]...)
+		if err := onChunk(chunkIndex, chunkResults, res.Err); err != nil {
+			fhi.LogError(err)
+			return nil, Err[any](err)
+		}
+		if fhi.settings().accumulateChunks {
+			results = append(results, chunkResults...)
+		}
+	}
+	return results, Ok[any](nil)
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestTryChunked(t *testing.T) {
+	errStop := errors.New("stop")
+	tests := []struct {
+		name       string
+		size       int
+		functions  int
+		accumulate bool
+		stopAt     int // chunk whose onChunk returns errStop, or -1
+		wantChunks string
+		want       string
+		wantErr    error
+	}{
+		{"even chunks", 2, 4, false, -1, "[[0 1] [2 3]]", "[]", nil},
+		{"last partial chunk", 2, 5, false, -1, "[[0 1] [2 3] [4]]", "[]", nil},
+		{"chunk larger than the batch", 10, 3, false, -1, "[[0 1 2]]", "[]", nil},
+		{"accumulated", 2, 3, true, -1, "[[0 1] [2]]", "[0 1 2]", nil},
+		{"onChunk aborts the rest", 2, 6, true, 0, "[[0 1]]", "[]", errStop},
+		{"zero size", 0, 3, false, -1, "[]", "[]", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler(WithLogger(&recordingLogger{}))
+			fh.SetAccumulateChunks(tt.accumulate)
+			funcs := make([]func() Result[any], tt.functions)
+			for i := range funcs {
+				funcs[i] = fh.WrapFunction(func() int { return i })
+			}
+			chunks := [][]any{}
+			onChunk := func(chunkIndex int, results []any, err error) error {
+				if chunkIndex != len(chunks) || err != nil {
+					t.Errorf("onChunk(%d, %v, %v) after %d chunks", chunkIndex, results, err, len(chunks))
+				}
+				chunks = append(chunks, results)
+				if chunkIndex == tt.stopAt {
+					return errStop
+				}
+				return nil
+			}
+			results, res := fh.TryChunked(tt.size, onChunk, func(err error) error { return err }, funcs...)
+			switch {
+			case tt.wantErr != nil && !errors.Is(res.Err, tt.wantErr):
+				t.Fatalf("TryChunked = %v, want %v", res.Err, tt.wantErr)
+			case tt.wantErr == nil && res.IsErr() != (tt.size <= 0):
+				t.Fatalf("TryChunked = %v, want an error only for a chunk size below one", res.Err)
+			}
+			if fmt.Sprint(chunks) != tt.wantChunks {
+				t.Fatalf("chunks = %v, want %s", chunks, tt.wantChunks)
+			}
+			if res.IsOk() && fmt.Sprint(results) != tt.want {
+				t.Fatalf("TryChunked = %v, want %s", results, tt.want)
+			}
+		})
+	}
+}
+
+func TestTryChunkedPassesChunkErrors(t *testing.T) {
+	fh := NewHandler(WithLogger(&recordingLogger{}))
+	var errs []error
+	funcs := []func() Result[any]{
+		fh.WrapFunction(func() error { return errBoom }),
+		fh.WrapFunction(func() int { return 1 }),
+	}
+	_, res := fh.TryChunked(1, func(chunkIndex int, results []any, err error) error {
+		errs = append(errs, err)
+		return nil
+	}, func(err error) error { return err }, funcs...)
+	if res.IsErr() {
+		t.Fatalf("TryChunked = %v, want the failure left to onChunk", res.Err)
+	}
+	if len(errs) != 2 || !errors.Is(errs[0], errBoom) || errs[1] != nil {
+		t.Fatalf("onChunk got %v, want the first chunk's failure only", errs)
+	}
+}
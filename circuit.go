@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CircuitState type for the state of a function's circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets every attempt through, the state of a function without recent failures
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails every attempt with ErrCircuitOpen without calling the function
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probe attempts through to test whether the function recovered
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitMetrics interface that a Metrics implementation can also implement to observe circuit breakers
+type CircuitMetrics interface {
+	// CircuitChanged reports a function's circuit breaker moving to state
+	CircuitChanged(handler, function string, state CircuitState)
+}
+
+// SetCircuitBreaker method to give every function, by name, a circuit breaker. After threshold failed attempts
+// in a row the circuit opens and attempts fail with ErrCircuitOpen, without being retried, for openFor. Then up
+// to probes attempts, at least one, are let through: the circuit closes when they all succeed and opens again
+// when one fails.
+// Argument validation and transformer failures do not count. A threshold of zero or less turns the breakers
+// off; setting them resets every circuit.
+func (fhi *FunctionHandlerImpl) SetCircuitBreaker(threshold int, openFor time.Duration, probes int) {
+	fhi.configure(func(s *settings) { s.circuits = newCircuits(threshold, openFor, probes) })
+}
+
+// newCircuits function to create the circuit breakers of SetCircuitBreaker, nil when they are off
+func newCircuits(threshold int, openFor time.Duration, probes int) *circuits {
+	if threshold <= 0 {
+		return nil
+	}
+	return &circuits{threshold: threshold, openFor: openFor, probes: probes, byName: map[string]*circuit{}}
+}
+
+// Circuit method to return the state of the circuit breaker of the function called name
+func (fhi *FunctionHandlerImpl) Circuit(name string) CircuitState {
+	cs := fhi.getCircuits()
+	if cs == nil {
+		return CircuitClosed
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if c, ok := cs.byName[name]; ok {
+		return c.state
+	}
+	return CircuitClosed
+}
+
+// Circuits method to return the state of the circuit breaker of every function that has run, by name
+func (fhi *FunctionHandlerImpl) Circuits() map[string]CircuitState {
+	states := map[string]CircuitState{}
+	cs := fhi.getCircuits()
+	if cs == nil {
+		return states
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	for name, c := range cs.byName {
+		states[name] = c.state
+	}
+	return states
+}
+
+// circuits struct to hold the settings and the circuit breakers of a handler
+type circuits struct {
+	threshold int
+	openFor   time.Duration
+	probes    int
+	mu        sync.Mutex
+	byName    map[string]*circuit
+}
+
+// circuit struct to hold the state of one function's circuit breaker
+type circuit struct {
+	state    CircuitState
+	failures int       // failed attempts in a row while closed
+	until    time.Time // when an open circuit lets probes through
+	probing  int       // probes started while half-open
+	passed   int       // probes succeeded while half-open
+}
+
+// getCircuits method to return the handler's circuit breakers, or the nearest ancestor's
+func (fhi *FunctionHandlerImpl) getCircuits() *circuits {
+	for h := fhi; h != nil; h = h.parent {
+		if circuits := h.settings().circuits; circuits != nil {
+			return circuits
+		}
+	}
+	return nil
+}
+
+// allowAttempt method to check the circuit breaker of the function called name before an attempt
+func (fhi *FunctionHandlerImpl) allowAttempt(name string) error {
+	cs := fhi.getCircuits()
+	if cs == nil {
+		return nil
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	c, ok := cs.byName[name]
+	if !ok {
+		c = &circuit{}
+		cs.byName[name] = c
+	}
+	now := fhi.getClock().Now()
+	if c.state == CircuitOpen && !now.Before(c.until) {
+		c.state, c.probing, c.passed = CircuitHalfOpen, 0, 0
+		fhi.circuitChanged(name, c.state)
+	}
+	switch {
+	case c.state == CircuitOpen:
+		return fhi.errorf("%w for %s, %s left", ErrCircuitOpen, name, c.until.Sub(now))
+	case c.state == CircuitHalfOpen && c.probing >= max(cs.probes, 1):
+		return fhi.errorf("%w for %s, %d probes running", ErrCircuitOpen, name, c.probing)
+	case c.state == CircuitHalfOpen:
+		c.probing++
+	}
+	return nil
+}
+
+// recordAttempt method to update the circuit breaker of the function called name with the result of an attempt
+// run with ctx. Failures that say nothing about the function, such as a cancelled batch, only free the probe.
+func (fhi *FunctionHandlerImpl) recordAttempt(ctx context.Context, name string, err error) {
+	cs := fhi.getCircuits()
+	if cs == nil {
+		return
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	c, ok := cs.byName[name]
+	if !ok {
+		return
+	}
+	cancelled := ctx.Err() != nil && !errors.Is(context.Cause(ctx), ErrTimeout)
+	if errors.Is(err, ErrValidation) || errors.Is(err, ErrArgTransform) || err != nil && cancelled {
+		if c.state == CircuitHalfOpen {
+			c.probing--
+		}
+		return
+	}
+	open := func() {
+		c.state, c.until = CircuitOpen, fhi.getClock().Now().Add(cs.openFor)
+		fhi.circuitChanged(name, c.state)
+	}
+	switch c.state {
+	case CircuitClosed:
+		if err == nil {
+			c.failures = 0
+			return
+		}
+		if c.failures++; c.failures >= cs.threshold {
+			open()
+		}
+	case CircuitHalfOpen:
+		if err != nil {
+			open()
+			return
+		}
+		if c.passed++; c.passed >= max(cs.probes, 1) {
+			c.state, c.failures = CircuitClosed, 0
+			fhi.circuitChanged(name, c.state)
+		}
+	}
+}
+
+// circuitChanged method to report a circuit breaker's new state to the metrics
+func (fhi *FunctionHandlerImpl) circuitChanged(name string, state CircuitState) {
+	if metrics, ok := fhi.getMetrics().(CircuitMetrics); ok {
+		metrics.CircuitChanged(fhi.settings().name, name, state)
+	}
+}
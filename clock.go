@@ -0,0 +1,20 @@
+package handler
+
+import "time"
+
+// Clock interface to abstract time so delays can be controlled in tests
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock struct to implement Clock with the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
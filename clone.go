@@ -0,0 +1,79 @@
+package handler
+
+import "reflect"
+
+// copyArg function to give a wrapped function its own copy of a slice, map or pointer-to-struct argument.
+// Other arguments are already passed by value and are returned unchanged.
+func copyArg(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return cloneValue(v)
+	case reflect.Pointer:
+		if v.Type().Elem().Kind() == reflect.Struct {
+			return cloneValue(v)
+		}
+	case reflect.Interface: // an argument wrapped for an interface parameter is copied like its dynamic value
+		if !v.IsNil() {
+			return copyArg(v.Elem()).Convert(v.Type())
+		}
+	}
+	return v
+}
+
+// cloneValue function to deep-copy a value using reflection.
+// Limitations: channels and funcs are shared rather than copied, unexported struct
+// fields are copied shallowly, and cyclic data structures are not supported.
+func cloneValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(cloneValue(iter.Key()), cloneValue(iter.Value()))
+		}
+		return c
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(cloneValue(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(cloneValue(v.Elem()))
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(cloneValue(v.Field(i)))
+			}
+		}
+		return c
+	default:
+		return v
+	}
+}
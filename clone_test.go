@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// TestCopyArgs runs two parallel functions writing to the same map. Without SetCopyArgs they race,
+// which go test -race reports; with it each call gets its own copy and the caller's map is untouched.
+func TestCopyArgs(t *testing.T) {
+	fh := NewHandler(WithMode(ModeParallel))
+	fh.SetCopyArgs(true)
+	shared := map[string]int{"n": 0}
+	var started sync.WaitGroup
+	started.Add(2)
+	write := func(m map[string]int, v int) int {
+		started.Done()
+		started.Wait() // both functions hold the map at the same time
+		m["n"] = v
+		m["writer"] = v
+		return m["n"]
+	}
+	results, res := fh.Try(func(err error) error { return err },
+		fh.WrapFunction(write, shared, 1),
+		fh.WrapFunction(write, shared, 2),
+	)
+	if res.IsErr() {
+		t.Fatal(res.Err)
+	}
+	if len(results) != 2 || results[0] == results[1] {
+		t.Fatalf("got %v, want each function to read back its own value", results)
+	}
+	if want := map[string]int{"n": 0}; !reflect.DeepEqual(shared, want) {
+		t.Fatalf("caller's map changed to %v", shared)
+	}
+}
+
+func TestCopyArg(t *testing.T) {
+	type inner struct{ Tags []string }
+	type outer struct {
+		Inner  *inner
+		Counts map[string]int
+		Ch     chan int
+		secret []int
+	}
+	ch := make(chan int)
+	orig := &outer{Inner: &inner{Tags: []string{"a"}}, Counts: map[string]int{"a": 1}, Ch: ch, secret: []int{1}}
+	c := copyArg(reflect.ValueOf(orig)).Interface().(*outer)
+	if c == orig || c.Inner == orig.Inner {
+		t.Fatal("pointers were not copied")
+	}
+	c.Inner.Tags[0] = "b"
+	c.Counts["a"] = 2
+	if orig.Inner.Tags[0] != "a" || orig.Counts["a"] != 1 {
+		t.Fatalf("copy shares data with the original: %+v %+v", orig.Inner, orig.Counts)
+	}
+	// documented limitations: channels are shared and unexported fields copied shallowly
+	if c.Ch != ch {
+		t.Fatal("channel was not shared")
+	}
+	if &c.secret[0] != &orig.secret[0] {
+		t.Fatal("unexported field was copied deeply")
+	}
+	// values that are not slices, maps or pointers to structs pass through
+	n := 1
+	if got := copyArg(reflect.ValueOf(&n)).Interface().(*int); got != &n {
+		t.Fatal("pointer to a non-struct was copied")
+	}
+}
+
+func TestCopyArgsForInterfaceParameter(t *testing.T) {
+	fh := NewHandler()
+	fh.SetCopyArgs(true)
+	shared := map[string]int{"n": 0}
+	_, res := fh.Try(func(err error) error { return err }, fh.WrapFunction(func(v any) {
+		v.(map[string]int)["n"] = 1
+	}, shared))
+	if res.IsErr() {
+		t.Fatal(res.Err)
+	}
+	if shared["n"] != 0 {
+		t.Fatal("a map passed for an interface parameter was not copied")
+	}
+}
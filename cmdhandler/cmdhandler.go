@@ -0,0 +1,111 @@
+// Package cmdhandler wraps external commands as functions for the handler package, so os/exec stays out of the core.
+package cmdhandler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	handler "github.com/Spongebob959/handler"
+)
+
+// ExitError struct to report a command that exited with a non-zero code, with what it wrote to stderr
+type ExitError struct {
+	Command string
+	Code    int
+	Stderr  string
+}
+
+func (e *ExitError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("%s exited with code %d", e.Command, e.Code)
+	}
+	return fmt.Sprintf("%s exited with code %d: %s", e.Command, e.Code, strings.TrimSpace(e.Stderr))
+}
+
+// Option type to configure how commands are started
+type Option func(*Runner)
+
+// Env function to set the environment of the commands, as in exec.Cmd.Env
+func Env(env ...string) Option {
+	return func(r *Runner) {
+		r.env = env
+	}
+}
+
+// Dir function to set the working directory of the commands
+func Dir(dir string) Option {
+	return func(r *Runner) {
+		r.dir = dir
+	}
+}
+
+// Stdin function to feed data to the standard input of every attempt
+func Stdin(data []byte) Option {
+	return func(r *Runner) {
+		r.stdin = data
+	}
+}
+
+// WaitDelay function to set how long to wait for the command's output after it was killed, one second by default
+func WaitDelay(d time.Duration) Option {
+	return func(r *Runner) {
+		r.waitDelay = d
+	}
+}
+
+// Runner struct to create wrapped commands run by a handler
+type Runner struct {
+	fh        handler.FunctionHandler
+	env       []string
+	dir       string
+	stdin     []byte
+	waitDelay time.Duration
+}
+
+// New function to create a runner whose commands are wrapped by fh
+func New(fh handler.FunctionHandler, opts ...Option) *Runner {
+	r := &Runner{fh: fh, waitDelay: time.Second}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// WrapCommand method to create a function that runs the command with a fresh exec.Cmd on every attempt.
+// Its value is the command's stdout; a non-zero exit becomes an *ExitError holding stderr. The command runs with
+// the context the handler injects: when the timeout fires or the batch is cancelled, the command's whole
+// process group is killed and the error wraps the cancellation's cause.
+func (r *Runner) WrapCommand(name string, args ...string) func() handler.Result[any] {
+	return r.fh.WrapNamed(strings.Join(append([]string{name}, args...), " "), func(ctx context.Context) (string, error) {
+		return r.run(ctx, name, args)
+	})
+}
+
+// run method to run the command once, killed when ctx is cancelled; the error then wraps ctx's cause
+func (r *Runner) run(ctx context.Context, name string, args []string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = r.env
+	cmd.Dir = r.dir
+	if r.stdin != nil {
+		cmd.Stdin = bytes.NewReader(r.stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = r.waitDelay
+	killGroup(cmd)
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return stdout.String(), fmt.Errorf("%s killed: %w", name, context.Cause(ctx))
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout.String(), &ExitError{Command: name, Code: exitErr.ExitCode(), Stderr: stderr.String()}
+	}
+	return stdout.String(), err
+}
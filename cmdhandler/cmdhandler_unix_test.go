@@ -0,0 +1,110 @@
+//go:build unix
+
+package cmdhandler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	handler "github.com/Spongebob959/handler"
+)
+
+func TestWrapCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		opts     []Option
+		want     string
+		wantCode int
+		wantErr  string
+	}{
+		{"stdout is the value", "echo hello", nil, "hello\n", 0, ""},
+		{"exit code and stderr", "echo partial; echo oops >&2; exit 3", nil, "", 3, "oops"},
+		{"stdin and env", `cat; echo "$GREETING"`, []Option{Stdin([]byte("in\n")), Env("GREETING=hi")}, "in\nhi\n", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := handler.NewHandler()
+			res := New(fh, tt.opts...).WrapCommand("sh", "-c", tt.script)()
+			if tt.wantCode == 0 {
+				if res.IsErr() || res.Values[0] != tt.want {
+					t.Fatalf("got %v, want %q", res, tt.want)
+				}
+				return
+			}
+			var exitErr *ExitError
+			if !errors.As(res.Err, &exitErr) || exitErr.Code != tt.wantCode || strings.TrimSpace(exitErr.Stderr) != tt.wantErr {
+				t.Fatalf("got %v, want exit code %d with stderr %q", res.Err, tt.wantCode, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWrapCommandFreshCmdPerRetry(t *testing.T) {
+	dir := t.TempDir()
+	fh := handler.NewHandler(handler.WithRetries(2), handler.WithBackoff(handler.ConstantBackoff(0)))
+	// every attempt counts itself in a file and only the third succeeds
+	script := `n=$(cat count 2>/dev/null || echo 0); n=$((n+1)); echo $n > count; [ $n -ge 3 ] || exit 1; echo "attempt $n"`
+	res := fh.RunWithRetry(context.Background(), New(fh, Dir(dir)).WrapCommand("sh", "-c", script))
+	if res.IsErr() || res.Values[0] != "attempt 3\n" {
+		t.Fatalf("got %v, want success on attempt 3", res)
+	}
+}
+
+func TestWrapCommandTimeoutKillsProcessGroup(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	fh := handler.NewHandler(handler.WithTimeout(200 * time.Millisecond))
+	// the shell starts a sleep of its own and waits for it; both have to be killed
+	script := `sleep 30 & echo $! > ` + pidFile + `; wait`
+	started := time.Now()
+	_, res := fh.Try(func(err error) error { return err }, New(fh, WaitDelay(100*time.Millisecond)).WrapCommand("sh", "-c", script))
+	if !errors.Is(res.Err, handler.ErrTimeout) {
+		t.Fatalf("got %v, want %v", res.Err, handler.ErrTimeout)
+	}
+	if took := time.Since(started); took > 5*time.Second {
+		t.Fatalf("took %s, want the command killed at the timeout", took)
+	}
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); alive(pid); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatalf("child sleep %d outlived the timeout", pid)
+		}
+	}
+}
+
+func TestWrapCommandKeepsCancelCause(t *testing.T) {
+	errStop := errors.New("shutting down")
+	fh := handler.NewHandler()
+	ctx, cancel := context.WithCancelCause(context.Background())
+	time.AfterFunc(50*time.Millisecond, func() { cancel(errStop) })
+	res := fh.RunWithRetry(ctx, New(fh).WrapCommand("sleep", "30"))
+	// the command's own error names the cause, not just context.Canceled
+	if !errors.Is(res.Err, errStop) || !strings.Contains(res.Err.Error(), "sleep killed: "+errStop.Error()) {
+		t.Fatalf("got %v, want the command killed with the cause %v", res.Err, errStop)
+	}
+}
+
+// alive function to report whether the process pid still runs; a zombie waiting to be reaped does not count
+func alive(pid int) bool {
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return syscall.Kill(pid, 0) == nil && !os.IsNotExist(err)
+	}
+	fields := strings.Fields(string(stat))
+	return len(fields) > 2 && fields[2] != "Z"
+}
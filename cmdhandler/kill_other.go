@@ -0,0 +1,8 @@
+//go:build !unix
+
+package cmdhandler
+
+import "os/exec"
+
+// killGroup function to keep the default cancellation, which kills only the command itself
+func killGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package cmdhandler
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killGroup function to start the command in its own process group and kill the whole group on cancellation,
+// so children it spawned do not outlive it
+func killGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
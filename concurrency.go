@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"sync"
+)
+
+// SetMaxConcurrency method to let at most n functions of a batch run at once in the concurrent modes.
+// Functions beyond the limit wait for a running one to finish before they start, so a batch of thousands of
+// functions does not start thousands of goroutines. Zero or less removes the limit, the default.
+func (fhi *FunctionHandlerImpl) SetMaxConcurrency(n int) {
+	fhi.configure(func(s *settings) { s.maxConcurrency = n })
+}
+
+// dispatch method to call run for every function in its own goroutine, at most the maximum concurrency at a time.
+// It returns at once; functions not started yet are skipped once stop is closed. wait blocks until every
+// started run returned.
+func (fhi *FunctionHandlerImpl) dispatch(funcs []func() Result[any], stop <-chan struct{}, run func(i int, fn func() Result[any])) (wait func()) {
+	cfg := fhi.settings()
+	var wg sync.WaitGroup
+	var slots chan struct{}
+	if cfg.maxConcurrency > 0 {
+		slots = make(chan struct{}, cfg.maxConcurrency)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i, fn := range funcs {
+			if slots != nil {
+				select {
+				case slots <- struct{}{}:
+				case <-stop:
+					return
+				}
+			}
+			wg.Add(1)
+			go func(i int, fn func() Result[any]) {
+				defer wg.Done()
+				if slots != nil {
+					defer func() { <-slots }()
+				}
+				run(i, fn)
+			}(i, fn)
+		}
+	}()
+	return wg.Wait
+}
+
+// runStaggered method to run fn with ctx after the stagger delay of the function at index i, reporting false
+// without running it when stop is closed or ctx ends first. A function whose delayed start would be past ctx's
+// deadline is not run and fails at once with ErrStaggerDeadline.
+func (fhi *FunctionHandlerImpl) runStaggered(ctx context.Context, i int, fn func() Result[any], stop <-chan struct{}) (Result[any], bool) {
+	delay := fhi.staggerDelay(i)
+	if delay <= 0 {
+		return fhi.runFunction(ctx, fn), true
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if start := fhi.getClock().Now().Add(delay); start.After(deadline) {
+			name := nameOf(fn, describe(fn))
+			err := fhi.errorfIn(ctx, "%w: %s would start in %s, %s after the deadline", ErrStaggerDeadline, name, delay, start.Sub(deadline))
+			fhi.logErrorIn(ctx, err)
+			res := Err[any](err)
+			ids, _ := ExecutionIDsFrom(ctx)
+			res.info = &ExecutionInfo{Name: name, BatchID: ids.Batch}
+			return res, true
+		}
+	}
+	select {
+	case <-fhi.getClock().After(delay):
+		return fhi.runFunction(ctx, fn), true
+	case <-ctx.Done():
+		return Result[any]{}, false
+	case <-stop:
+		return Result[any]{}, false
+	}
+}
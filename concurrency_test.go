@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// peakCounter struct to record the most functions running at once
+type peakCounter struct {
+	running, peak, runs atomic.Int32
+}
+
+func (p *peakCounter) run() {
+	p.runs.Add(1)
+	n := p.running.Add(1)
+	defer p.running.Add(-1)
+	for {
+		peak := p.peak.Load()
+		if n <= peak || p.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+}
+
+func TestMaxConcurrency(t *testing.T) {
+	const functions = 12
+	tests := []struct {
+		name  string
+		mode  ExecutionMode
+		limit int
+	}{
+		{"parallel unlimited", ModeParallel, 0},
+		{"parallel one at a time", ModeParallel, 1},
+		{"parallel three at a time", ModeParallel, 3},
+		{"fail fast three at a time", ModeFailFast, 3},
+		{"collect errors three at a time", ModeCollectErrors, 3},
+		{"negative removes the limit", ModeParallel, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler(WithMode(tt.mode))
+			fh.SetMaxConcurrency(tt.limit)
+			var p peakCounter
+			funcs := make([]func() Result[any], functions)
+			for i := range funcs {
+				funcs[i] = fh.WrapFunction(p.run)
+			}
+			if _, res := fh.Try(func(err error) error { return err }, funcs...); res.IsErr() {
+				t.Fatalf("Try = %v", res.Err)
+			}
+			if p.runs.Load() != functions {
+				t.Fatalf("ran %d functions, want %d", p.runs.Load(), functions)
+			}
+			peak := int(p.peak.Load())
+			if tt.limit > 0 && peak > tt.limit {
+				t.Fatalf("%d functions ran at once, want at most %d", peak, tt.limit)
+			}
+			if tt.limit != 1 && peak < 2 {
+				t.Fatalf("%d functions ran at once, want them to run concurrently", peak)
+			}
+		})
+	}
+}
+
+func TestMaxConcurrencyFailFastSkipsWaitingFunctions(t *testing.T) {
+	fh := NewHandler(WithMode(ModeFailFast))
+	fh.SetMaxConcurrency(1)
+	var p peakCounter
+	funcs := []func() Result[any]{fh.WrapFunction(func() error { return errBoom })}
+	for i := 0; i < 10; i++ {
+		funcs = append(funcs, fh.WrapFunction(p.run))
+	}
+	if _, res := fh.Try(func(err error) error { return err }, funcs...); !errors.Is(res.Err, errBoom) {
+		t.Fatalf("Try = %v, want %v", res.Err, errBoom)
+	}
+	time.Sleep(20 * time.Millisecond) // a function already holding the slot may still finish
+	if runs := p.runs.Load(); runs > 1 {
+		t.Fatalf("%d functions waiting for a slot ran after the failure, want at most 1", runs)
+	}
+}
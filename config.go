@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Config struct to hold the plain settings of a handler, for loading them from files and showing them on
+// diagnostics endpoints. In JSON durations are strings such as "2s" and the mode is its name; the backoff
+// has no JSON form.
+type Config struct {
+	Name                 string        `json:"name,omitempty"`
+	Mode                 ExecutionMode `json:"mode"`
+	Timeout              time.Duration `json:"-"`
+	Retries              int           `json:"retries"`
+	Backoff              Backoff       `json:"-"`
+	RetryOnTimeout       bool          `json:"retryOnTimeout,omitempty"`
+	AttemptTimeout       time.Duration `json:"-"`
+	MaxRetryDuration     time.Duration `json:"-"`
+	HedgeDelay           time.Duration `json:"-"`
+	CircuitThreshold     int           `json:"circuitThreshold,omitempty"`
+	CircuitOpenFor       time.Duration `json:"-"`
+	CircuitProbes        int           `json:"circuitProbes,omitempty"`
+	RateLimit            float64       `json:"rateLimit,omitempty"`
+	RateBurst            int           `json:"rateBurst,omitempty"`
+	MaxConcurrency       int           `json:"maxConcurrency,omitempty"`
+	MaxConcurrentBatches int           `json:"maxConcurrentBatches,omitempty"`
+	RejectWhenBusy       bool          `json:"rejectWhenBusy,omitempty"`
+	Stagger              time.Duration `json:"-"`
+	StaggerJitter        float64       `json:"staggerJitter,omitempty"`
+	HandlerRetryLimit    int           `json:"handlerRetryLimit,omitempty"`
+	HandlerRetries       int           `json:"handlerRetries,omitempty"`
+	HandlerBackoff       time.Duration `json:"-"`
+	HandlerTimeout       time.Duration `json:"-"`
+	SlowThreshold        time.Duration `json:"-"`
+	OrderedResults       bool          `json:"orderedResults,omitempty"`
+	CopyArgs             bool          `json:"copyArgs,omitempty"`
+	AccumulateChunks     bool          `json:"accumulateChunks,omitempty"`
+	FlattenSlices        bool          `json:"flattenSlices,omitempty"`
+	DrainChannels        bool          `json:"drainChannels,omitempty"`
+	DiscardValues        bool          `json:"discardValues,omitempty"`
+	PprofLabels          bool          `json:"pprofLabels,omitempty"`
+}
+
+// plainConfig has the fields of Config without its methods, so configJSON can embed it without recursion
+type plainConfig Config
+
+// configJSON struct to give the durations of a Config their string form
+type configJSON struct {
+	plainConfig
+	Timeout          string `json:"timeout,omitempty"`
+	AttemptTimeout   string `json:"attemptTimeout,omitempty"`
+	MaxRetryDuration string `json:"maxRetryDuration,omitempty"`
+	HedgeDelay       string `json:"hedgeDelay,omitempty"`
+	CircuitOpenFor   string `json:"circuitOpenFor,omitempty"`
+	Stagger          string `json:"stagger,omitempty"`
+	HandlerBackoff   string `json:"handlerBackoff,omitempty"`
+	HandlerTimeout   string `json:"handlerTimeout,omitempty"`
+	SlowThreshold    string `json:"slowThreshold,omitempty"`
+}
+
+// MarshalJSON method to encode the config with durations as strings
+func (c Config) MarshalJSON() ([]byte, error) {
+	cj := configJSON{plainConfig: plainConfig(c)}
+	for _, d := range cj.durations(&c) {
+		if *d.value != 0 {
+			*d.text = d.value.String()
+		}
+	}
+	return json.Marshal(cj)
+}
+
+// UnmarshalJSON method to decode the config, rejecting unknown fields
+func (c *Config) UnmarshalJSON(data []byte) error {
+	parsed, _, err := ParseConfig(data, true)
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+// ParseConfig function to decode a config from JSON. Unknown fields are an error when strict is set and are
+// otherwise returned, so callers can warn about typos that would silently do nothing.
+func ParseConfig(data []byte, strict bool) (Config, []string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Config{}, nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	var unknown []string
+	for field := range fields {
+		if !configFields[field] {
+			unknown = append(unknown, field)
+		}
+	}
+	sort.Strings(unknown)
+	if strict && len(unknown) > 0 {
+		return Config{}, unknown, fmt.Errorf("%w: unknown fields %s", ErrInvalidConfig, strings.Join(unknown, ", "))
+	}
+	var cj configJSON
+	if err := json.Unmarshal(data, &cj); err != nil {
+		return Config{}, unknown, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	c := Config(cj.plainConfig)
+	for _, d := range cj.durations(&c) {
+		if *d.text == "" {
+			continue
+		}
+		value, err := time.ParseDuration(*d.text)
+		if err != nil {
+			return Config{}, unknown, fmt.Errorf("%w: %s: %w", ErrInvalidConfig, d.name, err)
+		}
+		*d.value = value
+	}
+	return c, unknown, nil
+}
+
+// configDuration struct to pair a duration of a Config with its string form
+type configDuration struct {
+	name  string
+	value *time.Duration
+	text  *string
+}
+
+// durations method to list the duration fields of c next to their string forms in cj
+func (cj *configJSON) durations(c *Config) []configDuration {
+	return []configDuration{
+		{"timeout", &c.Timeout, &cj.Timeout},
+		{"attemptTimeout", &c.AttemptTimeout, &cj.AttemptTimeout},
+		{"maxRetryDuration", &c.MaxRetryDuration, &cj.MaxRetryDuration},
+		{"hedgeDelay", &c.HedgeDelay, &cj.HedgeDelay},
+		{"circuitOpenFor", &c.CircuitOpenFor, &cj.CircuitOpenFor},
+		{"stagger", &c.Stagger, &cj.Stagger},
+		{"handlerBackoff", &c.HandlerBackoff, &cj.HandlerBackoff},
+		{"handlerTimeout", &c.HandlerTimeout, &cj.HandlerTimeout},
+		{"slowThreshold", &c.SlowThreshold, &cj.SlowThreshold},
+	}
+}
+
+// configFields holds the JSON names of every config field
+var configFields = func() map[string]bool {
+	fields := map[string]bool{}
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Anonymous {
+				collect(field.Type)
+				continue
+			}
+			if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+				fields[name] = true
+			}
+		}
+	}
+	collect(reflect.TypeOf(configJSON{}))
+	return fields
+}()
+
+// Config method to return a snapshot of the handler's current settings. A child without a circuit breaker or
+// rate limit of its own reports those of the ancestor it uses.
+func (fhi *FunctionHandlerImpl) Config() Config {
+	return fhi.effective(fhi.settings()).config()
+}
+
+// effective method to return s, the settings of the handler, with the circuit breakers and rate limiter it
+// resolves through its parent filled in when it has none of its own
+func (fhi *FunctionHandlerImpl) effective(s *settings) *settings {
+	if fhi.parent == nil || (s.circuits != nil && s.rateLimiter != nil) {
+		return s
+	}
+	resolved := *s
+	if resolved.circuits == nil {
+		resolved.circuits = fhi.parent.getCircuits()
+	}
+	if resolved.rateLimiter == nil {
+		resolved.rateLimiter = fhi.parent.getRateLimiter()
+	}
+	return &resolved
+}
+
+// config method to return the plain settings as a Config
+func (s *settings) config() Config {
+	var circuitThreshold, circuitProbes int
+	var circuitOpenFor time.Duration
+	if cs := s.circuits; cs != nil {
+		circuitThreshold, circuitOpenFor, circuitProbes = cs.threshold, cs.openFor, cs.probes
+	}
+	var rateLimit float64
+	var rateBurst int
+	if bucket, ok := s.rateLimiter.(*tokenBucket); ok {
+		rateLimit, rateBurst = bucket.rate, int(bucket.burst)
+	}
+	return Config{
+		Name:                 s.name,
+		Mode:                 s.mode,
+		Timeout:              s.timeout,
+		Retries:              s.retries,
+		Backoff:              s.backoff,
+		RetryOnTimeout:       s.retryOnTimeout,
+		AttemptTimeout:       s.attemptTimeout,
+		MaxRetryDuration:     s.maxRetryDuration,
+		HedgeDelay:           s.hedgeDelay,
+		CircuitThreshold:     circuitThreshold,
+		CircuitOpenFor:       circuitOpenFor,
+		CircuitProbes:        circuitProbes,
+		RateLimit:            rateLimit,
+		RateBurst:            rateBurst,
+		MaxConcurrency:       s.maxConcurrency,
+		MaxConcurrentBatches: cap(s.batchSlots),
+		RejectWhenBusy:       s.rejectWhenBusy,
+		Stagger:              s.stagger,
+		StaggerJitter:        s.staggerJitter,
+		HandlerRetryLimit:    s.handlerRetryLimit,
+		HandlerRetries:       s.handlerRetries,
+		HandlerBackoff:       s.handlerBackoff,
+		HandlerTimeout:       s.handlerTimeout,
+		SlowThreshold:        s.slowThreshold,
+		OrderedResults:       s.orderedResults,
+		CopyArgs:             s.copyArgs,
+		AccumulateChunks:     s.accumulateChunks,
+		FlattenSlices:        s.flattenSlices,
+		DrainChannels:        s.drainChannels,
+		DiscardValues:        s.discardValues,
+		PprofLabels:          s.pprofLabels,
+	}
+}
+
+// ApplyConfig method to apply every setting of c after validating them all; nothing is applied when one is invalid.
+// The settings change at once, so a batch starting meanwhile sees either none or all of them. A nil Backoff, as
+// decoded from JSON, keeps the current one; other settings that are functions or interfaces, such as the clock
+// and the metrics, are left as they are. A child only gets a circuit breaker or rate limit of its own when c
+// differs from the one it uses through its parent.
+func (fhi *FunctionHandlerImpl) ApplyConfig(c Config) error {
+	if err := c.validate(); err != nil {
+		err = fhi.errorf("%w", err)
+		fhi.LogError(err)
+		return err
+	}
+	fhi.configure(func(s *settings) {
+		current := fhi.effective(s).config()
+		if c.CircuitThreshold != current.CircuitThreshold || c.CircuitOpenFor != current.CircuitOpenFor || c.CircuitProbes != current.CircuitProbes {
+			s.circuits = newCircuits(c.CircuitThreshold, c.CircuitOpenFor, c.CircuitProbes)
+		}
+		if c.RateLimit != current.RateLimit || c.RateBurst != current.RateBurst {
+			s.rateLimiter = fhi.newRateLimiter(c.RateLimit, c.RateBurst)
+		}
+		if c.MaxConcurrentBatches != current.MaxConcurrentBatches {
+			s.batchSlots = newBatchSlots(c.MaxConcurrentBatches)
+		}
+		s.name, s.mode = c.Name, c.Mode
+		s.timeout, s.retries, s.retryOnTimeout = c.Timeout, c.Retries, c.RetryOnTimeout
+		if c.Backoff != nil {
+			s.backoff = c.Backoff
+		}
+		s.attemptTimeout, s.maxRetryDuration, s.hedgeDelay = c.AttemptTimeout, c.MaxRetryDuration, max(c.HedgeDelay, 0)
+		s.maxConcurrency, s.rejectWhenBusy = c.MaxConcurrency, c.RejectWhenBusy
+		s.stagger, s.staggerJitter = c.Stagger, c.StaggerJitter
+		s.handlerRetryLimit, s.handlerRetries, s.handlerBackoff = c.HandlerRetryLimit, c.HandlerRetries, c.HandlerBackoff
+		s.handlerTimeout, s.slowThreshold = c.HandlerTimeout, c.SlowThreshold
+		s.orderedResults, s.copyArgs, s.accumulateChunks = c.OrderedResults, c.CopyArgs, c.AccumulateChunks
+		s.flattenSlices, s.drainChannels, s.discardValues = c.FlattenSlices, c.DrainChannels, c.DiscardValues
+		s.pprofLabels = c.PprofLabels
+	})
+	return nil
+}
+
+func (c Config) validate() error {
+	var errs []error
+	check := func(ok bool, format string, a ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, a...))
+		}
+	}
+	_, validMode := strategies[c.Mode]
+	check(validMode, "mode %s is not valid", c.Mode)
+	check(c.Timeout >= 0, "timeout %s is negative", c.Timeout)
+	check(c.AttemptTimeout >= 0, "attemptTimeout %s is negative", c.AttemptTimeout)
+	check(c.Retries >= RetryForever, "retries %d is less than %d", c.Retries, RetryForever)
+	check(c.MaxRetryDuration >= 0, "maxRetryDuration %s is negative", c.MaxRetryDuration)
+	check(c.HedgeDelay >= 0, "hedgeDelay %s is negative", c.HedgeDelay)
+	check(c.CircuitThreshold >= 0, "circuitThreshold %d is negative", c.CircuitThreshold)
+	check(c.CircuitOpenFor >= 0, "circuitOpenFor %s is negative", c.CircuitOpenFor)
+	check(c.CircuitProbes >= 0, "circuitProbes %d is negative", c.CircuitProbes)
+	check(c.RateLimit >= 0, "rateLimit %g is negative", c.RateLimit)
+	check(c.RateBurst >= 0, "rateBurst %d is negative", c.RateBurst)
+	check(c.MaxConcurrency >= 0, "maxConcurrency %d is negative", c.MaxConcurrency)
+	check(c.MaxConcurrentBatches >= 0, "maxConcurrentBatches %d is negative", c.MaxConcurrentBatches)
+	check(c.Stagger >= 0, "stagger %s is negative", c.Stagger)
+	check(c.StaggerJitter >= 0, "staggerJitter %g is negative", c.StaggerJitter)
+	check(c.HandlerRetries >= 0, "handlerRetries %d is negative", c.HandlerRetries)
+	check(c.HandlerBackoff >= 0, "handlerBackoff %s is negative", c.HandlerBackoff)
+	check(c.HandlerTimeout >= 0, "handlerTimeout %s is negative", c.HandlerTimeout)
+	check(c.SlowThreshold >= 0, "slowThreshold %s is negative", c.SlowThreshold)
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConfigRoundTrip(t *testing.T) {
+	fh := NewHandler(WithName("svc"), WithMode(ModeFailFast), WithTimeout(2*time.Second), WithRetries(3))
+	fh.SetAttemptTimeout(500 * time.Millisecond)
+	fh.SetMaxRetryDuration(time.Second)
+	fh.SetHedge(100 * time.Millisecond)
+	fh.SetCircuitBreaker(5, time.Minute, 2)
+	fh.SetRateLimit(10, 5)
+	fh.SetHandlerRetryLimit(-1) // disables handler retries
+	fh.SetStagger(time.Millisecond, 0.5)
+	fh.SetOrderedResults(true)
+	want := fh.Config()
+
+	if err := fh.ApplyConfig(want); err != nil {
+		t.Fatalf("ApplyConfig(Config()) = %v", err)
+	}
+	if got := fh.Config(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Config after ApplyConfig = %+v, want %+v", got, want)
+	}
+
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Config
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal(%s) = %v", data, err)
+	}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Fatalf("JSON round trip = %+v, want %+v", decoded, want)
+	}
+	other := NewHandler()
+	if err := other.ApplyConfig(decoded); err != nil {
+		t.Fatalf("ApplyConfig on a new handler = %v", err)
+	}
+	if got := other.Config(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Config of a new handler = %+v, want %+v", got, want)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"zero", Config{}, false},
+		{"negative handler retry limit", Config{HandlerRetryLimit: -1}, false},
+		{"retry forever", Config{Retries: RetryForever}, false},
+		{"negative timeout", Config{Timeout: -time.Second}, true},
+		{"negative attempt timeout", Config{AttemptTimeout: -time.Second}, true},
+		{"retries below forever", Config{Retries: -2}, true},
+		{"unknown mode", Config{Mode: ExecutionMode(99)}, true},
+		{"negative rate limit", Config{RateLimit: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseConfigUnknownFields(t *testing.T) {
+	_, unknown, err := ParseConfig([]byte(`{"retries": 2, "retires": 3}`), false)
+	if err != nil || len(unknown) != 1 || unknown[0] != "retires" {
+		t.Fatalf("ParseConfig = %v, %v; want the unknown field retires", unknown, err)
+	}
+	if _, _, err := ParseConfig([]byte(`{"retires": 3}`), true); err == nil {
+		t.Fatal("strict ParseConfig accepted an unknown field")
+	}
+}
+
+func TestConfigKeepsBackoff(t *testing.T) {
+	fh := NewHandler(WithBackoff(LinearBackoff(time.Second, time.Second, 0)))
+	tests := []struct {
+		name   string
+		target *FunctionHandlerImpl
+		config Config
+	}{
+		{"same handler", fh, fh.Config()},
+		{"new handler", NewHandler(), fh.Config()},
+		{"no backoff in the config", fh, Config{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.target.ApplyConfig(tt.config); err != nil {
+				t.Fatalf("ApplyConfig = %v", err)
+			}
+			if got := tt.target.backoffFor(3, errBoom); got != 3*time.Second {
+				t.Fatalf("third retry waits %s, want the linear backoff's 3s", got)
+			}
+		})
+	}
+}
+
+func TestChildConfigReportsEffectiveSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(c *Config)
+		wantOwn  bool
+		wantRate float64
+	}{
+		{"unchanged", func(c *Config) {}, false, 10},
+		{"other setting changed", func(c *Config) { c.Retries = 5 }, false, 10},
+		{"circuit threshold changed", func(c *Config) { c.CircuitThreshold = 2 }, true, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := NewHandler()
+			parent.SetCircuitBreaker(5, time.Minute, 1)
+			parent.SetRateLimit(10, 2)
+			child := parent.Child()
+			c := child.Config()
+			if c.CircuitThreshold != 5 || c.CircuitOpenFor != time.Minute || c.RateLimit != 10 || c.RateBurst != 2 {
+				t.Fatalf("child Config = %+v, want the parent's circuit breaker and rate limit", c)
+			}
+			tt.change(&c)
+			if err := child.ApplyConfig(c); err != nil {
+				t.Fatalf("ApplyConfig = %v", err)
+			}
+			if own := child.getCircuits() != parent.getCircuits(); own != tt.wantOwn {
+				t.Fatalf("child has circuits of its own: %v, want %v", own, tt.wantOwn)
+			}
+			if child.getRateLimiter() != parent.getRateLimiter() {
+				t.Fatal("the child stopped sharing the parent's rate limiter")
+			}
+			if got := child.Config(); got.CircuitThreshold != c.CircuitThreshold || got.RateLimit != tt.wantRate {
+				t.Fatalf("child Config after ApplyConfig = %+v, want %+v", got, c)
+			}
+		})
+	}
+}
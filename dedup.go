@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"runtime/debug"
+	"slices"
+	"sync"
+)
+
+// WrapKeyed method to create a function like WrapFunction whose attempts are shared with those of other
+// functions wrapped under the same key by this handler or its relatives: an attempt started while another
+// with the key is running waits for it and receives a copy of its result instead of calling function again.
+// The shared attempt runs with the context of the one that started it.
+func (fhi *FunctionHandlerImpl) WrapKeyed(key string, function interface{}, args ...interface{}) func() Result[any] {
+	w := fhi.wrapFunction(function, args)
+	run := w.run
+	flights := &fhi.root().flights
+	w.run = func(exec *execution) Result[any] {
+		return flights.do(exec.ctx, key, func() Result[any] {
+			return run(exec)
+		})
+	}
+	return bind(w)
+}
+
+// root method to return the handler at the top of the parent chain
+func (fhi *FunctionHandlerImpl) root() *FunctionHandlerImpl {
+	h := fhi
+	for h.parent != nil {
+		h = h.parent
+	}
+	return h
+}
+
+// flightGroup struct to hold the running attempts of keyed functions
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+// flight struct to hold one running attempt and, once done is closed, its result
+type flight struct {
+	done chan struct{}
+	res  Result[any]
+}
+
+// do method to run fn for key unless an attempt for key is running, in which case its result is waited for
+// until ctx ends
+func (g *flightGroup) do(ctx context.Context, key string, fn func() Result[any]) Result[any] {
+	g.mu.Lock()
+	if f, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-f.done:
+			return Result[any]{Values: slices.Clone(f.res.Values), Err: f.res.Err}
+		case <-ctx.Done():
+			return Err[any](context.Cause(ctx))
+		}
+	}
+	if g.calls == nil {
+		g.calls = map[string]*flight{}
+	}
+	f := &flight{done: make(chan struct{})}
+	g.calls[key] = f
+	g.mu.Unlock()
+	defer func() {
+		if r := recover(); r != nil {
+			f.res = Err[any](&PanicError{Value: r, Stack: debug.Stack(), FuncName: key})
+			defer panic(r) // after the waiting attempts are released, panic in the one that ran
+		}
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(f.done)
+	}()
+	f.res = fn()
+	return Result[any]{Values: slices.Clone(f.res.Values), Err: f.res.Err}
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWrapKeyed(t *testing.T) {
+	tests := []struct {
+		name      string
+		keys      []string
+		child     bool // wrap every other function on a child handler
+		wantCalls int32
+	}{
+		{"one key shares the attempt", []string{"user:1", "user:1", "user:1", "user:1"}, false, 1},
+		{"a child shares with its parent", []string{"user:1", "user:1", "user:1", "user:1"}, true, 1},
+		{"different keys run apart", []string{"user:1", "user:2", "user:1", "user:2"}, false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler(WithMode(ModeParallel))
+			child := fh.Child()
+			var calls atomic.Int32
+			fetch := func(key string) string {
+				calls.Add(1)
+				time.Sleep(50 * time.Millisecond) // the other attempts start meanwhile
+				return "value of " + key
+			}
+			funcs := make([]func() Result[any], len(tt.keys))
+			for i, key := range tt.keys {
+				h := fh
+				if tt.child && i%2 == 1 {
+					h = child
+				}
+				funcs[i] = h.WrapKeyed(key, fetch, key)
+			}
+			results, res := fh.Try(func(err error) error { return err }, funcs...)
+			if res.IsErr() || len(results) != len(tt.keys) {
+				t.Fatalf("Try = %v, %v; want a value per function", results, res.Err)
+			}
+			for _, value := range results {
+				if value != "value of user:1" && value != "value of user:2" {
+					t.Fatalf("Try = %v, want the values of the keys", results)
+				}
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Fatalf("function called %d times, want %d", calls.Load(), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWrapKeyedRunsAgainAfterTheAttempt(t *testing.T) {
+	fh := NewHandler()
+	calls := 0
+	fn := fh.WrapKeyed("k", func() (int, error) {
+		calls++
+		if calls == 1 {
+			return 0, errBoom
+		}
+		return calls, nil
+	})
+	if res := fn(); !errors.Is(res.Err, errBoom) {
+		t.Fatalf("first call = %v, want %v", res.Err, errBoom)
+	}
+	if res := fn(); res.IsErr() || res.Values[0] != 2 {
+		t.Fatalf("second call = %v, %v; want a fresh attempt", res.Values, res.Err)
+	}
+}
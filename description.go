@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Description struct to describe a wrapped function without running it, for tooling such as admin pages.
+// Problem holds the reason the function would fail its argument checks or its argument validators, if any;
+// arguments computed at every attempt, as with WrapWithArgsFunc, are not checked. Timeout and Retries are
+// the ones the function runs with, its own options applied over the handler's settings.
+type Description struct {
+	Name    string
+	Params  []string
+	Args    []string
+	Returns []string
+	Problem string
+	Timeout time.Duration
+	Retries int
+}
+
+// Describe method to describe fn: its name and, for functions created by a Wrap method, its signature and
+// bound arguments, formatted through the argument redactor. fn is not called.
+func (fhi *FunctionHandlerImpl) Describe(fn func() Result[any]) Description {
+	w := describe(fn)
+	d := Description{Name: nameOf(fn, w)}
+	run := fhi
+	if w != nil && len(w.opts) > 0 {
+		run = fhi.overridden(w.opts)
+	}
+	cfg := run.settings()
+	d.Timeout, d.Retries = cfg.timeout, cfg.retries
+	if w == nil || w.function == nil {
+		return d
+	}
+	funcType := reflect.TypeOf(w.function)
+	if funcType.Kind() != reflect.Func {
+		d.Problem = fhi.errorf("%w: got %T", ErrNotAFunction, w.function).Error()
+		return d
+	}
+	for i := 0; i < funcType.NumIn(); i++ {
+		d.Params = append(d.Params, funcType.In(i).String())
+	}
+	for i := 0; i < funcType.NumOut(); i++ {
+		d.Returns = append(d.Returns, funcType.Out(i).String())
+	}
+	for i, arg := range w.args {
+		d.Args = append(d.Args, fhi.formatArg(d.Name, i, arg))
+	}
+	if w.lateArgs {
+		return d
+	}
+	err := fhi.checkArgs(funcType, w.args)
+	if err == nil {
+		err = fhi.validateArgs(w.validators, w.args)
+	}
+	if err != nil {
+		d.Problem = err.Error()
+	}
+	return d
+}
+
+// DescribeAll method to describe every function of a batch, in order
+func (fhi *FunctionHandlerImpl) DescribeAll(funcs ...func() Result[any]) []Description {
+	descriptions := make([]Description, len(funcs))
+	for i, fn := range funcs {
+		descriptions[i] = fhi.Describe(fn)
+	}
+	return descriptions
+}
+
+// SetArgRedactor method to set a hook deciding how Describe shows bound arguments. It receives the
+// function's name and the argument's index and value; returning true replaces the value by the string.
+func (fhi *FunctionHandlerImpl) SetArgRedactor(redact func(funcName string, index int, arg any) (string, bool)) {
+	fhi.configure(func(s *settings) { s.redactArg = redact })
+}
+
+// formatArg method to format a bound argument for a Description
+func (fhi *FunctionHandlerImpl) formatArg(funcName string, index int, arg any) string {
+	cfg := fhi.settings()
+	if cfg.redactArg != nil {
+		if s, ok := cfg.redactArg(funcName, index, arg); ok {
+			return s
+		}
+	}
+	return fmt.Sprintf("%#v", arg)
+}
+
+// Describe method to describe the functions of the group in order, using the group's names when set
+func (g *Group) Describe() []Description {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	descriptions := make([]Description, len(g.entries))
+	for i, entry := range g.entries {
+		descriptions[i] = g.fhi.Describe(entry.fn)
+		if entry.name != "" {
+			descriptions[i].Name = entry.name
+		}
+	}
+	return descriptions
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDescribe(t *testing.T) {
+	fh := NewHandler()
+	add := func(a, b int) int { return a + b }
+	tests := []struct {
+		name        string
+		fn          func() Result[any]
+		wantArgs    int
+		wantProblem bool
+	}{
+		{"bound arguments", fh.WrapFunction(add, 1, 2), 2, false},
+		{"missing argument", fh.WrapFunction(add, 1), 1, true},
+		{"wrong argument type", fh.WrapFunction(add, 1, "two"), 2, true},
+		{"arguments computed later", fh.WrapWithArgsFunc(add, func(attempt int, prevErr error) ([]interface{}, error) {
+			return []interface{}{attempt, 1}, nil
+		}), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := fh.Describe(tt.fn)
+			if len(d.Params) != 2 || len(d.Returns) != 1 {
+				t.Fatalf("Describe = %+v, want the signature of add", d)
+			}
+			if len(d.Args) != tt.wantArgs {
+				t.Fatalf("Describe args = %v, want %d", d.Args, tt.wantArgs)
+			}
+			if (d.Problem != "") != tt.wantProblem {
+				t.Fatalf("Describe problem = %q, want a problem %v", d.Problem, tt.wantProblem)
+			}
+		})
+	}
+}
+
+func TestDescribeRedactsArguments(t *testing.T) {
+	fh := NewHandler()
+	fh.SetArgRedactor(func(funcName string, index int, arg any) (string, bool) { return "***", index == 1 })
+	d := fh.Describe(fh.WrapFunction(func(user, password string) {}, "bob", "secret"))
+	if len(d.Args) != 2 || d.Args[0] != `"bob"` || d.Args[1] != "***" {
+		t.Fatalf("Describe args = %v, want the password redacted", d.Args)
+	}
+}
+
+func TestDescribeEffectiveSettings(t *testing.T) {
+	fh := NewHandler(WithTimeout(time.Second), WithRetries(2))
+	add := func(a, b int) int { return a + b }
+	tests := []struct {
+		name        string
+		fn          func() Result[any]
+		wantTimeout time.Duration
+		wantRetries int
+	}{
+		{"handler settings", fh.WrapFunction(add, 1, 2), time.Second, 2},
+		{"own options", fh.WrapFunction(add, 1, 2, WithTimeout(time.Minute), WithRetries(5)), time.Minute, 5},
+		{"own timeout only", fh.WrapFunction(add, 1, 2, WithTimeout(time.Minute)), time.Minute, 2},
+		{"validated with own options", fh.WrapWithValidators(add, nil, 1, 2, WithRetries(0)), time.Second, 0},
+		{"plain function", func() Result[any] { return Ok[any]() }, time.Second, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := fh.Describe(tt.fn)
+			if d.Timeout != tt.wantTimeout || d.Retries != tt.wantRetries {
+				t.Fatalf("Describe = timeout %s, %d retries; want %s, %d", d.Timeout, d.Retries, tt.wantTimeout, tt.wantRetries)
+			}
+			if d.Problem != "" {
+				t.Fatalf("Describe problem = %q, want none", d.Problem)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+// SetDrainChannels method to make wrapped functions drain returned receive channels into the result's values.
+// Without it a returned channel is passed through untouched.
+func (fhi *FunctionHandlerImpl) SetDrainChannels(drain bool) {
+	fhi.configure(func(s *settings) { s.drainChannels = drain })
+}
+
+// drain method to replace every receive channel among the values of an Ok result by the elements received
+// from it until it is closed. When ctx ends first, the result holds the error together with the elements
+// received so far; a deadline is reported as ErrTimeout.
+func (fhi *FunctionHandlerImpl) drain(ctx context.Context, res Result[any]) Result[any] {
+	if !fhi.settings().drainChannels || res.IsErr() {
+		return res
+	}
+	values := make([]any, 0, len(res.Values))
+	for _, value := range res.Values {
+		ch := reflect.ValueOf(value)
+		if ch.Kind() != reflect.Chan || ch.Type().ChanDir()&reflect.RecvDir == 0 {
+			values = append(values, value)
+			continue
+		}
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+			{Dir: reflect.SelectRecv, Chan: ch},
+		}
+		for {
+			chosen, elem, ok := reflect.Select(cases)
+			if chosen == 0 {
+				var err error
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					err = fhi.errorf("%w while draining channel after %d values", ErrTimeout, len(values))
+				} else {
+					err = fhi.errorf("channel drain stopped after %d values: %w", len(values), context.Cause(ctx))
+				}
+				fhi.LogError(err)
+				return Result[any]{Values: values, Err: err}
+			}
+			if !ok {
+				break
+			}
+			values = append(values, elem.Interface())
+		}
+	}
+	return Ok(values...)
+}
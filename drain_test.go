@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// numbers function to return a channel holding values, closed when closed is set
+func numbers(closed bool, values ...int) <-chan int {
+	ch := make(chan int, len(values))
+	for _, v := range values {
+		ch <- v
+	}
+	if closed {
+		close(ch)
+	}
+	return ch
+}
+
+func TestDrainChannels(t *testing.T) {
+	unclosed := numbers(false, 1, 2)
+	tests := []struct {
+		name    string
+		drain   bool
+		ctx     func() (context.Context, context.CancelFunc)
+		values  []any
+		want    []any
+		wantErr error
+	}{
+		{"off", false, nil, []any{unclosed}, []any{unclosed}, nil},
+		{"drained in place", true, nil, []any{"before", numbers(true, 1, 2, 3), "after"}, []any{"before", 1, 2, 3, "after"}, nil},
+		{"empty channel", true, nil, []any{numbers(true)}, []any{}, nil},
+		{"send-only channel kept", true, nil, []any{make(chan<- int)}, nil, nil},
+		{"deadline while draining", true, func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 10*time.Millisecond)
+		}, []any{numbers(false, 1, 2)}, []any{1, 2}, ErrTimeout},
+		{"cancel while draining", true, func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancelCause(context.Background())
+			cancel(errBoom)
+			return ctx, func() {}
+		}, []any{numbers(false)}, []any{}, errBoom},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler()
+			fh.SetDrainChannels(tt.drain)
+			ctx, cancel := context.Background(), context.CancelFunc(func() {})
+			if tt.ctx != nil {
+				ctx, cancel = tt.ctx()
+			}
+			defer cancel()
+			res := fh.drain(ctx, Ok(tt.values...))
+			if !errors.Is(res.Err, tt.wantErr) {
+				t.Fatalf("drain = %v, want %v", res.Err, tt.wantErr)
+			}
+			want := tt.want
+			if want == nil {
+				want = tt.values
+			}
+			if !slices.Equal(res.Values, want) {
+				t.Fatalf("drain = %v, want %v", res.Values, want)
+			}
+		})
+	}
+}
+
+func TestDrainChannelsOfWrappedFunction(t *testing.T) {
+	fh := NewHandler()
+	fh.SetDrainChannels(true)
+	results, res := fh.Try(func(err error) error { return err }, fh.WrapFunction(func() (<-chan int, error) {
+		return numbers(true, 4, 5), nil
+	}))
+	if res.IsErr() || !slices.Equal(results, []any{4, 5}) {
+		t.Fatalf("Try = %v, %v; want the channel's values", results, res.Err)
+	}
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors wrapped by every failure the package fabricates, for use with errors.Is
+var (
+	ErrNotAFunction     = errors.New("no function provided")
+	ErrArgCountMismatch = errors.New("argument count does not match function's parameter count")
+	ErrArgTypeMismatch  = errors.New("argument type does not match function's parameter type")
+	ErrInvalidHandler   = errors.New("invalid error handler")
+	ErrNoFunctions      = errors.New("no functions provided")
+	ErrTimeout          = errors.New("function timed out")
+	ErrRetryExhausted   = errors.New("retries exhausted")
+	ErrValidation       = errors.New("argument validation failed")
+	ErrWorkerStopped    = errors.New("worker stopped")
+	ErrGroupRunning     = errors.New("group is running")
+	ErrBusy             = errors.New("handler is busy")
+	ErrInvalidMode      = errors.New("invalid execution mode")
+	ErrScanMismatch     = errors.New("result does not match scan destination")
+	ErrInterrupted      = errors.New("interrupted by signal")
+	ErrMethodNotFound   = errors.New("method not found")
+	ErrUnboundedRetries = errors.New("unlimited retries need a timeout or a cancellable context")
+	ErrHandlerTimeout   = errors.New("error handler timed out")
+	ErrUnknownFunction  = errors.New("function is not registered")
+	ErrDuplicateName    = errors.New("name is already registered")
+	ErrNoPersister      = errors.New("no state persister set")
+	ErrInvalidQuorum    = errors.New("invalid quorum")
+	ErrAbandoned        = errors.New("function abandoned")
+	ErrAllFailed        = errors.New("all functions failed")
+	ErrArgTransform     = errors.New("argument transformer failed")
+	ErrPermanent        = errors.New("permanent failure")
+	ErrInvalidSchedule  = errors.New("invalid schedule")
+	ErrInvalidResult    = errors.New("invalid result")
+	ErrInvalidConfig    = errors.New("invalid configuration")
+	ErrCircuitOpen      = errors.New("circuit breaker is open")
+	ErrNotScalar        = errors.New("result does not hold exactly one value")
+	ErrStaggerDeadline  = errors.New("staggered start is past the batch deadline")
+)
+
+// PanicError struct to hold a panic recovered from a function as an error
+type PanicError struct {
+	Value    any
+	Stack    []byte
+	FuncName string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic in %s: %v", e.FuncName, e.Value)
+}
+
+// Unwrap method to expose the panic value when it is itself an error
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
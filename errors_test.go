@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	fh := NewHandler()
+	passThrough := func(err error) error { return err }
+	tests := []struct {
+		name string
+		run  func() error
+		want error
+	}{
+		{"not a function", func() error {
+			_, res := fh.Try(passThrough, fh.WrapFunction(42))
+			return res.Err
+		}, ErrNotAFunction},
+		{"argument count", func() error {
+			_, res := fh.Try(passThrough, fh.WrapFunction(func(a, b int) {}, 1))
+			return res.Err
+		}, ErrArgCountMismatch},
+		{"argument type", func() error {
+			_, res := fh.Try(passThrough, fh.WrapFunction(func(a int) {}, "one"))
+			return res.Err
+		}, ErrArgTypeMismatch},
+		{"invalid handler", func() error {
+			_, res := fh.Try("not a handler", fh.WrapFunction(func() {}))
+			return res.Err
+		}, ErrInvalidHandler},
+		{"no functions", func() error {
+			_, res := fh.Try(passThrough)
+			return res.Err
+		}, ErrNoFunctions},
+		{"timeout", func() error {
+			h := fh.With(WithTimeout(10 * time.Millisecond))
+			_, res := h.Try(passThrough, h.WrapFunction(func(ctx context.Context) { <-ctx.Done() }))
+			return res.Err
+		}, ErrTimeout},
+		{"aggregated under collect-errors", func() error {
+			h := fh.With(WithMode(ModeCollectErrors))
+			_, res := h.Try(passThrough, h.WrapFunction(func() {}), h.WrapFunction(func(a int) {}, "one"))
+			return res.Err
+		}, ErrArgTypeMismatch},
+		{"wrapped by the error handler", func() error {
+			_, res := fh.Try(func(err error) error { return errors.Join(errors.New("handled"), err) }, fh.WrapFunction(func(a, b int) {}))
+			return res.Err
+		}, ErrArgCountMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want an error matching %v", err, tt.want)
+			}
+		})
+	}
+}
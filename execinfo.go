@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"reflect"
+	"time"
+)
+
+// ExecutionInfo struct to describe the run of a function, passed to error handlers that take it as a second
+// parameter, as in func(err error, info ExecutionInfo) error, and reported by TryAll
+type ExecutionInfo struct {
+	Name        string        // the function's name, as reported to the metrics
+	ExecutionID string        // the ID of the run, the same for all its attempts; empty when IDs are off
+	BatchID     string        // the ID of the batch; empty when IDs are off
+	Index       int           // the function's position in the batch
+	Attempts    int           // the attempts started, retries included
+	Duration    time.Duration // the time the run took, retries and backoff included
+	TimedOut    bool          // whether the failure is the handler's timeout
+}
+
+// executionInfoType is the reflect type of ExecutionInfo
+var executionInfoType = reflect.TypeOf(ExecutionInfo{})
+
+// executionInfo function to return the description of the run that produced res, at index in the batch
+func executionInfo(index int, res Result[any]) ExecutionInfo {
+	var info ExecutionInfo
+	if res.info != nil {
+		info = *res.info
+	}
+	info.Index = index
+	return info
+}
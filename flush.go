@@ -0,0 +1,67 @@
+package handler
+
+import "sync"
+
+// SetFlushEvery method to pass the final results of a batch's functions to onBatch in groups of n, in the order
+// they completed, and once more with the remainder when the batch ends. Failed results are included.
+// An error from onBatch aborts the batch like an error handler's error. n <= 0 turns flushing off.
+func (fhi *FunctionHandlerImpl) SetFlushEvery(n int, onBatch func(batch []Result[any]) error) {
+	fhi.configure(func(s *settings) { s.flushEvery, s.onFlush = n, onBatch })
+}
+
+// flusher struct to buffer the results of one batch for the SetFlushEvery callback
+type flusher struct {
+	fhi     *FunctionHandlerImpl
+	every   int
+	onFlush func(batch []Result[any]) error
+	mu      sync.Mutex
+	buf     []Result[any]
+}
+
+// newFlusher method to create the flusher of a batch, or nil when flushing is off
+func (fhi *FunctionHandlerImpl) newFlusher() *flusher {
+	cfg := fhi.settings()
+	if cfg.flushEvery <= 0 || cfg.onFlush == nil {
+		return nil
+	}
+	return &flusher{fhi: fhi, every: cfg.flushEvery, onFlush: cfg.onFlush, buf: make([]Result[any], 0, cfg.flushEvery)}
+}
+
+// add method to buffer a final result, flushing when the buffer is full
+func (f *flusher) add(res Result[any]) error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.buf = append(f.buf, res)
+	if len(f.buf) < f.every {
+		return nil
+	}
+	return f.flushLocked()
+}
+
+// done method to flush the remaining results at the end of the batch
+func (f *flusher) done() error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.flushLocked()
+}
+
+// flushLocked method to pass the buffered results to the callback; f.mu must be held
+func (f *flusher) flushLocked() error {
+	if len(f.buf) == 0 {
+		return nil
+	}
+	batch := f.buf
+	f.buf = make([]Result[any], 0, f.every)
+	if err := f.onFlush(batch); err != nil {
+		err = f.fhi.errorf("flush callback failed: %w", err)
+		f.fhi.LogError(err)
+		return err
+	}
+	return nil
+}
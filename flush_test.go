@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFlushEveryFlushesParallelResultsAsTheyArrive(t *testing.T) {
+	for _, mode := range []ExecutionMode{ModeParallel, ModeCollectErrors} {
+		t.Run(mode.String(), func(t *testing.T) {
+			fh := NewHandler(WithMode(mode))
+			start := time.Now()
+			var mu sync.Mutex
+			var flushedAt []time.Duration
+			var sizes []int
+			fh.SetFlushEvery(2, func(batch []Result[any]) error {
+				mu.Lock()
+				defer mu.Unlock()
+				flushedAt = append(flushedAt, time.Since(start))
+				sizes = append(sizes, len(batch))
+				return nil
+			})
+			sleep := func(d time.Duration) func() Result[any] {
+				return fh.WrapFunction(func() { time.Sleep(d) })
+			}
+			if _, res := fh.Try(func(err error) error { return err },
+				sleep(10*time.Millisecond), sleep(10*time.Millisecond), sleep(300*time.Millisecond)); res.IsErr() {
+				t.Fatal(res.Err)
+			}
+			if len(sizes) != 2 || sizes[0] != 2 || sizes[1] != 1 {
+				t.Fatalf("flushed batches of %v, want [2 1]", sizes)
+			}
+			if flushedAt[0] >= 200*time.Millisecond {
+				t.Fatalf("first flush after %s, want it before the slow function finished", flushedAt[0])
+			}
+		})
+	}
+}
+
+func TestFlushErrorAbortsParallelBatchAfterAllFinished(t *testing.T) {
+	fh := NewHandler(WithMode(ModeParallel))
+	fh.SetFlushEvery(1, func([]Result[any]) error { return errors.New("flush failed") })
+	var mu sync.Mutex
+	finished := 0
+	fn := fh.WrapFunction(func() {
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		finished++
+		mu.Unlock()
+	})
+	_, res := fh.Try(func(err error) error { return err }, fn, fn, fn)
+	if res.IsOk() {
+		t.Fatal("batch succeeded, want the flush error")
+	}
+	if finished != 3 {
+		t.Fatalf("%d functions finished before the batch returned, want 3", finished)
+	}
+}
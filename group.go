@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"sync"
+)
+
+// Group struct to collect functions incrementally and run them as one batch with the handler's settings
+type Group struct {
+	fhi     *FunctionHandlerImpl
+	mu      sync.Mutex
+	running int
+	entries []groupEntry
+}
+
+// groupEntry struct to hold a function of a group and its optional name
+type groupEntry struct {
+	name string
+	fn   func() Result[any]
+}
+
+// Group method to create an empty group of functions run by this handler
+func (fhi *FunctionHandlerImpl) Group() *Group {
+	return &Group{fhi: fhi}
+}
+
+// Add method to append a function to the group; it fails with ErrGroupRunning while the group runs
+func (g *Group) Add(fn func() Result[any]) error {
+	return g.AddNamed("", fn)
+}
+
+// AddNamed method to append a function under a name, which must be unique within the group
+func (g *Group) AddNamed(name string, fn func() Result[any]) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.checkIdle(); err != nil {
+		return err
+	}
+	if name != "" && g.indexOf(name) >= 0 {
+		return g.fhi.errorf("function %q is already in the group", name)
+	}
+	g.entries = append(g.entries, groupEntry{name: name, fn: fn})
+	return nil
+}
+
+// Remove method to remove the function added under name, reporting whether it was found
+func (g *Group) Remove(name string) (bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.checkIdle(); err != nil {
+		return false, err
+	}
+	i := g.indexOf(name)
+	if name == "" || i < 0 {
+		return false, nil
+	}
+	g.entries = append(g.entries[:i], g.entries[i+1:]...)
+	return true, nil
+}
+
+// Clear method to remove every function from the group
+func (g *Group) Clear() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.checkIdle(); err != nil {
+		return err
+	}
+	g.entries = nil
+	return nil
+}
+
+// Len method to return the number of functions in the group
+func (g *Group) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.entries)
+}
+
+// Run method to run the group's functions as one batch; a group can be run again after Run returns
+func (g *Group) Run(ctx context.Context, handler interface{}) ([]any, Result[any]) {
+	release, err := g.fhi.acquireBatch(ctx)
+	if err != nil {
+		g.fhi.LogError(err)
+		return nil, Err[any](err)
+	}
+	defer release()
+	g.mu.Lock()
+	g.running++
+	funcs := make([]func() Result[any], len(g.entries))
+	for i, entry := range g.entries {
+		funcs[i] = entry.fn
+	}
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		g.running--
+		g.mu.Unlock()
+	}()
+	return g.fhi.tryFuncs(ctx, handler, funcs)
+}
+
+// checkIdle method to refuse changes while the group is running; g.mu must be held
+func (g *Group) checkIdle() error {
+	if g.running > 0 {
+		return g.fhi.errorf("%w", ErrGroupRunning)
+	}
+	return nil
+}
+
+// indexOf method to find the entry added under name; g.mu must be held
+func (g *Group) indexOf(name string) int {
+	for i, entry := range g.entries {
+		if entry.name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	fh := NewHandler(WithLogger(&recordingLogger{}))
+	value := func(v int) func() Result[any] { return fh.WrapFunction(func() int { return v }) }
+	tests := []struct {
+		name    string
+		build   func(g *Group) error
+		wantLen int
+		want    string
+		wantErr error
+	}{
+		{"add", func(g *Group) error {
+			return errors.Join(g.Add(value(1)), g.AddNamed("two", value(2)))
+		}, 2, "[1 2]", nil},
+		{"duplicate name", func(g *Group) error {
+			g.AddNamed("one", value(1))
+			if err := g.AddNamed("one", value(2)); err == nil {
+				return errors.New("a second function was added under the same name")
+			}
+			return nil
+		}, 1, "[1]", nil},
+		{"remove", func(g *Group) error {
+			g.AddNamed("one", value(1))
+			g.AddNamed("two", value(2))
+			if removed, err := g.Remove("one"); !removed || err != nil {
+				return fmt.Errorf("Remove = %v, %v", removed, err)
+			}
+			if removed, _ := g.Remove("missing"); removed {
+				return errors.New("removed a missing function")
+			}
+			return nil
+		}, 1, "[2]", nil},
+		{"clear", func(g *Group) error {
+			g.Add(value(1))
+			return g.Clear()
+		}, 0, "[]", ErrNoFunctions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := fh.Group()
+			if err := tt.build(g); err != nil {
+				t.Fatal(err)
+			}
+			if g.Len() != tt.wantLen {
+				t.Fatalf("Len = %d, want %d", g.Len(), tt.wantLen)
+			}
+			results, res := g.Run(context.Background(), func(err error) error { return err })
+			if !errors.Is(res.Err, tt.wantErr) {
+				t.Fatalf("Run = %v, want %v", res.Err, tt.wantErr)
+			}
+			if tt.wantErr == nil && fmt.Sprint(results) != tt.want {
+				t.Fatalf("Run = %v, want %s", results, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupRunning(t *testing.T) {
+	fh := NewHandler()
+	g := fh.Group()
+	runs := 0
+	var during []error
+	g.Add(fh.WrapFunction(func() {
+		runs++
+		_, removeErr := g.Remove("x")
+		during = append(during, g.Add(fh.WrapFunction(func() {})), removeErr, g.Clear())
+	}))
+	for i := 0; i < 2; i++ {
+		if _, res := g.Run(context.Background(), func(err error) error { return err }); res.IsErr() {
+			t.Fatalf("Run = %v", res.Err)
+		}
+	}
+	if runs != 2 {
+		t.Fatalf("ran %d times in two runs, want 2", runs)
+	}
+	for _, err := range during {
+		if !errors.Is(err, ErrGroupRunning) {
+			t.Fatalf("changing the group while it runs = %v, want %v", err, ErrGroupRunning)
+		}
+	}
+	if g.Len() != 1 {
+		t.Fatalf("Len = %d after the runs, want 1", g.Len())
+	}
+	if err := g.Add(fh.WrapFunction(func() {})); err != nil {
+		t.Fatalf("Add after Run = %v", err)
+	}
+}
@@ -1,259 +1,1457 @@
 package handler
+
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"math/rand"
 	"reflect"
 	"runtime"
+	"runtime/debug"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// Result struct to hold values or an error
-type Result[T any] struct {
-	Values []T
-	Err    error
-}
-
-// Ok function to create a Result with values
-func Ok[T any](values ...T) Result[T] {
-	return Result[T]{Values: values}
-}
-
-// Err function to create a Result with an error
-func Err[T any](err error) Result[T] {
-	return Result[T]{Err: err}
-}
-
-// Methods to check if the Result contains an error or values
-func (r *Result[T]) IsOk() bool {
-	return r.Err == nil
-}
-
-func (r *Result[T]) IsErr() bool {
-	return r.Err != nil
-}
-
 // FunctionHandler interface definition
 type FunctionHandler interface {
 	ConvertArgs(args ...interface{}) []reflect.Value
+	ConvertArgsFor(function interface{}, args ...interface{}) ([]reflect.Value, error)
 	WrapFunction(function interface{}, args ...interface{}) func() Result[any]
+	WrapFunctionSlice(function interface{}, args []interface{}) func() Result[any]
+	WrapNamed(name string, function interface{}, args ...interface{}) func() Result[any]
+	WrapChecked(function interface{}, args ...interface{}) (func() Result[any], error)
+	WrapMethodByName(receiver interface{}, method string, args ...interface{}) (func() Result[any], error)
+	WrapMethod(receiver interface{}, method string, args ...interface{}) func() Result[any]
+	ApplyArgs(function interface{}, args []interface{}) Result[any]
+	Apply(function interface{}, args ...interface{}) Result[any]
+	WrapWithValidators(function interface{}, validators []func(args []interface{}) error, args ...interface{}) func() Result[any]
+	WrapWithArgsFunc(function interface{}, argsFor func(attempt int, prevErr error) ([]interface{}, error)) func() Result[any]
+	WrapWithCallback(function interface{}, onResult func(Result[any]), args ...interface{}) func() Result[any]
 	WrapErrorHandler(handlerFunc interface{}) Result[HandlerValues]
 	Try(handler interface{}, funcs ...func() Result[any]) ([]any, Result[any])
+	TryContext(ctx context.Context, handler interface{}, funcs ...func() Result[any]) ([]any, Result[any])
+	TryQuorum(k int, handler interface{}, funcs ...func() Result[any]) ([]any, Result[any])
+	TryAny(handler interface{}, funcs ...func() Result[any]) ([]any, Result[any])
+	TryAll(funcs ...func() Result[any]) ([]Settled, error)
+	MustTry(handler interface{}, funcs ...func() Result[any]) []any
+	TryChan(ctx context.Context, handler interface{}, in <-chan func() Result[any]) ([]any, Result[any])
+	TryStream(handler interface{}, funcs ...func() Result[any]) <-chan Result[any]
+	RunWithRetry(ctx context.Context, fn func() Result[any]) Result[any]
+	TryChunked(chunkSize int, onChunk func(chunkIndex int, results []any, err error) error, handler interface{}, funcs ...func() Result[any]) ([]any, Result[any])
+	SubTry(handler interface{}, funcs ...func() Result[any]) func() Result[any]
+	NewWorker() *Worker
+	Group() *Group
+	Describe(fn func() Result[any]) Description
+	SetRegistry(registry *Registry)
+	SetSkipUnknownNames(skip bool)
+	RunByName(handler interface{}, calls []NamedCall) ([]any, Result[any])
+	TryNamed(handler interface{}, calls ...NamedCall) ([]any, Result[any])
+	TryPersistent(batchID string, handler interface{}, registry *Registry, calls ...NamedCall) ([]any, Result[any])
+	ResumeBatch(batchID string, handler interface{}, registry *Registry) ([]any, Result[any])
+	SetStatePersister(persister StatePersister, checkpoints Checkpoint)
+	DescribeAll(funcs ...func() Result[any]) []Description
+	SetArgRedactor(redact func(funcName string, index int, arg any) (string, bool))
 	SetTimeout(duration time.Duration)
 	SetRetry(retries int)
+	SetRetryOnTimeout(retryOnTimeout bool)
+	SetAttemptTimeout(d time.Duration)
+	SetOverallTimeout(d time.Duration)
+	SetRetryIf(retryIf func(err error) bool)
+	SetMaxRetryDuration(d time.Duration)
+	SetAttemptEstimate(estimate func(durations []time.Duration) time.Duration)
 	SetParallel(isParallel bool)
+	SetFailFast(failFast bool)
+	SetMode(mode ExecutionMode)
+	Mode() ExecutionMode
+	Config() Config
+	ApplyConfig(c Config) error
+	SetStagger(d time.Duration, jitter float64)
+	SetMaxConcurrency(n int)
+	SetOrderedResults(ordered bool)
+	SetClock(clock Clock)
+	SetCopyArgs(copyArgs bool)
+	SetFlushEvery(n int, onBatch func(batch []Result[any]) error)
+	UseArgTransformer(transform ArgTransformer)
+	UseResultValidator(validate ResultValidator)
+	WrapWithResultValidator(function interface{}, validate func(values []any) error, args ...interface{}) func() Result[any]
+	SetName(name string)
+	SetAccumulateChunks(accumulate bool)
+	SetDefaultHandler(handler interface{})
+	SetRecoverHandler(handler func(recovered any, stack []byte, funcName string) error)
+	SetMetrics(metrics Metrics)
+	SetPprofLabels(enabled bool)
+	SetExecutionIDs(mode IDMode)
+	SetDrainChannels(drain bool)
+	SetFlattenSlices(flatten bool)
+	SetDiscardValues(discard bool)
+	SetHandlerRetryLimit(limit int)
+	SetSlowThreshold(d time.Duration, onSlow func(name string, took time.Duration))
+	SetHandlerRetries(n int, backoff time.Duration)
+	SetBackoff(backoff Backoff)
+	SetHandlerTimeout(d time.Duration)
+	SetIgnoreHandlerTimeout(ignore bool)
+	SetMaxConcurrentBatches(n int)
+	SetRejectWhenBusy(reject bool)
+	SetLogger(logger Logger)
+	OnStart(hook func(e HookEvent))
+	OnSuccess(hook func(e HookEvent))
+	OnFailure(hook func(e HookEvent))
+	OnRetry(hook func(e HookEvent))
+	OnTimeout(hook func(e HookEvent))
+	SetCircuitBreaker(threshold int, openFor time.Duration, probes int)
+	Circuit(name string) CircuitState
+	Circuits() map[string]CircuitState
+	SetRateLimit(rps float64, burst int)
+	SetRateLimiter(limiter RateLimiter)
+	WrapKeyed(key string, function interface{}, args ...interface{}) func() Result[any]
+	WrapCached(function interface{}, ttl time.Duration, args ...interface{}) func() Result[any]
+	SetCacheSize(n int)
+	ClearCache()
+	SetTracer(tracer Tracer)
+	SetHedge(delay time.Duration)
+	SetAsyncLogging(buffer int)
+	SetLogOverflow(policy OverflowPolicy)
+	DroppedLogs() int64
+	Flush()
+	Close()
+	Child(opts ...Option) *FunctionHandlerImpl
+	With(opts ...Option) *FunctionHandlerImpl
+	Clone() *FunctionHandlerImpl
+	NewScheduler() *Scheduler
+	Scan(values []any, dests ...any) error
+	SetScanNilError(nilError bool)
 }
 
-// FunctionHandlerImpl struct to implement FunctionHandler interface
+// FunctionHandlerImpl struct to implement FunctionHandler interface.
+//
+// A handler is safe for concurrent use: any number of goroutines may run batches, wrap functions, create
+// children, read its state such as Circuits and call its setters at the same time. Every setter replaces the
+// settings as a whole, so a running batch sees either the old or the new values of the settings it changes,
+// never a mix, and picks the change up from its next attempt on.
 type FunctionHandlerImpl struct {
-	timeout   time.Duration
-	retries   int
-	isParallel bool
+	parent         *FunctionHandlerImpl
+	children       children
+	settingsMu     sync.Mutex
+	current        atomic.Pointer[settings]
+	waitingBatches atomic.Int32
+	logger         atomic.Pointer[asyncLogger]
+	flights        flightGroup
+	cache          resultCache
+	lastID         atomic.Uint64
+}
+
+// settings struct to hold the configuration of a handler. A settings value is never changed once stored in
+// a handler; setters store a changed copy instead.
+type settings struct {
+	timeout              time.Duration
+	retries              int
+	retryOnTimeout       bool
+	attemptTimeout       time.Duration
+	retryIf              func(err error) bool
+	maxRetryDuration     time.Duration
+	hedgeDelay           time.Duration
+	backoff              Backoff
+	attemptEstimate      func(durations []time.Duration) time.Duration
+	mode                 ExecutionMode
+	maxConcurrency       int
+	orderedResults       bool
+	stagger              time.Duration
+	staggerJitter        float64
+	clock                Clock
+	copyArgs             bool
+	argTransformers      []ArgTransformer
+	resultValidators     []ResultValidator
+	registry             *Registry
+	skipUnknownNames     bool
+	flushEvery           int
+	onFlush              func(batch []Result[any]) error
+	name                 string
+	accumulateChunks     bool
+	defaultHandler       interface{}
+	recoverHandler       func(recovered any, stack []byte, funcName string) error
+	metrics              Metrics
+	handlerRetryLimit    int
+	slowThreshold        time.Duration
+	onSlow               func(name string, took time.Duration)
+	handlerRetries       int
+	handlerBackoff       time.Duration
+	handlerTimeout       time.Duration
+	ignoreHandlerTimeout bool
+	batchSlots           chan struct{}
+	rejectWhenBusy       bool
+	logOverflow          OverflowPolicy
+	logSink              Logger
+	hooks                [hookKinds][]func(e HookEvent)
+	circuits             *circuits
+	rateLimiter          RateLimiter
+	tracer               Tracer
+	scanNilError         bool
+	pprofLabels          bool
+	idMode               IDMode
+	redactArg            func(funcName string, index int, arg any) (string, bool)
+	persister            StatePersister
+	checkpoints          Checkpoint
+	drainChannels        bool
+	flattenSlices        bool
+	discardValues        bool
+}
+
+// noSettings is the configuration of a handler no setter was called on yet
+var noSettings settings
+
+// settings method to return the current configuration of the handler
+func (fhi *FunctionHandlerImpl) settings() *settings {
+	if s := fhi.current.Load(); s != nil {
+		return s
+	}
+	return &noSettings
+}
+
+// configure method to apply change to a copy of the configuration and store the copy. The slices of the copy
+// share their arrays with the previous configuration, so change must replace them rather than modify them.
+func (fhi *FunctionHandlerImpl) configure(change func(s *settings)) {
+	fhi.settingsMu.Lock()
+	defer fhi.settingsMu.Unlock()
+	s := *fhi.settings()
+	change(&s)
+	fhi.current.Store(&s)
+}
+
+// RetryForever is the retry count for retrying without limit
+const RetryForever = -1
+
+// defaultHandlerRetryLimit is how often the error handler may ask for a function to run again by default
+const defaultHandlerRetryLimit = 3
+
+// errorType is the reflect type of the error interface
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// ErrorHandler interface for error handlers that are values with a method instead of plain functions
+type ErrorHandler interface {
+	HandleError(err error) error
 }
 
 // HandlerValues struct to hold function values
 type HandlerValues struct {
-	Args []reflect.Value
-	Func *reflect.Value
+	Args    []reflect.Value
+	Func    *reflect.Value
+	handler ErrorHandler
+	routes  []handlerRoute
+}
+
+// errorArg method to convert err to the handler's parameter type, using errors.As for typed handlers.
+// An ErrorHandler takes every error as is and needs no converted argument.
+func (hv HandlerValues) errorArg(err error) (reflect.Value, bool) {
+	if hv.routes != nil {
+		return reflect.Value{}, false // a HandlerMux is resolved to one of its routes first
+	}
+	if hv.handler != nil {
+		return reflect.Value{}, true
+	}
+	paramType := hv.Func.Type().In(0)
+	if paramType == errorType {
+		return reflect.ValueOf(err), true
+	}
+	target := reflect.New(paramType)
+	if !errors.As(err, target.Interface()) {
+		return reflect.Value{}, false
+	}
+	return target.Elem(), true
 }
 
-// SetTimeout method to set timeout duration
+// SetTimeout method to set timeout duration. A function that times out is stopped through its injected context
+// and, when it implements Canceler, its Cancel method; the handler does not wait for it to return.
 func (fhi *FunctionHandlerImpl) SetTimeout(duration time.Duration) {
-	fhi.timeout = duration
+	fhi.configure(func(s *settings) { s.timeout = duration })
 }
 
-// SetRetry method to set retry attempts
+// SetRetry method to set retry attempts. RetryForever retries until the timeout or the context ends the loop,
+// and is refused when neither can.
 func (fhi *FunctionHandlerImpl) SetRetry(retries int) {
-	fhi.retries = retries
+	fhi.configure(func(s *settings) { s.retries = retries })
+}
+
+// checkRetryBound method to refuse unlimited retries when nothing would ever stop them
+func (fhi *FunctionHandlerImpl) checkRetryBound(ctx context.Context) error {
+	cfg := fhi.settings()
+	if cfg.retries != RetryForever || ctx.Done() != nil || cfg.maxRetryDuration > 0 || fhi.overallTimeout() > 0 {
+		return nil
+	}
+	return fhi.errorf("%w", ErrUnboundedRetries)
+}
+
+// SetRetryOnTimeout method to apply the timeout to each attempt instead of the whole run, so a timed out
+// attempt uses up a retry and waits for the backoff like any other failure. By default a timeout ends the function.
+func (fhi *FunctionHandlerImpl) SetRetryOnTimeout(retryOnTimeout bool) {
+	fhi.configure(func(s *settings) { s.retryOnTimeout = retryOnTimeout })
 }
 
-// SetParallel method to enable or disable parallel execution
+// SetAttemptTimeout method to give each attempt its own deadline of d, after which it fails with ErrTimeout and is
+// retried like any other failure. It takes the place of the timeout applied to each attempt by SetRetryOnTimeout
+// and can be combined with SetOverallTimeout. Zero turns it off.
+func (fhi *FunctionHandlerImpl) SetAttemptTimeout(d time.Duration) {
+	fhi.configure(func(s *settings) { s.attemptTimeout = d })
+}
+
+// SetOverallTimeout method to bound the whole run of a function, every attempt and wait between them, by d. It is
+// SetTimeout without SetRetryOnTimeout, so attempts only get a deadline of their own from SetAttemptTimeout.
+func (fhi *FunctionHandlerImpl) SetOverallTimeout(d time.Duration) {
+	fhi.configure(func(s *settings) { s.timeout, s.retryOnTimeout = d, false })
+}
+
+// overallTimeout method to return the timeout of the whole run of a function, 0 when there is none
+func (fhi *FunctionHandlerImpl) overallTimeout() time.Duration {
+	cfg := fhi.settings()
+	if cfg.retryOnTimeout {
+		return 0
+	}
+	return cfg.timeout
+}
+
+// attemptTimeoutOf method to return the timeout of each attempt, 0 when there is none
+func (fhi *FunctionHandlerImpl) attemptTimeoutOf() time.Duration {
+	cfg := fhi.settings()
+	if cfg.attemptTimeout > 0 || !cfg.retryOnTimeout {
+		return cfg.attemptTimeout
+	}
+	return cfg.timeout
+}
+
+// SetRetryIf method to only retry failures retryIf approves, such as network timeouts; any other failure
+// ends the function after its first attempt. Validation, transformer and permanent failures are never
+// retried. A nil retryIf retries every failure, the default.
+func (fhi *FunctionHandlerImpl) SetRetryIf(retryIf func(err error) bool) {
+	fhi.configure(func(s *settings) { s.retryIf = retryIf })
+}
+
+// SetMaxRetryDuration method to stop retrying once the attempts and waits of a function took d, whatever
+// retries are left. A retry whose backoff would end after the budget is not started; a running attempt is
+// not cut short, use the timeout for that. Zero, the default, sets no budget.
+func (fhi *FunctionHandlerImpl) SetMaxRetryDuration(d time.Duration) {
+	fhi.configure(func(s *settings) { s.maxRetryDuration = d })
+}
+
+// SetAttemptEstimate method to set how long the next attempt is expected to take, given the durations of the
+// attempts so far. A retry that cannot finish before the context's deadline is skipped. By default the last
+// attempt's duration is used.
+func (fhi *FunctionHandlerImpl) SetAttemptEstimate(estimate func(durations []time.Duration) time.Duration) {
+	fhi.configure(func(s *settings) { s.attemptEstimate = estimate })
+}
+
+// estimateAttempt method to estimate the duration of the next attempt
+func (fhi *FunctionHandlerImpl) estimateAttempt(durations []time.Duration) time.Duration {
+	cfg := fhi.settings()
+	if cfg.attemptEstimate != nil {
+		return cfg.attemptEstimate(durations)
+	}
+	return durations[len(durations)-1]
+}
+
+// SetParallel method to enable or disable parallel execution.
+//
+// Deprecated: use SetMode with ModeParallel or ModeSequential.
 func (fhi *FunctionHandlerImpl) SetParallel(isParallel bool) {
-	fhi.isParallel = isParallel
+	if isParallel {
+		fhi.SetMode(ModeParallel)
+	} else {
+		fhi.SetMode(ModeSequential)
+	}
+}
+
+// SetFailFast method to enable or disable fail-fast execution; disabling it switches back to parallel execution.
+//
+// Deprecated: use SetMode with ModeFailFast or ModeParallel.
+func (fhi *FunctionHandlerImpl) SetFailFast(failFast bool) {
+	if failFast {
+		fhi.SetMode(ModeFailFast)
+	} else if fhi.settings().mode == ModeFailFast {
+		fhi.SetMode(ModeParallel)
+	}
+}
+
+// SetStagger method to delay the start of each parallel function by its index times d.
+// jitter adds a random extra delay of up to jitter*d; a zero d starts everything at once. A function whose
+// delayed start would be past the batch context's deadline is not run and fails with ErrStaggerDeadline.
+func (fhi *FunctionHandlerImpl) SetStagger(d time.Duration, jitter float64) {
+	fhi.configure(func(s *settings) {
+		s.stagger = d
+		s.staggerJitter = jitter
+	})
+}
+
+// SetClock method to replace the clock used for delays, mainly for tests
+func (fhi *FunctionHandlerImpl) SetClock(clock Clock) {
+	fhi.configure(func(s *settings) { s.clock = clock })
+}
+
+// SetCopyArgs method to give every call its own deep copy of slice, map and pointer-to-struct arguments,
+// so functions sharing an argument do not race on it in parallel mode. Off by default for performance.
+func (fhi *FunctionHandlerImpl) SetCopyArgs(copyArgs bool) {
+	fhi.configure(func(s *settings) { s.copyArgs = copyArgs })
+}
+
+// SetName method to name the handler; the name prefixes its log lines and the errors it creates
+func (fhi *FunctionHandlerImpl) SetName(name string) {
+	fhi.configure(func(s *settings) { s.name = name })
+}
+
+// SetDefaultHandler method to set the error handler used for failures a typed error handler does not match
+func (fhi *FunctionHandlerImpl) SetDefaultHandler(handler interface{}) {
+	fhi.configure(func(s *settings) { s.defaultHandler = handler })
+}
+
+// SetRecoverHandler method to handle failures caused by a panic instead of the error handler.
+// Its non-nil return aborts the batch; without it panics reach the error handler as a *PanicError.
+func (fhi *FunctionHandlerImpl) SetRecoverHandler(handler func(recovered any, stack []byte, funcName string) error) {
+	fhi.configure(func(s *settings) { s.recoverHandler = handler })
+}
+
+// SetHandlerRetryLimit method to cap how often the error handler may ask for one function to run again.
+// The limit is separate from SetRetry; it defaults to 3 and a negative limit disables handler retries.
+func (fhi *FunctionHandlerImpl) SetHandlerRetryLimit(limit int) {
+	fhi.configure(func(s *settings) { s.handlerRetryLimit = limit })
+}
+
+// getHandlerRetryLimit method to return the handler retry limit, applying the default
+func (fhi *FunctionHandlerImpl) getHandlerRetryLimit() int {
+	cfg := fhi.settings()
+	switch {
+	case cfg.handlerRetryLimit == 0:
+		return defaultHandlerRetryLimit
+	case cfg.handlerRetryLimit < 0:
+		return 0
+	}
+	return cfg.handlerRetryLimit
+}
+
+// SetSlowThreshold method to report every attempt that takes longer than d, successful or not, with a
+// warning and a call to onSlow. An attempt cut short by the timeout is reported with the time until then.
+// A zero d disables the check.
+func (fhi *FunctionHandlerImpl) SetSlowThreshold(d time.Duration, onSlow func(name string, took time.Duration)) {
+	fhi.configure(func(s *settings) {
+		s.slowThreshold = d
+		s.onSlow = onSlow
+	})
+}
+
+// checkSlow method to report an attempt of the named function that took longer than the slow threshold
+func (fhi *FunctionHandlerImpl) checkSlow(name string, took time.Duration) {
+	cfg := fhi.settings()
+	if cfg.slowThreshold <= 0 || took <= cfg.slowThreshold {
+		return
+	}
+	fhi.logWarn("%s took %s, above the slow threshold of %s", name, took, cfg.slowThreshold)
+	if cfg.onSlow != nil {
+		cfg.onSlow(name, took)
+	}
+}
+
+// SetHandlerTimeout method to bound every call of the error handler by d, so a hung handler cannot
+// block the batch. By default an overrun counts as the handler returning ErrHandlerTimeout; zero waits forever.
+func (fhi *FunctionHandlerImpl) SetHandlerTimeout(d time.Duration) {
+	fhi.configure(func(s *settings) { s.handlerTimeout = d })
+}
+
+// SetIgnoreHandlerTimeout method to treat an error handler overrunning its timeout as having handled the error
+func (fhi *FunctionHandlerImpl) SetIgnoreHandlerTimeout(ignore bool) {
+	fhi.configure(func(s *settings) { s.ignoreHandlerTimeout = ignore })
+}
+
+// SetHandlerRetries method to call the error handler up to n more times, waiting backoff in between,
+// when it returns an error, before that error aborts the batch. Default zero calls it once.
+//
+// The error handler MUST be idempotent when this is used: a handler that failed half way, for example
+// after writing the failure to a database but before returning, is called again with the same error.
+func (fhi *FunctionHandlerImpl) SetHandlerRetries(n int, backoff time.Duration) {
+	fhi.configure(func(s *settings) {
+		s.handlerRetries = n
+		s.handlerBackoff = backoff
+	})
+}
+
+// SetAccumulateChunks method to make TryChunked return the results of all chunks instead of none
+func (fhi *FunctionHandlerImpl) SetAccumulateChunks(accumulate bool) {
+	fhi.configure(func(s *settings) { s.accumulateChunks = accumulate })
+}
+
+// SetFlattenSlices method to spread returned slices and arrays into one value per element.
+// With several return values each slice is spread in place; an empty or nil slice contributes no values.
+// Maps and strings are kept as single values.
+func (fhi *FunctionHandlerImpl) SetFlattenSlices(flatten bool) {
+	fhi.configure(func(s *settings) { s.flattenSlices = flatten })
+}
+
+// SetDiscardValues method to make batches return an empty results slice instead of collecting every value,
+// saving memory for side-effect-only batches. Error handlers and result callbacks still see every result.
+func (fhi *FunctionHandlerImpl) SetDiscardValues(discard bool) {
+	fhi.configure(func(s *settings) { s.discardValues = discard })
+}
+
+// collect method to add the values of a successful function to the batch results unless they are discarded
+func (fhi *FunctionHandlerImpl) collect(results []any, values []any) []any {
+	if fhi.settings().discardValues {
+		return results
+	}
+	return append(results, values...)
+}
+
+// errorf method to create an error, prefixed with the handler name when one is set
+func (fhi *FunctionHandlerImpl) errorf(format string, a ...any) error {
+	return prefixErrorf(fhi.settings().name, format, a)
+}
+
+// prefixedError struct to hold an error created by the handler with the prefix naming the handler, and the
+// execution IDs when set
+type prefixedError struct {
+	prefix string
+	err    error
+}
+
+func (e *prefixedError) Error() string { return e.prefix + ": " + e.err.Error() }
+func (e *prefixedError) Unwrap() error { return e.err }
+
+// unprefixedError struct to show a prefixedError without its prefix, when it is wrapped by an error that
+// already starts with the same prefix
+type unprefixedError struct {
+	*prefixedError
+}
+
+func (e unprefixedError) Error() string { return e.err.Error() }
+func (e unprefixedError) Unwrap() error { return e.prefixedError }
+
+// prefixErrorf function to create an error with fmt.Errorf, prefixed when prefix is set. Arguments that are
+// errors with the same prefix lose theirs in the message, so wrapping one does not repeat the prefix.
+func prefixErrorf(prefix, format string, a []any) error {
+	if prefix == "" {
+		return fmt.Errorf(format, a...)
+	}
+	a = slices.Clone(a)
+	for i, arg := range a {
+		if err, ok := arg.(*prefixedError); ok && err.prefix == prefix {
+			a[i] = unprefixedError{err}
+		}
+	}
+	return &prefixedError{prefix: prefix, err: fmt.Errorf(format, a...)}
+}
+
+// getClock method to return the configured clock or the real one
+func (fhi *FunctionHandlerImpl) getClock() Clock {
+	cfg := fhi.settings()
+	if cfg.clock == nil {
+		return realClock{}
+	}
+	return cfg.clock
+}
+
+// staggerDelay method to compute the start delay of the function at index i
+func (fhi *FunctionHandlerImpl) staggerDelay(i int) time.Duration {
+	cfg := fhi.settings()
+	if cfg.stagger <= 0 {
+		return 0
+	}
+	delay := time.Duration(i) * cfg.stagger
+	if cfg.staggerJitter > 0 {
+		delay += time.Duration(rand.Float64() * cfg.staggerJitter * float64(cfg.stagger))
+	}
+	return delay
 }
 
-// ConvertArgs method to convert arguments to reflect values
+// ConvertArgs method to convert arguments to reflect values. An untyped nil becomes a nil interface{} value;
+// use ConvertArgsFor to convert arguments for the parameters of a particular function.
 func (fhi *FunctionHandlerImpl) ConvertArgs(args ...interface{}) []reflect.Value {
 	inputs := make([]reflect.Value, len(args))
 	for i, arg := range args {
+		if arg == nil {
+			inputs[i] = reflect.Zero(anyType)
+			continue
+		}
 		inputs[i] = reflect.ValueOf(arg)
 	}
 	return inputs
 }
 
-// WrapFunction method to create a function that returns a Result
+// ConvertArgsFor method to convert arguments to reflect values of the parameters of function they are passed to,
+// after checking them like WrapChecked. An untyped nil becomes the nil of its pointer, interface, map, slice,
+// channel or function parameter, and a value passed for an interface parameter is wrapped in that interface.
+func (fhi *FunctionHandlerImpl) ConvertArgsFor(function interface{}, args ...interface{}) ([]reflect.Value, error) {
+	funcType := reflect.TypeOf(function)
+	if funcType == nil || funcType.Kind() != reflect.Func {
+		return nil, fhi.errorf("%w: got %T", ErrNotAFunction, function)
+	}
+	if err := fhi.checkArgs(funcType, args); err != nil {
+		return nil, err
+	}
+	inputs := make([]reflect.Value, len(args))
+	convertArgsInto(funcType, injected(funcType, len(args)), inputs, args)
+	return inputs, nil
+}
+
+// anyType is the reflect type of interface{}
+var anyType = reflect.TypeOf((*any)(nil)).Elem()
+
+// convertArgsInto function to fill inputs with the reflect values of args, passed to the parameters of funcType
+// from index first on, which checkArgs accepted
+func convertArgsInto(funcType reflect.Type, first int, inputs []reflect.Value, args []interface{}) {
+	for i, arg := range args {
+		paramType := paramTypeAt(funcType, first+i)
+		if arg == nil {
+			inputs[i] = reflect.Zero(paramType)
+			continue
+		}
+		inputs[i] = reflect.ValueOf(arg)
+		if paramType.Kind() == reflect.Interface {
+			inputs[i] = inputs[i].Convert(paramType)
+		}
+	}
+}
+
+// paramTypeAt function to return the type of the parameter of funcType at index i, the element type of a
+// variadic parameter
+func paramTypeAt(funcType reflect.Type, i int) reflect.Type {
+	if funcType.IsVariadic() && i == funcType.NumIn()-1 {
+		return funcType.In(i).Elem()
+	}
+	return funcType.In(i)
+}
+
+// nillable function to report whether the zero value of t is nil
+func nillable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return true
+	}
+	return false
+}
+
+// WrapFunction method to create a function that returns a Result.
+// A first parameter of type context.Context or <-chan struct{} without a matching argument is supplied by the handler.
+// Options such as WithRetries and WithTimeout after the arguments override the settings of the handler running it.
 func (fhi *FunctionHandlerImpl) WrapFunction(function interface{}, args ...interface{}) func() Result[any] {
-	return func() Result[any] {
-		funcValue := reflect.ValueOf(function)
-		funcType := funcValue.Type()
-		if funcType.Kind() != reflect.Func {
-			err := fmt.Errorf("no function provided")
+	return bind(fhi.wrapFunction(function, args))
+}
+
+// WrapChecked method to create a function like WrapFunction after checking that function is a function and that
+// args match its parameters, so wiring mistakes are reported when wrapping instead of when the batch runs. Only
+// the function is checked when the handler has argument transformers, which may still change the arguments.
+func (fhi *FunctionHandlerImpl) WrapChecked(function interface{}, args ...interface{}) (func() Result[any], error) {
+	funcType := reflect.TypeOf(function)
+	if funcType == nil || funcType.Kind() != reflect.Func {
+		err := fhi.errorf("%w: got %T", ErrNotAFunction, function)
+		fhi.LogError(err)
+		return nil, err
+	}
+	w := fhi.wrapFunction(function, args)
+	if len(fhi.settings().argTransformers) == 0 {
+		if err := fhi.checkArgs(funcType, w.args); err != nil {
+			fhi.LogError(err)
+			return nil, err
+		}
+	}
+	return bind(w), nil
+}
+
+// WrapFunctionSlice method to create a function like WrapFunction, taking the arguments as a slice.
+// Useful when the arguments arrive as []any, for example decoded from a JSON job payload.
+func (fhi *FunctionHandlerImpl) WrapFunctionSlice(function interface{}, args []interface{}) func() Result[any] {
+	return bind(fhi.wrapFunction(function, args))
+}
+
+// WrapNamed method to create a function like WrapFunction under the given name, which is used in logs,
+// metrics and pprof labels in place of the name taken from the function itself
+func (fhi *FunctionHandlerImpl) WrapNamed(name string, function interface{}, args ...interface{}) func() Result[any] {
+	w := fhi.wrapFunction(function, args)
+	w.name = name
+	return bind(w)
+}
+
+// ApplyArgs method to call function with the arguments in args right away, with the same
+// argument checks, error extraction and panic recovery as a function created by WrapFunction
+func (fhi *FunctionHandlerImpl) ApplyArgs(function interface{}, args []interface{}) Result[any] {
+	args, err := fhi.transformArgs(funcName(function), args)
+	if err != nil {
+		return Err[any](err)
+	}
+	return fhi.call(context.Background(), function, args, nil)
+}
+
+// Apply method to call function with args right away, with the configured retries and timeout.
+// No error handler is involved: failures are logged and returned, and a panic goes to the recover handler when one is set.
+func (fhi *FunctionHandlerImpl) Apply(function interface{}, args ...interface{}) Result[any] {
+	cfg := fhi.settings()
+	res := fhi.runFunction(context.Background(), fhi.WrapFunction(function, args...))
+	var panicErr *PanicError
+	if cfg.recoverHandler != nil && errors.As(res.Err, &panicErr) {
+		if err := cfg.recoverHandler(panicErr.Value, panicErr.Stack, panicErr.FuncName); err != nil {
 			fhi.LogError(err)
 			return Err[any](err)
 		}
-		if len(args) != funcType.NumIn() {
-			err := fmt.Errorf("argument count does not match function's parameter count")
+	}
+	return res
+}
+
+// wrapFunction method to describe a call of function with fixed arguments
+func (fhi *FunctionHandlerImpl) wrapFunction(function interface{}, args []interface{}) *wrapped {
+	args, opts := splitOptions(function, args)
+	// The argument count is fixed per wrapped function, so input buffers are pooled
+	// and reused across calls and retries instead of being allocated every time.
+	inputPool := sync.Pool{New: func() any {
+		inputs := make([]reflect.Value, len(args)+injectedFor(function, len(args)))
+		return &inputs
+	}}
+	w := &wrapped{name: funcName(function), function: function, args: args, opts: opts}
+	if name := optionsName(opts); name != "" {
+		w.name = name
+	}
+	w.run = func(exec *execution) Result[any] {
+		args, err := fhi.transformArgs(w.name, args)
+		if err != nil {
+			return Err[any](err)
+		}
+		buf := inputPool.Get().(*[]reflect.Value)
+		defer func() {
+			clear(*buf) // drop references so stale arguments are not kept alive or reused
+			inputPool.Put(buf)
+		}()
+		return fhi.drain(exec.ctx, fhi.call(exec.ctx, function, args, *buf))
+	}
+	return w
+}
+
+// call method to call function with args after checking them against its signature, injecting the
+// parameters the handler supplies from ctx. inputs is used when it has room for exactly the injected
+// parameters and args; a trailing error return becomes the Result's error.
+func (fhi *FunctionHandlerImpl) call(ctx context.Context, function interface{}, args []interface{}, inputs []reflect.Value) (res Result[any]) {
+	cfg := fhi.settings()
+	funcValue := reflect.ValueOf(function)
+	if funcValue.Kind() != reflect.Func {
+		err := fhi.errorf("%w: got %T", ErrNotAFunction, function)
+		fhi.LogError(err)
+		return Err[any](err)
+	}
+	funcType := funcValue.Type()
+	if err := fhi.checkArgs(funcType, args); err != nil {
+		fhi.LogError(err)
+		return Err[any](err)
+	}
+	// a panic in the function, or in preparing its inputs, becomes a PanicError for the error handler
+	defer func() {
+		if r := recover(); r != nil {
+			err := &PanicError{Value: r, Stack: debug.Stack(), FuncName: funcName(function)}
+			fhi.LogError(err)
+			res = Err[any](err)
+		}
+	}()
+	n := injected(funcType, len(args))
+	if len(inputs) != n+len(args) {
+		inputs = make([]reflect.Value, n+len(args))
+	}
+	injectInto(funcType, inputs[:n], ctx)
+	convertArgsInto(funcType, n, inputs[n:], args)
+	if cfg.copyArgs {
+		for i := n; i < len(inputs); i++ {
+			inputs[i] = copyArg(inputs[i])
+		}
+	}
+	results := funcValue.Call(inputs)
+	if funcType.NumOut() == 0 {
+		return Ok[any]()
+	}
+	lastIndex := len(results) - 1
+	if funcType.Out(lastIndex).Implements(errorType) {
+		errValue := results[lastIndex].Interface()
+		if errValue != nil {
+			err := errValue.(error)
 			fhi.LogError(err)
 			return Err[any](err)
 		}
-		inputs := fhi.ConvertArgs(args...)
-		results := funcValue.Call(inputs)
-		if funcType.NumOut() == 0 {
-			return Ok[any]()
-		}
-		lastIndex := len(results) - 1
-		if funcType.Out(lastIndex).Implements(reflect.TypeOf((*error)(nil)).Elem()) {
-			errValue := results[lastIndex].Interface()
-			if errValue != nil {
-				err := errValue.(error)
-				fhi.LogError(err)
-				return Err[any](err)
+		results = results[:lastIndex]
+	}
+	values := make([]any, 0, len(results))
+	for _, res := range results {
+		if cfg.flattenSlices && (res.Kind() == reflect.Slice || res.Kind() == reflect.Array) {
+			for i := 0; i < res.Len(); i++ {
+				values = append(values, res.Index(i).Interface())
 			}
-			results = results[:lastIndex]
+			continue
+		}
+		values = append(values, res.Interface())
+	}
+	return Ok(values...)
+}
+
+// checkArgs method to check args against the parameters of funcType.
+// Parameters the handler injects are not expected among args.
+func (fhi *FunctionHandlerImpl) checkArgs(funcType reflect.Type, args []interface{}) error {
+	n := injected(funcType, len(args))
+	if len(args) != funcType.NumIn()-n {
+		return fhi.errorf("%w: got %d, want %d", ErrArgCountMismatch, len(args), funcType.NumIn())
+	}
+	for i, arg := range args {
+		paramType := paramTypeAt(funcType, n+i)
+		argType := reflect.TypeOf(arg)
+		if argType == nil && !nillable(paramType) {
+			return fhi.errorf("%w: argument %d is nil, want %s", ErrArgTypeMismatch, i, paramType)
 		}
-		values := make([]any, len(results))
-		for i, res := range results {
-			values[i] = res.Interface()
+		if argType != nil && !argType.AssignableTo(paramType) {
+			return fhi.errorf("%w: argument %d is %T, want %s", ErrArgTypeMismatch, i, arg, paramType)
 		}
-		return Ok(values...)
 	}
+	return nil
 }
 
-// WrapErrorHandler method to wrap an error handler function
+// WrapWithValidators method to create a function that runs the validators on its arguments before every call.
+// A validator error wraps ErrValidation, is returned in place of calling the function and is never retried.
+func (fhi *FunctionHandlerImpl) WrapWithValidators(function interface{}, validators []func(args []interface{}) error, args ...interface{}) func() Result[any] {
+	w := fhi.wrapFunction(function, args)
+	return bind(&wrapped{name: w.name, function: function, args: w.args, opts: w.opts, validators: validators, run: func(exec *execution) Result[any] {
+		if err := fhi.validateArgs(validators, w.args); err != nil {
+			fhi.LogError(err)
+			return Err[any](err)
+		}
+		return w.run(exec)
+	}})
+}
+
+// validateArgs method to run the validators on args in order, returning the first error wrapped in ErrValidation
+func (fhi *FunctionHandlerImpl) validateArgs(validators []func(args []interface{}) error, args []interface{}) error {
+	for _, validate := range validators {
+		if err := validate(args); err != nil {
+			return fhi.errorf("%w: %w", ErrValidation, err)
+		}
+	}
+	return nil
+}
+
+// WrapWithResultValidator method to create a function whose values are checked by validate after every successful call.
+// A validator error turns the call into a failure wrapping ErrInvalidResult, which is retried like any other failure.
+func (fhi *FunctionHandlerImpl) WrapWithResultValidator(function interface{}, validate func(values []any) error, args ...interface{}) func() Result[any] {
+	w := fhi.wrapFunction(function, args)
+	return bind(&wrapped{name: w.name, function: function, args: w.args, opts: w.opts, run: func(exec *execution) Result[any] {
+		res := w.run(exec)
+		if res.IsErr() {
+			return res
+		}
+		if err := validate(res.Values); err != nil {
+			err = fhi.errorf("%w: %s: %w", ErrInvalidResult, w.name, err)
+			fhi.LogError(err)
+			return Err[any](err)
+		}
+		return res
+	}})
+}
+
+// WrapWithArgsFunc method to create a function whose arguments are computed right before every attempt.
+// argsFor receives the 1-based attempt number and the previous attempt's error; its error fails that attempt.
+func (fhi *FunctionHandlerImpl) WrapWithArgsFunc(function interface{}, argsFor func(attempt int, prevErr error) ([]interface{}, error)) func() Result[any] {
+	return bind(&wrapped{name: funcName(function), function: function, lateArgs: true, run: func(exec *execution) Result[any] {
+		args, err := argsFor(exec.attempt, exec.prevErr)
+		if err != nil {
+			err = fhi.errorf("argument provider failed: %w", err)
+			fhi.LogError(err)
+			return Err[any](err)
+		}
+		if args, err = fhi.transformArgs(funcName(function), args); err != nil {
+			return Err[any](err)
+		}
+		return fhi.drain(exec.ctx, fhi.call(exec.ctx, function, args, nil))
+	}})
+}
+
+// WrapWithCallback method to create a function like WrapFunction that passes its final result to onResult.
+// onResult is called once per run in a batch, after the retries and timeout and before the error handler;
+// its time does not count against the timeout and a panic in it is recovered and logged.
+func (fhi *FunctionHandlerImpl) WrapWithCallback(function interface{}, onResult func(Result[any]), args ...interface{}) func() Result[any] {
+	w := fhi.wrapFunction(function, args)
+	w.onResult = onResult
+	return bind(w)
+}
+
+// WrapErrorHandler method to wrap an error handler function.
+// A value implementing ErrorHandler is called through its HandleError method without reflection, and a
+// HandlerMux routes every failure to one of its handlers.
+// A function handler may take the error interface or a concrete error type such as func(e *APIError) error;
+// a typed handler is only called for failures that errors.As can convert to its type. A second ExecutionInfo
+// parameter, as in func(err error, info ExecutionInfo) error, receives the details of the failed run.
+// A handler returning (retry bool, err error) can ask for the failed function to run again. One returning
+// a value of another type and an error, such as (fallback any, err error), can substitute a non-nil value
+// for the failed function's result, as a cached copy when a fetch failed.
 func (fhi *FunctionHandlerImpl) WrapErrorHandler(handlerFunc interface{}) Result[HandlerValues] {
+	if mux, ok := handlerFunc.(*HandlerMux); ok {
+		return fhi.wrapMux(mux)
+	}
+	if errorHandler, ok := handlerFunc.(ErrorHandler); ok {
+		return Ok(HandlerValues{handler: errorHandler})
+	}
 	handlerValue := reflect.ValueOf(handlerFunc)
-	handlerType := handlerValue.Type()
-	if handlerType.Kind() != reflect.Func {
-		err := fmt.Errorf("provided handler is not a function")
+	if handlerValue.Kind() != reflect.Func {
+		err := fhi.errorf("%w: provided handler is neither a function nor an ErrorHandler", ErrInvalidHandler)
 		fhi.LogError(err)
 		return Err[HandlerValues](err)
 	}
-	if handlerType.NumIn() != 1 || handlerType.In(0) != reflect.TypeOf((*error)(nil)).Elem() {
-		err := fmt.Errorf("the error handler must take an error as an arg")
+	handlerType := handlerValue.Type()
+	validIn := handlerType.NumIn() == 1 || handlerType.NumIn() == 2 && handlerType.In(1) == executionInfoType
+	if !validIn || !handlerType.In(0).Implements(errorType) {
+		err := fhi.errorf("%w: the error handler must take an error, optionally followed by an ExecutionInfo", ErrInvalidHandler)
 		fhi.LogError(err)
 		return Err[HandlerValues](err)
 	}
-	if handlerType.NumOut() > 1 || (handlerType.NumOut() == 1 && !handlerType.Out(0).Implements(reflect.TypeOf((*error)(nil)).Elem())) {
-		err := fmt.Errorf("the error handler must return at most one error")
+	switch {
+	case handlerType.NumOut() == 0:
+	case handlerType.NumOut() == 1 && handlerType.Out(0).Implements(errorType):
+	case handlerType.NumOut() == 2 && handlerType.Out(1).Implements(errorType):
+	default:
+		err := fhi.errorf("%w: the error handler must return nothing, an error, (retry bool, err error) or (fallback any, err error)", ErrInvalidHandler)
 		fhi.LogError(err)
 		return Err[HandlerValues](err)
 	}
 	return Ok(HandlerValues{Func: &handlerValue})
 }
 
-// Try method to handle multiple functions and an error handler with optional parallelism
+// Try method to handle multiple functions and an error handler with the configured execution mode
 func (fhi *FunctionHandlerImpl) Try(handler interface{}, funcs ...func() Result[any]) ([]any, Result[any]) {
-	results := []any{}
+	return fhi.TryContext(context.Background(), handler, funcs...)
+}
+
+// TryContext method to run a batch like Try that is tied to ctx. Cancelling ctx stops the batch with the context's
+// cause: functions not started yet are skipped, and running ones see the cancellation through their context and
+// are waited for before TryContext returns, except in fail-fast mode which returns at once as on a failure.
+// The error handler is not called for a cancelled batch.
+func (fhi *FunctionHandlerImpl) TryContext(ctx context.Context, handler interface{}, funcs ...func() Result[any]) ([]any, Result[any]) {
+	release, err := fhi.acquireBatch(ctx)
+	if err != nil {
+		fhi.LogError(err)
+		return nil, Err[any](err)
+	}
+	defer release()
 	handlerFunc := fhi.WrapErrorHandler(handler)
 	if handlerFunc.IsErr() {
-		err := fmt.Errorf("invalid error handler")
+		return nil, Err[any](handlerFunc.Err)
+	}
+	if len(funcs) == 0 {
+		err := fhi.errorf("%w", ErrNoFunctions)
 		fhi.LogError(err)
 		return nil, Err[any](err)
 	}
-	if len(funcs) == 0 {
-		err := fmt.Errorf("no functions provided")
+	run, err := fhi.strategy()
+	if err == nil {
+		err = fhi.checkRetryBound(ctx)
+	}
+	if err != nil {
 		fhi.LogError(err)
 		return nil, Err[any](err)
 	}
-	if fhi.isParallel {
-		var wg sync.WaitGroup
-		resultCh := make(chan Result[any], len(funcs))
-		for _, fn := range funcs {
-			wg.Add(1)
-			go func(fn func() Result[any]) {
-				defer wg.Done()
-				var res Result[any]
-				if fhi.timeout > 0 {
-					ctx, cancel := context.WithTimeout(context.Background(), fhi.timeout)
-					defer cancel()
-					ch := make(chan Result[any], 1)
-					go func() {
-						ch <- fhi.retryFunction(fn)
-					}()
-					select {
-					case res = <-ch:
-					case <-ctx.Done():
-						err := fmt.Errorf("function timed out")
-						fhi.LogError(err)
-						res = Err[any](err)
-					}
-				} else {
-					res = fhi.retryFunction(fn)
-				}
-				resultCh <- res
-			}(fn)
+	results, err := run(fhi.withBatchID(ctx), fhi, handlerFunc.Values[0], funcs)
+	if err != nil {
+		return results, Err[any](err)
+	}
+	return results, Ok[any](nil)
+}
+
+// MustTry method to call Try and panic with its error when the batch fails.
+// Meant for scripts and init code; servers should call Try and handle the error instead.
+func (fhi *FunctionHandlerImpl) MustTry(handler interface{}, funcs ...func() Result[any]) []any {
+	results, res := fhi.Try(handler, funcs...)
+	if res.IsErr() {
+		panic(res.Err)
+	}
+	return results
+}
+
+// runFunction method to run a function with the configured timeout and retries, reporting it to the metrics
+// and passing the result to the function's callback
+func (fhi *FunctionHandlerImpl) runFunction(ctx context.Context, fn func() Result[any]) Result[any] {
+	ctx = fhi.withExecutionID(ctx)
+	w := describe(fn)
+	if w != nil && len(w.opts) > 0 {
+		fhi = fhi.overridden(w.opts)
+	}
+	var res Result[any]
+	if tracer := fhi.getTracer(); tracer != nil {
+		var end func(err error)
+		ctx, end = tracer.StartRun(ctx, fhi.settings().name, nameOf(fn, w))
+		defer func() { end(res.Err) }()
+	}
+	attempts := new(atomic.Int64)
+	start := fhi.getClock().Now()
+	metrics := fhi.getMetrics()
+	if metrics == nil && !fhi.settings().pprofLabels {
+		res = fhi.runTimed(ctx, fn, attempts)
+	} else {
+		name := nameOf(fn, w)
+		if metrics != nil {
+			metrics.Started(fhi.settings().name, name)
+		}
+		res = fhi.labelled(ctx, name, func(ctx context.Context) Result[any] {
+			return fhi.runTimed(ctx, fn, attempts)
+		})
+		if metrics != nil {
+			metrics.Finished(fhi.settings().name, name, outcomeOf(ctx, res), fhi.getClock().Now().Sub(start))
+		}
+	}
+	event := HookEvent{Function: nameOf(fn, w), Attempt: int(attempts.Load()), Duration: fhi.getClock().Now().Sub(start), Err: res.Err}
+	ids, _ := ExecutionIDsFrom(ctx)
+	res.info = &ExecutionInfo{
+		Name:        event.Function,
+		ExecutionID: ids.Execution,
+		BatchID:     ids.Batch,
+		Attempts:    event.Attempt,
+		Duration:    event.Duration,
+		TimedOut:    errors.Is(res.Err, ErrTimeout),
+	}
+	if res.IsErr() {
+		fhi.fire(ctx, hookFailure, event)
+	} else {
+		fhi.fire(ctx, hookSuccess, event)
+	}
+	if w != nil && w.onResult != nil {
+		fhi.notifyResult(ctx, w, res)
+	}
+	return res
+}
+
+// notifyResult method to pass a final result to the function's callback, logging a panic in it
+func (fhi *FunctionHandlerImpl) notifyResult(ctx context.Context, w *wrapped, res Result[any]) {
+	defer func() {
+		if r := recover(); r != nil {
+			fhi.logErrorIn(ctx, fhi.errorfIn(ctx, "result callback of %s panicked: %v", w.name, r))
+		}
+	}()
+	w.onResult(res)
+}
+
+// runTimed method to run a function with the configured retries within the configured timeout.
+// When parent is cancelled it waits for the running attempt to return instead of reporting a timeout.
+func (fhi *FunctionHandlerImpl) runTimed(parent context.Context, fn func() Result[any], attempts *atomic.Int64) Result[any] {
+	timeout := fhi.overallTimeout()
+	if timeout <= 0 { // runWithRetry times every attempt on its own
+		return fhi.runWithRetry(parent, fn, attempts)
+	}
+	start := fhi.getClock().Now()
+	ctx, cancel := context.WithTimeoutCause(parent, timeout, timeoutCause(timeout))
+	defer cancel()
+	ch := make(chan Result[any], 1)
+	go func() {
+		ch <- fhi.runWithRetry(ctx, fn, attempts)
+	}()
+	select {
+	case res := <-ch:
+		return res
+	case <-ctx.Done():
+		if parent.Err() != nil {
+			return <-ch
+		}
+		w := describe(fn)
+		fhi.cancelTimedOut(w, context.Cause(ctx))
+		name, took := nameOf(fn, w), fhi.getClock().Now().Sub(start)
+		fhi.checkSlow(name, took)
+		err := fhi.errorfIn(parent, "%w", context.Cause(ctx))
+		fhi.logErrorIn(parent, err)
+		fhi.fire(parent, hookTimeout, HookEvent{Function: name, Attempt: int(attempts.Load()), Duration: took, Err: err})
+		return Err[any](err)
+	}
+}
+
+// outcome struct to pair a finished function with its result
+type outcome struct {
+	index          int
+	fn             func() Result[any]
+	res            Result[any]
+	handlerRetries int
+}
+
+// settle method to pass a failed result to the error handler, running the function again for as long as
+// the handler asks for it and the handler retry limit allows. It returns the final result, an Ok result
+// holding the fallback the handler substituted, or the handler's error when the batch must abort.
+func (fhi *FunctionHandlerImpl) settle(ctx context.Context, handler HandlerValues, index int, fn func() Result[any], res Result[any]) (Result[any], error) {
+	for handlerRetries := 0; res.IsErr(); handlerRetries++ {
+		retry, fallback, err := fhi.callHandler(handler, res.Err, executionInfo(index, res))
+		if err != nil {
+			return res, err
+		}
+		if fallback != nil {
+			return Ok(fallback), nil
+		}
+		if !retry || !fhi.allowHandlerRetry(handlerRetries, res.Err) {
+			break
+		}
+		res = fhi.runFunction(ctx, fn)
+	}
+	return res, nil
+}
+
+// allowHandlerRetry method to check a retry requested by the error handler against the handler retry limit
+func (fhi *FunctionHandlerImpl) allowHandlerRetry(handlerRetries int, err error) bool {
+	if limit := fhi.getHandlerRetryLimit(); handlerRetries >= limit {
+		fhi.LogError(fhi.errorf("error handler retry limit of %d reached: %w", limit, err))
+		return false
+	}
+	return true
+}
+
+// callHandler method to pass a failure to the error handler. A non-nil error means the batch must abort,
+// retry reports that a handler returning (bool, error) asked for the function to run again, and a non-nil
+// fallback is the value a handler returning (value, error) substitutes for the failed function's result.
+// A typed handler or HandlerMux that does not match the failure falls back to the default handler, and a failure
+// no handler accepts is returned as is.
+func (fhi *FunctionHandlerImpl) callHandler(handler HandlerValues, err error, info ExecutionInfo) (retry bool, fallback any, abort error) {
+	cfg := fhi.settings()
+	var panicErr *PanicError
+	if cfg.recoverHandler != nil && errors.As(err, &panicErr) {
+		if recoverError := cfg.recoverHandler(panicErr.Value, panicErr.Stack, panicErr.FuncName); recoverError != nil {
+			fhi.LogError(recoverError)
+			return false, nil, recoverError
+		}
+		return false, nil, nil
+	}
+	if handler.routes != nil {
+		if routed, found := handler.route(err); found {
+			handler = routed
+		}
+	}
+	arg, ok := handler.errorArg(err)
+	if !ok && cfg.defaultHandler != nil {
+		defaultHandler := fhi.WrapErrorHandler(cfg.defaultHandler)
+		if defaultHandler.IsErr() {
+			return false, nil, defaultHandler.Err
+		}
+		handler = defaultHandler.Values[0]
+		arg, ok = handler.errorArg(err)
+	}
+	if !ok {
+		fhi.LogError(err)
+		return false, nil, err
+	}
+	for i := 0; ; i++ {
+		retry, fallback, abort = fhi.invokeTimed(handler, err, arg, info)
+		if abort == nil {
+			return retry, fallback, nil
+		}
+		if i >= cfg.handlerRetries {
+			fhi.LogError(abort)
+			return false, nil, abort
 		}
-		wg.Wait()
-		close(resultCh)
-		for res := range resultCh {
+		fhi.logWarn("error handler attempt %d of %d failed, retrying: %v", i+1, cfg.handlerRetries+1, abort)
+		<-fhi.getClock().After(cfg.handlerBackoff)
+	}
+}
+
+// handlerOutcome struct to carry the return of an error handler call made in its own goroutine
+type handlerOutcome struct {
+	retry     bool
+	fallback  any
+	abort     error
+	recovered any
+}
+
+// invokeTimed method to call the error handler within the handler timeout. A handler that overruns it
+// is left to finish in the background and counts as having returned ErrHandlerTimeout, or nil when
+// SetIgnoreHandlerTimeout is set. A panic in the handler is raised again in the calling goroutine.
+func (fhi *FunctionHandlerImpl) invokeTimed(handler HandlerValues, err error, arg reflect.Value, info ExecutionInfo) (retry bool, fallback any, abort error) {
+	cfg := fhi.settings()
+	if cfg.handlerTimeout <= 0 {
+		return invokeHandler(handler, err, arg, info)
+	}
+	ch := make(chan handlerOutcome, 1) // buffered so a handler finishing after the timeout does not block
+	go func() {
+		var o handlerOutcome
+		defer func() {
+			o.recovered = recover()
+			ch <- o
+		}()
+		o.retry, o.fallback, o.abort = invokeHandler(handler, err, arg, info)
+	}()
+	select {
+	case o := <-ch:
+		if o.recovered != nil {
+			panic(o.recovered)
+		}
+		return o.retry, o.fallback, o.abort
+	case <-fhi.getClock().After(cfg.handlerTimeout):
+		fhi.logWarn("error handler did not return within %s for: %v", cfg.handlerTimeout, err)
+		if cfg.ignoreHandlerTimeout {
+			return false, nil, nil
+		}
+		return false, nil, fhi.errorf("%w after %s: %w", ErrHandlerTimeout, cfg.handlerTimeout, err)
+	}
+}
+
+// invokeHandler function to make one call of an error handler with the failure, converted to arg for function handlers
+func invokeHandler(handler HandlerValues, err error, arg reflect.Value, info ExecutionInfo) (retry bool, fallback any, abort error) {
+	if handler.handler != nil {
+		return false, nil, handler.handler.HandleError(err)
+	}
+	in := []reflect.Value{arg}
+	if handler.Func.Type().NumIn() == 2 {
+		in = append(in, reflect.ValueOf(info))
+	}
+	handlerResults := handler.Func.Call(in)
+	if len(handlerResults) > 0 {
+		if handlerError, ok := handlerResults[len(handlerResults)-1].Interface().(error); ok && handlerError != nil {
+			return false, nil, handlerError
+		}
+	}
+	if len(handlerResults) == 2 {
+		if handlerResults[0].Kind() == reflect.Bool {
+			return handlerResults[0].Bool(), nil, nil
+		}
+		return false, handlerResults[0].Interface(), nil
+	}
+	return false, nil, nil
+}
+
+// RunWithRetry method to call fn until it succeeds or the retries are used up, waiting between attempts.
+// It is only the attempt loop: the error handler is not invoked, and the timeout is only applied, to every
+// attempt, with SetRetryOnTimeout. Cancelling ctx stops it before the next attempt or during the wait between attempts,
+// and the error then wraps context.Cause(ctx).
+// A failure wrapping ErrPermanent or refused by SetRetryIf is not retried, and one made with RetryAfter sets the
+// wait before the next attempt. An open circuit breaker ends the loop. SetMaxRetryDuration bounds the total time. The failure left once the retries or
+// that budget are used up wraps ErrRetryExhausted.
+func (fhi *FunctionHandlerImpl) RunWithRetry(ctx context.Context, fn func() Result[any]) Result[any] {
+	return fhi.runWithRetry(ctx, fn, nil)
+}
+
+// runWithRetry method to run the attempt loop of RunWithRetry, counting the attempts in attempts when set
+func (fhi *FunctionHandlerImpl) runWithRetry(ctx context.Context, fn func() Result[any], attempts *atomic.Int64) Result[any] {
+	cfg := fhi.settings()
+	var res Result[any]
+	w := describe(fn)
+	timeouts := 0
+	var durations []time.Duration
+	if err := fhi.checkRetryBound(ctx); err != nil {
+		fhi.LogError(err)
+		return Err[any](err)
+	}
+	began := fhi.getClock().Now()
+	for i := 0; cfg.retries == RetryForever || i <= cfg.retries; i++ {
+		if err := ctx.Err(); err != nil {
+			return fhi.stoppedRetrying(ctx, res)
+		}
+		if err := fhi.allowAttempt(nameOf(fn, w)); err != nil {
 			if res.IsErr() {
-				handlerResults := handlerFunc.Values[0].Func.Call([]reflect.Value{reflect.ValueOf(res.Err)})
-				if len(handlerResults) == 1 {
-					if handlerError, ok := handlerResults[0].Interface().(error); ok && handlerError != nil {
-						fhi.LogError(handlerError)
-						return nil, Err[any](handlerError)
-					}
-				}
-			} else {
-				results = append(results, res.Values...)
+				err = fmt.Errorf("%w: %w", err, res.Err)
 			}
+			fhi.logErrorIn(ctx, err)
+			return Err[any](err)
 		}
-	} else {
-		for _, fn := range funcs {
-			var res Result[any]
-			if fhi.timeout > 0 {
-				ctx, cancel := context.WithTimeout(context.Background(), fhi.timeout)
-				defer cancel()
-				ch := make(chan Result[any], 1)
-				go func() {
-					ch <- fhi.retryFunction(fn)
-				}()
-				select {
-				case res = <-ch:
-				case <-ctx.Done():
-					err := fmt.Errorf("function timed out")
-					fhi.LogError(err)
-					res = Err[any](err)
+		if limiter := fhi.getRateLimiter(); limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				if ctx.Err() != nil {
+					return fhi.stoppedRetrying(ctx, res)
 				}
-			} else {
-				res = fhi.retryFunction(fn)
+				err = fhi.errorfIn(ctx, "rate limiter: %w", err)
+				fhi.logErrorIn(ctx, err)
+				return Err[any](err)
 			}
-			if res.IsErr() {
-				handlerResults := handlerFunc.Values[0].Func.Call([]reflect.Value{reflect.ValueOf(res.Err)})
-				if len(handlerResults) == 1 {
-					if handlerError, ok := handlerResults[0].Interface().(error); ok && handlerError != nil {
-						fhi.LogError(handlerError)
-						return nil, Err[any](handlerError)
-					}
-				}
-			} else {
-				results = append(results, res.Values...)
+		}
+		start := fhi.getClock().Now()
+		if attempts != nil {
+			attempts.Add(1)
+		}
+		fhi.fire(ctx, hookStart, HookEvent{Function: nameOf(fn, w), Attempt: i + 1})
+		exec := &execution{ctx: ctx, attempt: i + 1, prevErr: res.Err}
+		var endAttempt func(err error)
+		if tracer := fhi.getTracer(); tracer != nil {
+			exec.ctx, endAttempt = tracer.StartAttempt(ctx, cfg.name, nameOf(fn, w), i+1)
+		}
+		if timeout := fhi.attemptTimeoutOf(); timeout > 0 {
+			res = fhi.attemptTimed(fn, w, exec, timeout)
+			if errors.Is(res.Err, ErrTimeout) {
+				timeouts++
+				fhi.fire(ctx, hookTimeout, HookEvent{Function: nameOf(fn, w), Attempt: i + 1, Duration: fhi.getClock().Now().Sub(start), Err: res.Err})
 			}
+		} else {
+			res = fhi.hedgedAttempt(fn, w, exec)
+		}
+		if res.IsOk() {
+			res = fhi.validateResult(nameOf(fn, w), res)
+		}
+		fhi.recordAttempt(ctx, nameOf(fn, w), res.Err)
+		if endAttempt != nil {
+			endAttempt(res.Err)
+		}
+		took := fhi.getClock().Now().Sub(start)
+		durations = append(durations, took)
+		if ctx.Err() == nil { // a timed out attempt was already reported by runTimed
+			fhi.checkSlow(nameOf(fn, w), took)
+		}
+		if res.IsOk() {
+			return res
+		}
+		fhi.logErrorIn(ctx, res.Err)
+		if errors.Is(res.Err, ErrValidation) || errors.Is(res.Err, ErrArgTransform) || errors.Is(res.Err, ErrPermanent) {
+			return res // these failures are deterministic, retrying cannot help
+		}
+		if cfg.retryIf != nil && !cfg.retryIf(res.Err) {
+			return res
+		}
+		if ctx.Err() != nil { // the attempt ended with the context, do not wait for a retry that cannot start
+			return fhi.stoppedRetrying(ctx, res)
+		}
+		if i == cfg.retries {
+			break
+		}
+		backoff := fhi.backoffFor(i+1, res.Err)
+		if cfg.maxRetryDuration > 0 {
+			if spent := fhi.getClock().Now().Sub(began); spent+backoff > cfg.maxRetryDuration {
+				err := fhi.errorfIn(ctx, "%w: budget of %s used up after %d attempts in %s: %w", ErrRetryExhausted, cfg.maxRetryDuration, i+1, spent, res.Err)
+				fhi.logErrorIn(ctx, err)
+				return Err[any](err)
+			}
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			left, need := deadline.Sub(fhi.getClock().Now()), backoff+fhi.estimateAttempt(durations)
+			if left < need {
+				err := fhi.errorfIn(ctx, "retry skipped, %s left but the next attempt needs about %s: %w: %w", left, need, context.DeadlineExceeded, res.Err)
+				fhi.logErrorIn(ctx, err)
+				return Err[any](err)
+			}
+		}
+		fhi.fire(ctx, hookRetry, HookEvent{Function: nameOf(fn, w), Attempt: i + 2, Duration: backoff, Err: res.Err})
+		select {
+		case <-fhi.getClock().After(backoff):
+		case <-ctx.Done():
+			return fhi.stoppedRetrying(ctx, res)
+		}
+		if metrics := fhi.getMetrics(); metrics != nil {
+			metrics.Retried(cfg.name, nameOf(fn, w))
 		}
 	}
-	return results, Ok[any](nil)
+	if ctx.Err() != nil { // the last attempt ended with the context, say why
+		return fhi.stoppedRetrying(ctx, res)
+	}
+	if cfg.retries == 0 {
+		return res
+	}
+	if timeouts > 0 {
+		return Err[any](fhi.errorfIn(ctx, "%w after %d attempts: %w (%d timed out)", ErrRetryExhausted, cfg.retries+1, res.Err, timeouts))
+	}
+	return Err[any](fhi.errorfIn(ctx, "%w after %d attempts: %w", ErrRetryExhausted, cfg.retries+1, res.Err))
 }
 
-// retryFunction method to handle retry logic
-func (fhi *FunctionHandlerImpl) retryFunction(fn func() Result[any]) Result[any] {
-	var res Result[any]
-	for i := 0; i <= fhi.retries; i++ {
-		res = fn()
-		if res.IsOk() {
-			return res
+// attemptTimed method to make one attempt within timeout, returning ErrTimeout when it runs out.
+// When ctx itself is cancelled the attempt is waited for, as runTimed does.
+func (fhi *FunctionHandlerImpl) attemptTimed(fn func() Result[any], w *wrapped, exec *execution, timeout time.Duration) Result[any] {
+	parent := exec.ctx
+	ctx, cancel := context.WithTimeoutCause(parent, timeout, timeoutCause(timeout))
+	defer cancel()
+	exec.ctx = ctx
+	ch := make(chan Result[any], 1)
+	go func() {
+		ch <- fhi.hedgedAttempt(fn, w, exec)
+	}()
+	select {
+	case res := <-ch:
+		return res
+	case <-ctx.Done():
+		if parent.Err() != nil {
+			return <-ch
 		}
-		fhi.LogError(res.Err)
-		time.Sleep(time.Second) // Backoff can be added here
+		fhi.cancelTimedOut(w, context.Cause(ctx))
+		return Err[any](fhi.errorfIn(parent, "%w", context.Cause(ctx)))
 	}
-	return res
+}
+
+// timeoutCause function to build the cause of a context cancelled by a timeout of d
+func timeoutCause(d time.Duration) error {
+	return fmt.Errorf("%w after %s", ErrTimeout, d)
+}
+
+// attempt function to make one call of fn, through its description when it was created by a Wrap method.
+// A panic is turned into a PanicError result.
+func attempt(fn func() Result[any], w *wrapped, exec *execution) (res Result[any]) {
+	defer func() {
+		if r := recover(); r != nil {
+			name := funcName(fn)
+			if w != nil {
+				name = w.name
+			}
+			res = Err[any](&PanicError{Value: r, Stack: debug.Stack(), FuncName: name})
+		}
+	}()
+	if w != nil {
+		return w.run(exec)
+	}
+	return fn()
+}
+
+// funcName function to return the name of a function value for logs and errors
+func funcName(function interface{}) string {
+	funcValue := reflect.ValueOf(function)
+	if funcValue.Kind() != reflect.Func {
+		return "unknown"
+	}
+	if f := runtime.FuncForPC(funcValue.Pointer()); f != nil {
+		return f.Name()
+	}
+	return "unknown"
+}
+
+// stoppedRetrying method to build the result of a retry loop interrupted by its context
+func (fhi *FunctionHandlerImpl) stoppedRetrying(ctx context.Context, last Result[any]) Result[any] {
+	if last.IsErr() {
+		return Err[any](fhi.errorfIn(ctx, "retries stopped: %w: %w", context.Cause(ctx), last.Err))
+	}
+	return Err[any](fhi.errorfIn(ctx, "retries stopped: %w", context.Cause(ctx)))
+}
+
+// batchCancelled method to log and return the error of a batch stopped by its context
+func (fhi *FunctionHandlerImpl) batchCancelled(ctx context.Context) error {
+	err := fhi.errorfIn(ctx, "batch cancelled: %w", context.Cause(ctx))
+	fhi.logErrorIn(ctx, err)
+	return err
+}
+
+// logWarn method to log a warning, prefixed with the handler name when one is set
+func (fhi *FunctionHandlerImpl) logWarn(format string, a ...any) {
+	cfg := fhi.settings()
+	msg := fmt.Sprintf(format, a...)
+	if logger := fhi.getLogSink(); logger != nil {
+		logger.Warn(msg, fhi.logAttrs(nil)...)
+		return
+	}
+	if cfg.name != "" {
+		fhi.output(fmt.Sprintf("[WARN] [%s] %s", cfg.name, msg))
+		return
+	}
+	fhi.output(fmt.Sprintf("[WARN] %s", msg))
 }
 
 // LogError logs the error with file and line number information, very useful for the errorhandler
 func (fhi *FunctionHandlerImpl) LogError(err error) {
 	if err != nil {
 		_, file, line, _ := runtime.Caller(2) // Adjusted to capture the correct call stack frame
-		log.Printf("[ERROR] %s:%d %v", file, line, err)
+		fhi.writeError("", file, line, err)
+	}
+}
+
+// logErrorIn method to log an error like LogError, adding the IDs of the execution running under ctx
+func (fhi *FunctionHandlerImpl) logErrorIn(ctx context.Context, err error) {
+	if err != nil {
+		ids, _ := ExecutionIDsFrom(ctx)
+		_, file, line, _ := runtime.Caller(2)
+		fhi.writeError(ids.String(), file, line, err)
 	}
 }
 
+// writeError method to write an error line, prefixed with the handler name and the IDs when set
+func (fhi *FunctionHandlerImpl) writeError(ids, file string, line int, err error) {
+	cfg := fhi.settings()
+	if logger := fhi.getLogSink(); logger != nil {
+		attrs := []any{"error", err, "source", fmt.Sprintf("%s:%d", file, line)}
+		if ids != "" {
+			attrs = append(attrs, "ids", ids)
+		}
+		logger.Error("handler error", fhi.logAttrs(attrs)...)
+		return
+	}
+	prefix := "[ERROR]"
+	if cfg.name != "" {
+		prefix += " [" + cfg.name + "]"
+	}
+	if ids != "" {
+		prefix += " [" + ids + "]"
+	}
+	fhi.output(fmt.Sprintf("%s %s:%d %v", prefix, file, line, err))
+}
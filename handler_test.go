@@ -0,0 +1,941 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestSettersDuringBatches is meant for go test -race: setters run on a handler while it and its child run
+// batches.
+func TestSettersDuringBatches(t *testing.T) {
+	fh := NewHandler(WithMode(ModeParallel))
+	child := fh.Child()
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			fh.SetTimeout(time.Duration(i%3+1) * time.Second)
+			fh.SetRetry(i % 2)
+			fh.SetMode([]ExecutionMode{ModeParallel, ModeSequential, ModeCollectErrors}[i%3])
+			fh.SetName("reconfigured")
+			fh.SetStagger(0, 0)
+			fh.SetRateLimit(float64(1000+i), 10)
+			fh.SetCircuitBreaker(100, time.Second, 1)
+			fh.OnStart(func(e HookEvent) {})
+			fh.UseArgTransformer(func(name string, args []interface{}) ([]interface{}, error) { return args, nil })
+			if err := fh.ApplyConfig(fh.Config()); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	add := func(a, b int) int { return a + b }
+	for _, h := range []*FunctionHandlerImpl{fh, child, fh, child} {
+		wg.Add(1)
+		go func(h *FunctionHandlerImpl) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				results, res := h.Try(func(err error) error { return err }, h.WrapFunction(add, 1, 2), h.WrapFunction(add, 3, 4))
+				if res.IsErr() || len(results) != 2 {
+					t.Errorf("Try = %v, %v", results, res.Err)
+					return
+				}
+			}
+		}(h)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+}
+
+func TestSettersDoNotChangeDerivedHandlers(t *testing.T) {
+	fh := NewHandler(WithRetries(2), WithName("parent"))
+	fh.UseArgTransformer(func(name string, args []interface{}) ([]interface{}, error) { return args, nil })
+	child := fh.Child(WithName("child"))
+	fh.SetRetry(5)
+	fh.UseArgTransformer(func(name string, args []interface{}) ([]interface{}, error) { return args, nil })
+	if got := child.Config().Retries; got != 2 {
+		t.Fatalf("child retries = %d after the parent changed, want 2", got)
+	}
+	if got := len(child.settings().argTransformers); got != 1 {
+		t.Fatalf("child has %d argument transformers after the parent added one, want 1", got)
+	}
+	if got := fh.Config().Name; got != "parent" {
+		t.Fatalf("parent name = %q, want parent", got)
+	}
+}
+
+// requireGoroutines function to fail the test unless the number of goroutines drops back to before within
+// a second
+func requireGoroutines(t *testing.T, before int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("%d goroutines before, %d after", before, after)
+	}
+}
+
+func TestWrapFunctionSliceFromJSON(t *testing.T) {
+	var payload struct {
+		Args []any `json:"args"`
+	}
+	if err := json.Unmarshal([]byte(`{"args": ["order-7", 3, true, null]}`), &payload); err != nil {
+		t.Fatal(err)
+	}
+	ship := func(id string, qty float64, urgent bool, note *string) string {
+		return fmt.Sprintf("%s x%v urgent=%v note=%v", id, qty, urgent, note != nil)
+	}
+	fh := NewHandler()
+	want := "order-7 x3 urgent=true note=false"
+	if res := fh.WrapFunctionSlice(ship, payload.Args)(); res.IsErr() || res.Values[0] != want {
+		t.Fatalf("WrapFunctionSlice got %v, want %q", res, want)
+	}
+	if res := fh.ApplyArgs(ship, payload.Args); res.IsErr() || res.Values[0] != want {
+		t.Fatalf("ApplyArgs got %v, want %q", res, want)
+	}
+}
+
+func TestWrapFunctionSliceErrorsMatchWrapFunction(t *testing.T) {
+	fn := func(id string, qty int) {}
+	tests := []struct {
+		name string
+		args []any
+		want error
+	}{
+		{"too few", []any{"a"}, ErrArgCountMismatch},
+		{"too many", []any{"a", 1, 2}, ErrArgCountMismatch},
+		{"wrong type", []any{1, 1}, ErrArgTypeMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler()
+			positional := fh.WrapFunction(fn, tt.args...)()
+			slice := fh.WrapFunctionSlice(fn, tt.args)()
+			applied := fh.ApplyArgs(fn, tt.args)
+			if !errors.Is(positional.Err, tt.want) {
+				t.Fatalf("WrapFunction got %v, want %v", positional.Err, tt.want)
+			}
+			if slice.Err == nil || slice.Err.Error() != positional.Err.Error() {
+				t.Fatalf("WrapFunctionSlice got %v, want %v", slice.Err, positional.Err)
+			}
+			if applied.Err == nil || applied.Err.Error() != positional.Err.Error() {
+				t.Fatalf("ApplyArgs got %v, want %v", applied.Err, positional.Err)
+			}
+		})
+	}
+}
+
+func TestHandlerRequestsRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      ExecutionMode
+		limit     int
+		wantRuns  int32
+		wantCalls int32
+		wantErr   error
+	}{
+		{"sequential", ModeSequential, 0, 3, 3, nil},
+		{"parallel", ModeParallel, 0, 3, 3, nil},
+		{"fail-fast stops at the failure left", ModeFailFast, 0, 3, 3, errBoom},
+		{"capped", ModeSequential, 1, 2, 2, nil},
+		{"disabled", ModeParallel, -1, 1, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler(WithMode(tt.mode))
+			fh.SetHandlerRetryLimit(tt.limit)
+			var runs, calls atomic.Int32
+			flaky := fh.WrapFunction(func() error {
+				runs.Add(1)
+				return errBoom
+			})
+			// the handler asks for a retry twice, then gives up and accepts the failure
+			handler := func(err error) (bool, error) {
+				return calls.Add(1) <= 2, nil
+			}
+			results, res := fh.Try(handler, flaky, fh.WrapFunction(func() int { return 1 }))
+			if !errors.Is(res.Err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", res.Err, tt.wantErr)
+			}
+			if tt.wantErr == nil && fmt.Sprint(results) != "[1]" {
+				t.Fatalf("got %v, want the other function's value", results)
+			}
+			if runs.Load() != tt.wantRuns || calls.Load() != tt.wantCalls {
+				t.Fatalf("ran %d times with %d handler calls, want %d and %d", runs.Load(), calls.Load(), tt.wantRuns, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestMustTry(t *testing.T) {
+	fh := NewHandler(WithMode(ModeCollectErrors))
+	pass := func(err error) error { return err }
+	if got := fh.MustTry(pass, fh.WrapFunction(func() int { return 1 })); fmt.Sprint(got) != "[1]" {
+		t.Fatalf("got %v, want [1]", got)
+	}
+	defer func() {
+		r := recover()
+		err, ok := r.(error)
+		if !ok {
+			t.Fatalf("panicked with %T, want an error", r)
+		}
+		// the aggregate keeps every failure introspectable
+		var api *apiError
+		if !errors.Is(err, errBoom) || !errors.As(err, &api) || api.code != 500 {
+			t.Fatalf("panic value %v does not wrap the failures", err)
+		}
+	}()
+	fh.MustTry(pass,
+		fh.WrapFunction(func() error { return errBoom }),
+		fh.WrapFunction(func() error { return &apiError{code: 500} }),
+	)
+	t.Fatal("MustTry did not panic")
+}
+
+func TestWrapWithCallback(t *testing.T) {
+	for _, mode := range []ExecutionMode{ModeSequential, ModeParallel} {
+		t.Run(mode.String(), func(t *testing.T) {
+			fh := NewHandler(WithMode(mode), WithRetries(2), WithBackoff(ConstantBackoff(0)), WithTimeout(time.Second))
+			var mu sync.Mutex
+			var events []string
+			record := func(event string) {
+				mu.Lock()
+				defer mu.Unlock()
+				events = append(events, event)
+			}
+			var attempts atomic.Int32
+			// flaky succeeds on its last retry, so its callback sees only the success
+			flaky := fh.WrapWithCallback(func() (int, error) {
+				if attempts.Add(1) < 3 {
+					return 0, errBoom
+				}
+				return 7, nil
+			}, func(res Result[any]) { record(fmt.Sprintf("flaky %v %v", res.Values, res.Err)) })
+			failing := fh.WrapWithCallback(func() error { return errBoom },
+				func(res Result[any]) { record(fmt.Sprintf("failing boom=%v", errors.Is(res.Err, errBoom))) })
+			// a panicking callback is logged and does not change the result
+			panicking := fh.WrapWithCallback(func() int { return 1 }, func(Result[any]) { panic("callback") })
+			handler := func(err error) error {
+				record("handler")
+				return nil
+			}
+			results, res := fh.Try(handler, flaky, failing, panicking)
+			if res.IsErr() {
+				t.Fatal(res.Err)
+			}
+			if len(results) != 2 {
+				t.Fatalf("got %v, want the values of flaky and panicking", results)
+			}
+			// under both modes a function's callback runs before the handler sees its failure
+			want := "[failing boom=true handler]"
+			var failingEvents []string
+			for _, event := range events {
+				if event != "flaky [7] <nil>" {
+					failingEvents = append(failingEvents, event)
+				}
+			}
+			if len(events) != 3 || fmt.Sprint(failingEvents) != want {
+				t.Fatalf("got events %q, want flaky's success once and %s", events, want)
+			}
+		})
+	}
+}
+
+func TestWrapWithCallbackNotTimed(t *testing.T) {
+	fh := NewHandler(WithTimeout(20 * time.Millisecond))
+	slowCallback := fh.WrapWithCallback(func() int { return 1 }, func(Result[any]) { time.Sleep(60 * time.Millisecond) })
+	if res := slowCallback(); res.IsErr() {
+		t.Fatalf("callback time counted against the timeout: %v", res.Err)
+	}
+	if _, res := fh.Try(func(err error) error { return err }, slowCallback); res.IsErr() {
+		t.Fatalf("callback time counted against the timeout: %v", res.Err)
+	}
+}
+
+func TestFlattenSlices(t *testing.T) {
+	tests := []struct {
+		name    string
+		flatten bool
+		fn      interface{}
+		want    []any
+	}{
+		{"slice", true, func() []int { return []int{1, 2} }, []any{1, 2}},
+		{"array", true, func() [2]string { return [2]string{"a", "b"} }, []any{"a", "b"}},
+		{"empty slice", true, func() []int { return []int{} }, []any{}},
+		{"nil slice", true, func() []int { return nil }, []any{}},
+		{"each slice in place", true, func() ([]int, string, []int, error) { return []int{1}, "s", []int{2, 3}, nil }, []any{1, "s", 2, 3}},
+		{"map and string kept", true, func() (map[string]int, string) { return map[string]int{"a": 1}, "ab" }, []any{map[string]int{"a": 1}, "ab"}},
+		{"disabled", false, func() []int { return []int{1, 2} }, []any{[]int{1, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler()
+			fh.SetFlattenSlices(tt.flatten)
+			res := fh.WrapFunction(tt.fn)()
+			if res.IsErr() {
+				t.Fatal(res.Err)
+			}
+			if !reflect.DeepEqual(res.Values, tt.want) {
+				t.Fatalf("got %#v, want %#v", res.Values, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetrySkippedWithoutTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempt  time.Duration
+		deadline bool
+		estimate func(durations []time.Duration) time.Duration
+		wantRuns int32
+		wantSkip bool
+	}{
+		{"skip", 40 * time.Minute, true, nil, 1, true},
+		{"proceed", 10 * time.Minute, true, nil, 3, false},
+		{"custom estimate", 40 * time.Minute, true, func([]time.Duration) time.Duration { return time.Minute }, 2, true},
+		{"no deadline", 40 * time.Minute, false, nil, 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Now()}
+			fh := NewHandler(WithRetries(2), WithBackoff(ConstantBackoff(0)))
+			fh.SetClock(clock)
+			fh.SetAttemptEstimate(tt.estimate)
+			ctx := context.Background()
+			if tt.deadline {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithDeadline(ctx, clock.Now().Add(time.Hour))
+				defer cancel()
+			}
+			var runs atomic.Int32
+			res := fh.RunWithRetry(ctx, fh.WrapFunction(func() error {
+				runs.Add(1)
+				clock.advance(tt.attempt)
+				return errBoom
+			}))
+			if runs.Load() != tt.wantRuns {
+				t.Fatalf("ran %d times, want %d", runs.Load(), tt.wantRuns)
+			}
+			if !errors.Is(res.Err, errBoom) {
+				t.Fatalf("got %v, want the failure wrapped", res.Err)
+			}
+			skipped := errors.Is(res.Err, context.DeadlineExceeded) && strings.Contains(res.Err.Error(), "retry skipped")
+			if skipped != tt.wantSkip || errors.Is(res.Err, ErrRetryExhausted) == tt.wantSkip {
+				t.Fatalf("got %v, want skipped %v", res.Err, tt.wantSkip)
+			}
+		})
+	}
+}
+
+func TestRetryForever(t *testing.T) {
+	pass := func(err error) error { return err }
+	t.Run("refused without a bound", func(t *testing.T) {
+		fh := NewHandler(WithRetries(RetryForever))
+		var runs atomic.Int32
+		fn := fh.WrapFunction(func() { runs.Add(1) })
+		if _, res := fh.Try(pass, fn); !errors.Is(res.Err, ErrUnboundedRetries) {
+			t.Fatalf("Try got %v, want %v", res.Err, ErrUnboundedRetries)
+		}
+		if res := fh.RunWithRetry(context.Background(), fn); !errors.Is(res.Err, ErrUnboundedRetries) {
+			t.Fatalf("RunWithRetry got %v, want %v", res.Err, ErrUnboundedRetries)
+		}
+		if runs.Load() != 0 {
+			t.Fatalf("ran %d times", runs.Load())
+		}
+	})
+	t.Run("until success", func(t *testing.T) {
+		clock := &fakeClock{now: time.Now()}
+		fh := NewHandler(WithRetries(RetryForever), WithBackoff(ConstantBackoff(time.Second)))
+		fh.SetClock(clock)
+		var retries []int
+		fh.OnRetry(func(e HookEvent) { retries = append(retries, e.Attempt) })
+		var runs atomic.Int32
+		fn := fh.WrapFunction(func() (int32, error) {
+			if n := runs.Add(1); n <= 60 {
+				return 0, errBoom
+			}
+			return runs.Load(), nil
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		res := fh.RunWithRetry(ctx, fn)
+		if res.IsErr() || res.Values[0] != int32(61) {
+			t.Fatalf("got %v, want success on attempt 61", res)
+		}
+		if len(retries) != 60 || retries[0] != 2 || retries[59] != 61 {
+			t.Fatalf("retry hooks saw attempts %v, want 2 to 61", retries)
+		}
+		if waits := clock.waited(); len(waits) != 60 || waits[59] != time.Second {
+			t.Fatalf("waited %d times, want 60 backoffs of a second", len(waits))
+		}
+	})
+	t.Run("until the retry predicate refuses", func(t *testing.T) {
+		fh := NewHandler(WithRetries(RetryForever), WithBackoff(ConstantBackoff(0)), WithTimeout(time.Minute))
+		var runs atomic.Int32
+		fh.SetRetryIf(func(error) bool { return runs.Load() < 30 })
+		fn := fh.WrapFunction(func() error {
+			runs.Add(1)
+			return errBoom
+		})
+		if _, res := fh.Try(pass, fn); !errors.Is(res.Err, errBoom) || runs.Load() != 30 {
+			t.Fatalf("got %v after %d runs, want the failure after 30", res.Err, runs.Load())
+		}
+	})
+	t.Run("until the retry budget is used up", func(t *testing.T) {
+		clock := &fakeClock{now: time.Now()}
+		fh := NewHandler(WithRetries(RetryForever), WithBackoff(ConstantBackoff(0)))
+		fh.SetClock(clock)
+		fh.SetMaxRetryDuration(time.Minute)
+		var runs atomic.Int32
+		fn := fh.WrapFunction(func() error {
+			runs.Add(1)
+			clock.advance(time.Second)
+			return errBoom
+		})
+		if _, res := fh.Try(pass, fn); !errors.Is(res.Err, ErrRetryExhausted) || runs.Load() != 61 {
+			t.Fatalf("got %v after %d runs, want the budget used up after 61", res.Err, runs.Load())
+		}
+	})
+}
+
+func TestHandlerTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		ignore  bool
+		hang    bool
+		want    error
+	}{
+		{"handler in time", 50 * time.Millisecond, false, false, errBoom},
+		{"hung handler times out", 20 * time.Millisecond, false, true, ErrHandlerTimeout},
+		{"hung handler ignored", 20 * time.Millisecond, true, true, nil},
+		{"no timeout", 0, false, false, errBoom},
+	}
+	for _, mode := range []ExecutionMode{ModeSequential, ModeParallel} {
+		for _, tt := range tests {
+			t.Run(mode.String()+"/"+tt.name, func(t *testing.T) {
+				before := runtime.NumGoroutine()
+				fh := NewHandler(WithMode(mode), WithLogger(&recordingLogger{}))
+				fh.SetHandlerTimeout(tt.timeout)
+				fh.SetIgnoreHandlerTimeout(tt.ignore)
+				release := make(chan struct{})
+				handler := func(err error) error {
+					if tt.hang {
+						<-release
+					}
+					return err
+				}
+				start := time.Now()
+				_, res := fh.Try(handler, fh.WrapFunction(func() error { return errBoom }))
+				took := time.Since(start)
+				close(release)
+				if !errors.Is(res.Err, tt.want) || (tt.want == ErrHandlerTimeout && !errors.Is(res.Err, errBoom)) {
+					t.Fatalf("Try = %v, want %v", res.Err, tt.want)
+				}
+				if tt.hang && took > time.Second {
+					t.Fatalf("Try waited %s for the hung handler", took)
+				}
+				// the handler left running after its timeout finishes without blocking
+				requireGoroutines(t, before)
+			})
+		}
+	}
+}
+
+func TestHandlerRetries(t *testing.T) {
+	errWrite := errors.New("write failed")
+	tests := []struct {
+		name      string
+		retries   int
+		failures  int // handler calls failing before one succeeds
+		wantCalls int
+		want      error
+	}{
+		{"default calls once", 0, 1, 1, errWrite},
+		{"succeeds on a retry", 2, 2, 3, nil},
+		{"retries used up", 1, 3, 2, errWrite},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Now()}
+			fh := NewHandler(WithLogger(&recordingLogger{}))
+			fh.SetClock(clock)
+			fh.SetHandlerRetries(tt.retries, 10*time.Millisecond)
+			calls := 0
+			handler := func(err error) error {
+				if calls++; calls <= tt.failures {
+					return errWrite
+				}
+				return nil
+			}
+			_, res := fh.Try(handler, fh.WrapFunction(func() error { return errBoom }))
+			if !errors.Is(res.Err, tt.want) {
+				t.Fatalf("Try = %v, want %v", res.Err, tt.want)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("called the handler %d times, want %d", calls, tt.wantCalls)
+			}
+			if waited := clock.waited(); len(waited) != tt.wantCalls-1 {
+				t.Fatalf("waited %v between handler calls, want %d waits", waited, tt.wantCalls-1)
+			}
+		})
+	}
+}
+
+func TestSlowThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		attempts  []time.Duration // how long every attempt takes; all but the last fail
+		want      []time.Duration
+	}{
+		{"fast", time.Second, []time.Duration{10 * time.Millisecond}, nil},
+		{"slow success", time.Second, []time.Duration{2 * time.Second}, []time.Duration{2 * time.Second}},
+		{"every slow attempt, failures included", time.Second, []time.Duration{2 * time.Second, 10 * time.Millisecond, 3 * time.Second}, []time.Duration{2 * time.Second, 3 * time.Second}},
+		{"at the threshold", time.Second, []time.Duration{time.Second}, nil},
+		{"zero disables", 0, []time.Duration{2 * time.Second}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Now()}
+			fh := NewHandler(WithRetries(len(tt.attempts)-1), WithLogger(&recordingLogger{}))
+			fh.SetClock(clock)
+			var got []time.Duration
+			fh.SetSlowThreshold(tt.threshold, func(name string, took time.Duration) {
+				if name != "fetch" {
+					t.Errorf("reported %q, want fetch", name)
+				}
+				got = append(got, took)
+			})
+			attempt := 0
+			fn := fh.WrapNamed("fetch", func() error {
+				clock.advance(tt.attempts[attempt])
+				if attempt++; attempt < len(tt.attempts) {
+					return errBoom
+				}
+				return nil
+			})
+			if _, res := fh.Try(func(err error) error { return err }, fn); res.IsErr() {
+				t.Fatalf("Try = %v", res.Err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("reported %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlowThresholdOnTimeout(t *testing.T) {
+	fh := NewHandler(WithTimeout(30*time.Millisecond), WithLogger(&recordingLogger{}))
+	var mu sync.Mutex
+	var got []time.Duration
+	fh.SetSlowThreshold(10*time.Millisecond, func(name string, took time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, took)
+	})
+	release := make(chan struct{})
+	defer close(release)
+	_, res := fh.Try(func(err error) error { return err }, fh.WrapFunction(func() { <-release }))
+	if !errors.Is(res.Err, ErrTimeout) {
+		t.Fatalf("Try = %v, want %v", res.Err, ErrTimeout)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 1 || got[0] < 30*time.Millisecond || got[0] > time.Second {
+		t.Fatalf("reported %v, want the time until the timeout", got)
+	}
+}
+
+func TestRecoverHandler(t *testing.T) {
+	errPage := errors.New("paged")
+	tests := []struct {
+		name         string
+		recover      func(recovered any, stack []byte, funcName string) error
+		want         error
+		wantHandled  bool // the error handler got the failure
+		wantRecovers int
+	}{
+		{"no recover handler", nil, nil, true, 0},
+		{"recovered", func(any, []byte, string) error { return nil }, nil, false, 1},
+		{"recover handler aborts", func(any, []byte, string) error { return errPage }, errPage, false, 1},
+	}
+	for _, mode := range []ExecutionMode{ModeSequential, ModeParallel} {
+		for _, tt := range tests {
+			t.Run(mode.String()+"/"+tt.name, func(t *testing.T) {
+				fh := NewHandler(WithMode(mode), WithLogger(&recordingLogger{}))
+				var mu sync.Mutex
+				var recovers []string
+				if tt.recover != nil {
+					fh.SetRecoverHandler(func(recovered any, stack []byte, funcName string) error {
+						mu.Lock()
+						defer mu.Unlock()
+						if recovered != "kaboom" || len(stack) == 0 {
+							t.Errorf("recovered %v with %d bytes of stack", recovered, len(stack))
+						}
+						recovers = append(recovers, funcName)
+						return tt.recover(recovered, stack, funcName)
+					})
+				}
+				var handled []error
+				handler := func(err error) error {
+					mu.Lock()
+					defer mu.Unlock()
+					handled = append(handled, err)
+					return nil
+				}
+				_, res := fh.Try(handler,
+					fh.WrapFunction(func() { panic("kaboom") }),
+					fh.WrapFunction(func() error { return errBoom }),
+				)
+				if !errors.Is(res.Err, tt.want) {
+					t.Fatalf("Try = %v, want %v", res.Err, tt.want)
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if len(recovers) != tt.wantRecovers || (tt.wantRecovers > 0 && !strings.Contains(recovers[0], "TestRecoverHandler")) {
+					t.Fatalf("recover handler got %v, want the panicking function %d times", recovers, tt.wantRecovers)
+				}
+				var panicErr *PanicError
+				sawPanic, sawBoom := false, false
+				for _, err := range handled {
+					sawPanic = sawPanic || errors.As(err, &panicErr)
+					sawBoom = sawBoom || errors.Is(err, errBoom)
+				}
+				if sawPanic != tt.wantHandled {
+					t.Fatalf("error handler got the panic: %v, want %v", sawPanic, tt.wantHandled)
+				}
+				if tt.want == nil && !sawBoom {
+					t.Fatalf("error handler got %v, want the ordinary failure too", handled)
+				}
+			})
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	errPage := errors.New("paged")
+	flaky := func() func() (int, error) {
+		calls := 0
+		return func() (int, error) {
+			if calls++; calls < 3 {
+				return 0, errBoom
+			}
+			return calls, nil
+		}
+	}
+	tests := []struct {
+		name      string
+		opts      []Option
+		recover   func(recovered any, stack []byte, funcName string) error
+		function  interface{}
+		args      []interface{}
+		want      []any
+		wantErr   error
+		wantPanic bool
+	}{
+		{"value", nil, nil, func(a, b int) int { return a + b }, []interface{}{1, 2}, []any{3}, nil, false},
+		{"retried", []Option{WithRetries(2), WithBackoff(ConstantBackoff(0))}, nil, flaky(), nil, []any{3}, nil, false},
+		{"retries used up", []Option{WithRetries(1), WithBackoff(ConstantBackoff(0))}, nil, flaky(), nil, nil, ErrRetryExhausted, false},
+		{"timed out", []Option{WithTimeout(10 * time.Millisecond)}, nil, func() { time.Sleep(100 * time.Millisecond) }, nil, nil, ErrTimeout, false},
+		{"argument mismatch", nil, nil, func(a int) int { return a }, []interface{}{"one"}, nil, ErrArgTypeMismatch, false},
+		{"panic", nil, nil, func() { panic("kaboom") }, nil, nil, nil, true},
+		{"panic recovered", nil, func(any, []byte, string) error { return nil }, func() { panic("kaboom") }, nil, nil, nil, true},
+		{"recover handler aborts", nil, func(any, []byte, string) error { return errPage }, func() { panic("kaboom") }, nil, nil, errPage, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			fh := NewHandler(append(tt.opts, WithLogger(logger))...)
+			if tt.recover != nil {
+				fh.SetRecoverHandler(tt.recover)
+			}
+			res := fh.Apply(tt.function, tt.args...)
+			var panicErr *PanicError
+			switch {
+			case tt.wantPanic && !errors.As(res.Err, &panicErr):
+				t.Fatalf("Apply = %v, want a PanicError", res.Err)
+			case !tt.wantPanic && !errors.Is(res.Err, tt.wantErr):
+				t.Fatalf("Apply = %v, want %v", res.Err, tt.wantErr)
+			}
+			if res.IsOk() && !reflect.DeepEqual(res.Values, tt.want) {
+				t.Fatalf("Apply = %v, want %v", res.Values, tt.want)
+			}
+			if res.IsErr() && len(logger.logged()) == 0 {
+				t.Fatal("Apply did not log the failure")
+			}
+		})
+	}
+}
+
+func TestWrapChecked(t *testing.T) {
+	add := func(a, b int) int { return a + b }
+	tests := []struct {
+		name      string
+		transform bool
+		function  interface{}
+		args      []interface{}
+		want      error
+	}{
+		{"matching", false, add, []interface{}{1, 2}, nil},
+		{"not a function", false, 42, nil, ErrNotAFunction},
+		{"nil function", false, nil, nil, ErrNotAFunction},
+		{"too few arguments", false, add, []interface{}{1}, ErrArgCountMismatch},
+		{"too many arguments", false, add, []interface{}{1, 2, 3}, ErrArgCountMismatch},
+		{"wrong type", false, add, []interface{}{1, "two"}, ErrArgTypeMismatch},
+		{"nil for a value", false, add, []interface{}{1, nil}, ErrArgTypeMismatch},
+		{"nil for a pointer", false, func(p *int) {}, []interface{}{nil}, nil},
+		{"injected context", false, func(ctx context.Context, a int) int { return a }, []interface{}{1}, nil},
+		{"options are not arguments", false, add, []interface{}{1, 2, WithRetries(1)}, nil},
+		{"arguments left to the transformers", true, add, []interface{}{"1", "2"}, nil},
+		{"function checked despite transformers", true, "add", nil, ErrNotAFunction},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler(WithLogger(&recordingLogger{}))
+			if tt.transform {
+				fh.UseArgTransformer(func(funcName string, args []interface{}) ([]interface{}, error) {
+					for i, arg := range args {
+						var n int
+						fmt.Sscan(arg.(string), &n)
+						args[i] = n
+					}
+					return args, nil
+				})
+			}
+			fn, err := fh.WrapChecked(tt.function, tt.args...)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("WrapChecked = %v, want %v", err, tt.want)
+			}
+			if (fn == nil) != (tt.want != nil) {
+				t.Fatalf("WrapChecked returned a function: %v, want one only without error", fn != nil)
+			}
+			if fn != nil {
+				if _, res := fh.Try(func(err error) error { return err }, fn); res.IsErr() {
+					t.Fatalf("running the checked function = %v", res.Err)
+				}
+			}
+		})
+	}
+}
+
+func TestNilAndInterfaceArgs(t *testing.T) {
+	type stringer interface{ String() string }
+	tests := []struct {
+		name     string
+		function interface{}
+		args     []interface{}
+		want     string
+		wantErr  error
+	}{
+		{"nil pointer", func(p *int) bool { return p == nil }, []interface{}{nil}, "[true]", nil},
+		{"nil map", func(m map[string]int) int { return len(m) }, []interface{}{nil}, "[0]", nil},
+		{"nil slice", func(s []int) bool { return s == nil }, []interface{}{nil}, "[true]", nil},
+		{"nil error", func(err error) bool { return err == nil }, []interface{}{nil}, "[true]", nil},
+		{"nil any", func(v any) bool { return v == nil }, []interface{}{nil}, "[true]", nil},
+		{"nil variadic element", func(ps ...*int) bool { return ps[0] == nil }, []interface{}{nil}, "[true]", nil},
+		{"value for an interface", func(s fmt.Stringer) string { return s.String() }, []interface{}{time.Second}, "[1s]", nil},
+		{"error for an error", func(err error) string { return err.Error() }, []interface{}{errBoom}, "[boom]", nil},
+		{"nil for an int", func(n int) int { return n }, []interface{}{nil}, "", ErrArgTypeMismatch},
+		{"value not implementing the interface", func(s stringer) string { return s.String() }, []interface{}{42}, "", ErrArgTypeMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler(WithLogger(&recordingLogger{}))
+			inputs, err := fh.ConvertArgsFor(tt.function, tt.args...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ConvertArgsFor = %v, want %v", err, tt.wantErr)
+			}
+			for i, in := range inputs {
+				if !in.IsValid() {
+					t.Fatalf("ConvertArgsFor made an invalid value for argument %d", i)
+				}
+			}
+			results, res := fh.Try(func(err error) error { return err }, fh.WrapFunction(tt.function, tt.args...))
+			if !errors.Is(res.Err, tt.wantErr) {
+				t.Fatalf("Try = %v, want %v", res.Err, tt.wantErr)
+			}
+			if res.IsOk() && fmt.Sprint(results) != tt.want {
+				t.Fatalf("Try = %v, want %s", results, tt.want)
+			}
+		})
+	}
+	// ConvertArgs knows no parameter types, so nil becomes a valid nil interface{}
+	if in := NewHandler().ConvertArgs(nil, 1); !in[0].IsValid() || !in[0].IsNil() || in[1].Int() != 1 {
+		t.Fatalf("ConvertArgs = %v", in)
+	}
+}
+
+// slowAttempts function to return a function whose first slow attempts run until their context ends
+func slowAttempts(slow int32, attempts *atomic.Int32) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if attempts.Add(1) > slow {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	}
+}
+
+func TestRetryOnTimeout(t *testing.T) {
+	tests := []struct {
+		name           string
+		retryOnTimeout bool
+		attemptTimeout time.Duration
+		timeout        time.Duration
+		slow           int32
+		wantAttempts   int32
+		want           error
+		wantMessage    string
+	}{
+		{"timeout ends the function", false, 0, 20 * time.Millisecond, 1, 1, ErrTimeout, ""},
+		{"timed out attempt retried", true, 0, 20 * time.Millisecond, 1, 2, nil, ""},
+		{"every attempt timed out", true, 0, 20 * time.Millisecond, 3, 3, ErrRetryExhausted, "(3 timed out)"},
+		{"attempt timeout takes precedence", true, 20 * time.Millisecond, 10 * time.Second, 1, 2, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler(WithRetries(2), WithBackoff(ConstantBackoff(0)), WithLogger(&recordingLogger{}))
+			fh.SetTimeout(tt.timeout)
+			fh.SetRetryOnTimeout(tt.retryOnTimeout)
+			fh.SetAttemptTimeout(tt.attemptTimeout)
+			var attempts atomic.Int32
+			start := time.Now()
+			_, res := fh.Try(func(err error) error { return err }, fh.WrapFunction(slowAttempts(tt.slow, &attempts)))
+			if !errors.Is(res.Err, tt.want) {
+				t.Fatalf("Try = %v, want %v", res.Err, tt.want)
+			}
+			if tt.want == ErrRetryExhausted && !errors.Is(res.Err, ErrTimeout) {
+				t.Fatalf("Try = %v, want the attempts' %v", res.Err, ErrTimeout)
+			}
+			if tt.wantMessage != "" && !strings.Contains(res.Err.Error(), tt.wantMessage) {
+				t.Fatalf("Try = %q, want it to say %q", res.Err, tt.wantMessage)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Fatalf("made %d attempts, want %d", got, tt.wantAttempts)
+			}
+			if took := time.Since(start); took > 500*time.Millisecond {
+				t.Fatalf("Try took %s, want the timed out attempts cut short", took)
+			}
+		})
+	}
+}
+
+func TestAttemptAndOverallTimeouts(t *testing.T) {
+	tests := []struct {
+		name           string
+		retryOnTimeout bool // set before the timeouts
+		attemptTimeout time.Duration
+		overallTimeout time.Duration
+		slow           int32
+		wantAttempts   func(n int32) bool
+		want           []error // any of them, none for success
+	}{
+		{"attempt timeout retried", false, 20 * time.Millisecond, 0, 1, func(n int32) bool { return n == 2 }, nil},
+		{"overall timeout not retried", false, 0, 30 * time.Millisecond, 1, func(n int32) bool { return n == 1 }, []error{ErrTimeout}},
+		{"overall timeout clears retry on timeout", true, 0, 30 * time.Millisecond, 1, func(n int32) bool { return n == 1 }, []error{ErrTimeout}},
+		{"overall budget ends the attempts", false, 20 * time.Millisecond, 70 * time.Millisecond, 100, func(n int32) bool { return n >= 2 && n <= 4 },
+			// the budget either cuts an attempt short or leaves too little for the next one
+			[]error{ErrTimeout, context.DeadlineExceeded}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler(WithRetries(10), WithBackoff(ConstantBackoff(0)), WithLogger(&recordingLogger{}))
+			fh.SetRetryOnTimeout(tt.retryOnTimeout)
+			fh.SetAttemptTimeout(tt.attemptTimeout)
+			fh.SetOverallTimeout(tt.overallTimeout)
+			var attempts atomic.Int32
+			start := time.Now()
+			_, res := fh.Try(func(err error) error { return err }, fh.WrapFunction(slowAttempts(tt.slow, &attempts)))
+			if matched := slices.ContainsFunc(tt.want, func(want error) bool { return errors.Is(res.Err, want) }); matched != res.IsErr() {
+				t.Fatalf("Try = %v, want one of %v", res.Err, tt.want)
+			}
+			if got := attempts.Load(); !tt.wantAttempts(got) {
+				t.Fatalf("made %d attempts", got)
+			}
+			if took := time.Since(start); took > 500*time.Millisecond {
+				t.Fatalf("Try took %s, want the timeouts to end it", took)
+			}
+		})
+	}
+}
+
+func TestSetFailFast(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     ExecutionMode
+		failFast []bool // calls of SetFailFast, in order
+		wantMode ExecutionMode
+		wantWait bool // Try waits for the slow function
+	}{
+		{"on", ModeParallel, []bool{true}, ModeFailFast, false},
+		{"off again", ModeParallel, []bool{true, false}, ModeParallel, true},
+		{"off keeps sequential", ModeSequential, []bool{false}, ModeSequential, true},
+		{"off keeps collecting errors", ModeCollectErrors, []bool{false}, ModeCollectErrors, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler(WithMode(tt.mode), WithLogger(&recordingLogger{}))
+			for _, failFast := range tt.failFast {
+				fh.SetFailFast(failFast)
+			}
+			if mode := fh.settings().mode; mode != tt.wantMode {
+				t.Fatalf("mode = %s, want %s", mode, tt.wantMode)
+			}
+			started, cancelled := make(chan struct{}), make(chan struct{})
+			slow := fh.WrapFunction(func(ctx context.Context) {
+				close(started)
+				select {
+				case <-ctx.Done():
+					close(cancelled)
+				case <-time.After(100 * time.Millisecond):
+				}
+			})
+			start := time.Now()
+			failing := fh.WrapFunction(func() error {
+				<-started
+				return errBoom
+			})
+			_, res := fh.Try(func(err error) error { return err }, slow, failing)
+			took := time.Since(start)
+			if !errors.Is(res.Err, errBoom) {
+				t.Fatalf("Try = %v, want %v", res.Err, errBoom)
+			}
+			if waited := took >= 100*time.Millisecond; waited != tt.wantWait {
+				t.Fatalf("Try took %s; waiting for the slow function: %v, want %v", took, waited, tt.wantWait)
+			}
+			if !tt.wantWait {
+				select {
+				case <-cancelled:
+				case <-time.After(time.Second):
+					t.Fatal("the slow function was not cancelled")
+				}
+			}
+		})
+	}
+}
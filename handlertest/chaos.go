@@ -0,0 +1,136 @@
+package handlertest
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	handler "github.com/Spongebob959/handler"
+)
+
+// ErrChaos is the error injected by ChaosWrap when ChaosConfig.Err is not set
+var ErrChaos = errors.New("chaos: injected error")
+
+// Fault type to name the kinds of misbehavior ChaosWrap can inject
+type Fault int
+
+const (
+	// FaultLatency delays the call by ChaosConfig.Latency before it runs
+	FaultLatency Fault = iota
+	// FaultError returns ChaosConfig.Err instead of calling the function
+	FaultError
+	// FaultPanic panics instead of calling the function, which the handler turns into a PanicError
+	FaultPanic
+)
+
+func (f Fault) String() string {
+	switch f {
+	case FaultLatency:
+		return "latency"
+	case FaultError:
+		return "error"
+	case FaultPanic:
+		return "panic"
+	}
+	return fmt.Sprintf("Fault(%d)", int(f))
+}
+
+// ChaosConfig struct to set how often each fault is injected. Rates are probabilities between 0 and 1,
+// drawn from a source seeded with Seed so a test sees the same faults on every run.
+type ChaosConfig struct {
+	Seed        int64
+	ErrorRate   float64
+	PanicRate   float64
+	LatencyRate float64
+	Latency     time.Duration
+	Err         error
+}
+
+// Injection struct to record one fault injected into the call with the given 1-based number
+type Injection struct {
+	Call  int
+	Fault Fault
+}
+
+// Chaos struct to record the faults injected by a function created with ChaosWrap
+type Chaos struct {
+	mu         sync.Mutex
+	cfg        ChaosConfig
+	rand       *rand.Rand
+	calls      int
+	injections []Injection
+}
+
+// ChaosWrap function to wrap fn so that it misbehaves at the configured rates. Latency is drawn independently,
+// then a panic or else an error replaces the call. The wrapped function goes through the handler's retries
+// and timeouts unchanged, so an injected latency beyond the timeout surfaces as the handler's ErrTimeout.
+func ChaosWrap(fn func() handler.Result[any], cfg ChaosConfig) (func() handler.Result[any], *Chaos) {
+	if cfg.Err == nil {
+		cfg.Err = ErrChaos
+	}
+	c := &Chaos{cfg: cfg, rand: rand.New(rand.NewSource(cfg.Seed))}
+	return func() handler.Result[any] {
+		call, faults := c.draw()
+		for _, fault := range faults {
+			switch fault {
+			case FaultLatency:
+				time.Sleep(c.cfg.Latency)
+			case FaultPanic:
+				panic(fmt.Sprintf("chaos: injected panic in call %d", call))
+			case FaultError:
+				return handler.Err[any](c.cfg.Err)
+			}
+		}
+		return fn()
+	}, c
+}
+
+// draw method to pick the faults for the next call and record them
+func (c *Chaos) draw() (int, []Fault) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	var faults []Fault
+	if c.rand.Float64() < c.cfg.LatencyRate {
+		faults = append(faults, FaultLatency)
+	}
+	switch roll := c.rand.Float64(); {
+	case roll < c.cfg.PanicRate:
+		faults = append(faults, FaultPanic)
+	case roll < c.cfg.PanicRate+c.cfg.ErrorRate:
+		faults = append(faults, FaultError)
+	}
+	for _, fault := range faults {
+		c.injections = append(c.injections, Injection{Call: c.calls, Fault: fault})
+	}
+	return c.calls, faults
+}
+
+// Calls method to return how often the wrapped function was called
+func (c *Chaos) Calls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+// Injections method to return every fault injected so far, in call order
+func (c *Chaos) Injections() []Injection {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Injection(nil), c.injections...)
+}
+
+// Count method to return how often the given fault was injected
+func (c *Chaos) Count(fault Fault) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, injection := range c.injections {
+		if injection.Fault == fault {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,78 @@
+package handlertest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	handler "github.com/Spongebob959/handler"
+)
+
+func TestChaosWrap(t *testing.T) {
+	ok := func() handler.Result[any] { return handler.Ok[any]("value") }
+	tests := []struct {
+		name     string
+		cfg      ChaosConfig
+		opts     []handler.Option
+		want     error
+		wantCall int
+		check    func(t *testing.T, c *Chaos)
+	}{
+		{"no faults", ChaosConfig{Seed: 1}, nil, nil, 1, func(t *testing.T, c *Chaos) {
+			if len(c.Injections()) != 0 {
+				t.Fatalf("injected %v, want nothing", c.Injections())
+			}
+		}},
+		{"every call fails until the retries are used up", ChaosConfig{Seed: 1, ErrorRate: 1}, []handler.Option{handler.WithRetries(2), handler.WithBackoff(handler.ConstantBackoff(0))}, ErrChaos, 3, func(t *testing.T, c *Chaos) {
+			if c.Count(FaultError) != 3 {
+				t.Fatalf("injected %d errors, want 3", c.Count(FaultError))
+			}
+		}},
+		{"custom error", ChaosConfig{Seed: 1, ErrorRate: 1, Err: errBoom}, nil, errBoom, 1, nil},
+		{"panic becomes a PanicError", ChaosConfig{Seed: 1, PanicRate: 1}, nil, nil, 1, func(t *testing.T, c *Chaos) {
+			if got := c.Injections(); !reflect.DeepEqual(got, []Injection{{Call: 1, Fault: FaultPanic}}) {
+				t.Fatalf("injected %v, want a panic in call 1", got)
+			}
+		}},
+		{"latency beyond the timeout", ChaosConfig{Seed: 1, LatencyRate: 1, Latency: 50 * time.Millisecond}, []handler.Option{handler.WithTimeout(5 * time.Millisecond)}, handler.ErrTimeout, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := handler.NewHandler(tt.opts...)
+			fn, chaos := ChaosWrap(ok, tt.cfg)
+			_, res := fh.Try(func(err error) error { return err }, fn)
+			var panicErr *handler.PanicError
+			switch {
+			case tt.cfg.PanicRate == 1 && !errors.As(res.Err, &panicErr):
+				t.Fatalf("Try = %v, want a PanicError", res.Err)
+			case tt.cfg.PanicRate != 1 && !errors.Is(res.Err, tt.want):
+				t.Fatalf("Try = %v, want %v", res.Err, tt.want)
+			}
+			if chaos.Calls() != tt.wantCall {
+				t.Fatalf("called %d times, want %d", chaos.Calls(), tt.wantCall)
+			}
+			if tt.check != nil {
+				tt.check(t, chaos)
+			}
+		})
+	}
+}
+
+func TestChaosWrapIsSeeded(t *testing.T) {
+	cfg := ChaosConfig{Seed: 42, ErrorRate: 0.3, PanicRate: 0.1, LatencyRate: 0.2}
+	run := func() []Injection {
+		fn, chaos := ChaosWrap(func() handler.Result[any] { return handler.Ok[any]() }, cfg)
+		for i := 0; i < 100; i++ {
+			func() {
+				defer func() { recover() }()
+				fn()
+			}()
+		}
+		return chaos.Injections()
+	}
+	first, second := run(), run()
+	if len(first) == 0 || !reflect.DeepEqual(first, second) {
+		t.Fatalf("injected %v, then %v; want the same faults for the same seed", first, second)
+	}
+}
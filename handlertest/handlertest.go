@@ -0,0 +1,71 @@
+// Package handlertest provides assertions for tests of code using the handler package.
+package handlertest
+
+import (
+	"errors"
+	"reflect"
+
+	handler "github.com/Spongebob959/handler"
+)
+
+// TB interface with the parts of testing.TB the assertions use, satisfied by *testing.T
+type TB interface {
+	Helper()
+	Fatalf(format string, args ...any)
+}
+
+// RequireOk function to fail the test when r holds an error
+func RequireOk[T any](t TB, r handler.Result[T]) {
+	t.Helper()
+	if r.IsErr() {
+		t.Fatalf("expected an Ok result, got error: %v", r.Err)
+	}
+}
+
+// RequireErrIs function to fail the test unless r holds an error matching target with errors.Is
+func RequireErrIs[T any](t TB, r handler.Result[T], target error) {
+	t.Helper()
+	if r.IsOk() {
+		t.Fatalf("expected an error matching %q, got Ok with values %v", target, r.Values)
+		return
+	}
+	if !errors.Is(r.Err, target) {
+		t.Fatalf("expected an error matching %q, got: %v", target, r.Err)
+	}
+}
+
+// RequireValues function to fail the test unless r is Ok and holds exactly the wanted values
+func RequireValues[T any](t TB, r handler.Result[T], want ...T) {
+	t.Helper()
+	if r.IsErr() {
+		t.Fatalf("expected values %v, got error: %v", want, r.Err)
+		return
+	}
+	requireEqual(t, r.Values, want)
+}
+
+// RequireTrySucceeded function to fail the test when Try reported a failure
+func RequireTrySucceeded(t TB, results []any, res handler.Result[any]) {
+	t.Helper()
+	if res.IsErr() {
+		t.Fatalf("expected Try to succeed, got error: %v (results so far: %v)", res.Err, results)
+		return
+	}
+	if results == nil {
+		t.Fatalf("expected Try to succeed, got nil results")
+	}
+}
+
+// requireEqual function to compare value slices, reporting the index of the first mismatch
+func requireEqual[T any](t TB, got, want []T) {
+	t.Helper()
+	for i := 0; i < len(got) && i < len(want); i++ {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Fatalf("values differ at index %d: got %#v, want %#v\n got:  %v\n want: %v", i, got[i], want[i], got, want)
+			return
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d values, want %d\n got:  %v\n want: %v", len(got), len(want), got, want)
+	}
+}
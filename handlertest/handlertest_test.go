@@ -0,0 +1,61 @@
+package handlertest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	handler "github.com/Spongebob959/handler"
+)
+
+// fakeTB struct to record the failure of an assertion instead of failing the test running it
+type fakeTB struct {
+	failed bool
+	msg    string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	if !f.failed {
+		f.failed, f.msg = true, fmt.Sprintf(format, args...)
+	}
+}
+
+var errBoom = errors.New("boom")
+
+func TestAssertions(t *testing.T) {
+	tests := []struct {
+		name     string
+		assert   func(t TB)
+		wantFail string // a part of the failure message, or "" when the assertion must pass
+	}{
+		{"RequireOk passes", func(t TB) { RequireOk(t, handler.Ok(1)) }, ""},
+		{"RequireOk fails", func(t TB) { RequireOk(t, handler.Err[int](errBoom)) }, "boom"},
+		{"RequireErrIs passes", func(t TB) { RequireErrIs(t, handler.Err[int](fmt.Errorf("wrapped: %w", errBoom)), errBoom) }, ""},
+		{"RequireErrIs fails on Ok", func(t TB) { RequireErrIs(t, handler.Ok(1), errBoom) }, "got Ok"},
+		{"RequireErrIs fails on another error", func(t TB) { RequireErrIs(t, handler.Err[int](errors.New("other")), errBoom) }, "other"},
+		{"RequireValues passes", func(t TB) { RequireValues(t, handler.Ok(1, 2), 1, 2) }, ""},
+		{"RequireValues fails on error", func(t TB) { RequireValues(t, handler.Err[int](errBoom), 1) }, "boom"},
+		{"RequireValues fails on a different value", func(t TB) { RequireValues(t, handler.Ok(1, 3), 1, 2) }, "index 1"},
+		{"RequireValues fails on a different count", func(t TB) { RequireValues(t, handler.Ok(1), 1, 2) }, "got 1 values, want 2"},
+		{"RequireTrySucceeded passes", func(t TB) { RequireTrySucceeded(t, []any{1}, handler.Ok[any](nil)) }, ""},
+		{"RequireTrySucceeded fails on error", func(t TB) { RequireTrySucceeded(t, nil, handler.Err[any](errBoom)) }, "boom"},
+		{"RequireTrySucceeded fails on nil results", func(t TB) { RequireTrySucceeded(t, nil, handler.Ok[any](nil)) }, "nil results"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeTB{}
+			tt.assert(fake)
+			switch {
+			case tt.wantFail == "" && fake.failed:
+				t.Fatalf("assertion failed: %s", fake.msg)
+			case tt.wantFail != "" && !fake.failed:
+				t.Fatal("assertion passed, want it to fail")
+			case !strings.Contains(fake.msg, tt.wantFail):
+				t.Fatalf("failure message %q does not mention %q", fake.msg, tt.wantFail)
+			}
+		})
+	}
+}
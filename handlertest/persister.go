@@ -0,0 +1,42 @@
+package handlertest
+
+import (
+	"sync"
+
+	handler "github.com/Spongebob959/handler"
+)
+
+// MemoryPersister struct to keep batch state in memory, recording every save so tests can inspect checkpoints
+type MemoryPersister struct {
+	mu     sync.Mutex
+	states map[string][]handler.PendingExecution
+	saves  map[string]int
+}
+
+// NewMemoryPersister function to create an empty in-memory state persister
+func NewMemoryPersister() *MemoryPersister {
+	return &MemoryPersister{states: map[string][]handler.PendingExecution{}, saves: map[string]int{}}
+}
+
+// SaveState method to store a copy of the pending executions of a batch
+func (m *MemoryPersister) SaveState(batchID string, pending []handler.PendingExecution) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[batchID] = append([]handler.PendingExecution(nil), pending...)
+	m.saves[batchID]++
+	return nil
+}
+
+// LoadState method to return the pending executions last stored for a batch
+func (m *MemoryPersister) LoadState(batchID string) ([]handler.PendingExecution, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]handler.PendingExecution(nil), m.states[batchID]...), nil
+}
+
+// Saves method to return how often the state of a batch was saved
+func (m *MemoryPersister) Saves(batchID string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.saves[batchID]
+}
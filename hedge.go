@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"time"
+)
+
+// SetHedge method to start a second, speculative call of a function when an attempt has not finished within
+// delay. The first call to succeed wins and the other one's context is cancelled; when both fail the attempt
+// fails with the first error. An attempt failing before delay is not hedged. Zero or less turns hedging off.
+// Only idempotent functions should be hedged, and functions should watch their context so losers stop early.
+func (fhi *FunctionHandlerImpl) SetHedge(delay time.Duration) {
+	fhi.configure(func(s *settings) { s.hedgeDelay = max(delay, 0) })
+}
+
+// hedgedAttempt method to make one attempt of fn, hedged after the hedge delay when one is set
+func (fhi *FunctionHandlerImpl) hedgedAttempt(fn func() Result[any], w *wrapped, exec *execution) Result[any] {
+	cfg := fhi.settings()
+	if cfg.hedgeDelay <= 0 {
+		return attempt(fn, w, exec)
+	}
+	ctx, cancel := context.WithCancel(exec.ctx)
+	defer cancel() // stops the losing call
+	results := make(chan Result[any], 2)
+	launch := func() {
+		call := *exec
+		call.ctx = ctx
+		go func() {
+			results <- attempt(fn, w, &call)
+		}()
+	}
+	launch()
+	running, failed := 1, 0
+	var first Result[any]
+	hedge := fhi.getClock().After(cfg.hedgeDelay)
+	for {
+		select {
+		case <-hedge:
+			hedge = nil
+			if ctx.Err() == nil {
+				launch()
+				running++
+			}
+		case res := <-results:
+			if res.IsOk() || hedge != nil {
+				return res
+			}
+			if failed++; failed == 1 {
+				first = res
+			}
+			if failed == running {
+				return first
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// HookEvent struct to describe the point in a function's run a lifecycle hook is called for
+type HookEvent struct {
+	Handler     string        // the name set with SetName, may be empty
+	Function    string        // the function's name, as reported to the metrics
+	ExecutionID string        // the ID of the function's run, the same for all its attempts; empty when IDs are off
+	BatchID     string        // the ID of the batch running the function; empty when IDs are off
+	Attempt     int           // the attempt the event is about, counting from 1
+	Duration    time.Duration // how long the attempt or run took, or the backoff before a retry
+	Err         error         // the failure, nil for OnStart and OnSuccess
+}
+
+// hookKind identifies a lifecycle point
+type hookKind int
+
+const (
+	hookStart hookKind = iota
+	hookSuccess
+	hookFailure
+	hookRetry
+	hookTimeout
+	hookKinds
+)
+
+// hookNames holds the name of every lifecycle point, for the log line of a panicking hook
+var hookNames = [hookKinds]string{"OnStart", "OnSuccess", "OnFailure", "OnRetry", "OnTimeout"}
+
+// OnStart method to call hook before every attempt of a function
+func (fhi *FunctionHandlerImpl) OnStart(hook func(e HookEvent)) {
+	fhi.configure(func(s *settings) { s.hooks[hookStart] = append(slices.Clip(s.hooks[hookStart]), hook) })
+}
+
+// OnSuccess method to call hook when a function run by a batch succeeded, with the attempts it took and the
+// duration of the whole run
+func (fhi *FunctionHandlerImpl) OnSuccess(hook func(e HookEvent)) {
+	fhi.configure(func(s *settings) { s.hooks[hookSuccess] = append(slices.Clip(s.hooks[hookSuccess]), hook) })
+}
+
+// OnFailure method to call hook when a function run by a batch failed after its retries, before the error
+// handler sees the failure
+func (fhi *FunctionHandlerImpl) OnFailure(hook func(e HookEvent)) {
+	fhi.configure(func(s *settings) { s.hooks[hookFailure] = append(slices.Clip(s.hooks[hookFailure]), hook) })
+}
+
+// OnRetry method to call hook before waiting for a retry, with the attempt about to be made, the failure
+// that caused it and the backoff
+func (fhi *FunctionHandlerImpl) OnRetry(hook func(e HookEvent)) {
+	fhi.configure(func(s *settings) { s.hooks[hookRetry] = append(slices.Clip(s.hooks[hookRetry]), hook) })
+}
+
+// OnTimeout method to call hook when the timeout ran out, for the attempt with SetRetryOnTimeout and for the
+// whole run otherwise
+func (fhi *FunctionHandlerImpl) OnTimeout(hook func(e HookEvent)) {
+	fhi.configure(func(s *settings) { s.hooks[hookTimeout] = append(slices.Clip(s.hooks[hookTimeout]), hook) })
+}
+
+// fire method to call the hooks registered for kind on the handler and its ancestors, logging a panic in one
+func (fhi *FunctionHandlerImpl) fire(ctx context.Context, kind hookKind, e HookEvent) {
+	e.Handler = fhi.settings().name
+	ids, _ := ExecutionIDsFrom(ctx)
+	e.ExecutionID, e.BatchID = ids.Execution, ids.Batch
+	for h := fhi; h != nil; h = h.parent {
+		for _, hook := range h.settings().hooks[kind] {
+			fhi.callHook(kind, hook, e)
+		}
+	}
+}
+
+// callHook method to call one hook, logging a panic in it
+func (fhi *FunctionHandlerImpl) callHook(kind hookKind, hook func(e HookEvent), e HookEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			fhi.LogError(fhi.errorf("%s hook for %s panicked: %v", hookNames[kind], e.Function, r))
+		}
+	}()
+	hook(e)
+}
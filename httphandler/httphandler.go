@@ -0,0 +1,149 @@
+// Package httphandler wraps outbound HTTP requests as handler functions, so they get the handler's retries and timeouts.
+package httphandler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	handler "github.com/Spongebob959/handler"
+)
+
+// StatusError struct to report a response whose status is not a success, with its body
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("http status %s", e.Status)
+}
+
+// Option type to configure how requests are wrapped
+type Option func(*Runner)
+
+// RetryOn function to set which unsuccessful statuses are retried; the others fail the function at once.
+// By default 5xx and 429 are retried.
+func RetryOn(retryable func(statusCode int) bool) Option {
+	return func(r *Runner) {
+		r.retryable = retryable
+	}
+}
+
+// ReturnResponse function to make the functions return the *http.Response, with its body read into memory,
+// instead of the body bytes
+func ReturnResponse() Option {
+	return func(r *Runner) {
+		r.returnResponse = true
+	}
+}
+
+// Runner struct to wrap requests sent with a client as functions of a handler
+type Runner struct {
+	fh             handler.FunctionHandler
+	client         *http.Client
+	retryable      func(statusCode int) bool
+	returnResponse bool
+}
+
+// New function to create a runner whose requests are sent with client, or http.DefaultClient when it is nil,
+// and wrapped by fh
+func New(fh handler.FunctionHandler, client *http.Client, opts ...Option) *Runner {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	r := &Runner{fh: fh, client: client, retryable: defaultRetryable}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// defaultRetryable function to retry server errors and rate limiting
+func defaultRetryable(statusCode int) bool {
+	return statusCode >= 500 || statusCode == http.StatusTooManyRequests
+}
+
+// WrapRequest method to create a function that sends a fresh clone of req on every attempt, cancelled when the
+// handler's timeout fires or the batch is cancelled. Its value is the response body, or the response with
+// ReturnResponse. An unsuccessful status is a *StatusError: a retryable one honours the Retry-After header,
+// the others wrap handler.ErrPermanent and are not retried. A body without GetBody is read once up front.
+func (r *Runner) WrapRequest(req *http.Request) func() handler.Result[any] {
+	name := req.Method + " " + req.URL.Redacted()
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return r.fh.WrapNamed(name, func() error { return fmt.Errorf("read request body: %w", err) })
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+	return r.fh.WrapNamed(name, func(done <-chan struct{}) (any, error) {
+		ctx, cancel := context.WithCancel(req.Context())
+		defer cancel()
+		go func() {
+			select {
+			case <-done:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		return r.send(ctx, req)
+	})
+}
+
+// send method to send a clone of req with ctx once and classify the response
+func (r *Runner) send(ctx context.Context, req *http.Request) (any, error) {
+	attempt := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("%w: get request body: %w", handler.ErrPermanent, err)
+		}
+		attempt.Body = body
+	}
+	resp, err := r.client.Do(attempt)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		statusErr := &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: body}
+		if !r.retryable(resp.StatusCode) {
+			return nil, fmt.Errorf("%w: %w", handler.ErrPermanent, statusErr)
+		}
+		return nil, handler.RetryAfter(statusErr, retryAfter(resp.Header.Get("Retry-After")))
+	}
+	if r.returnResponse {
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return resp, nil
+	}
+	return body, nil
+}
+
+// retryAfter function to parse a Retry-After header given in seconds or as an HTTP date, or return 0
+func retryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
@@ -0,0 +1,149 @@
+package httphandler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	handler "github.com/Spongebob959/handler"
+)
+
+// recordingClock struct to record the waits between attempts, firing them at once
+type recordingClock struct {
+	mu    sync.Mutex
+	waits []time.Duration
+}
+
+func (c *recordingClock) Now() time.Time { return time.Now() }
+
+func (c *recordingClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waits = append(c.waits, d)
+	ch := make(chan time.Time, 1)
+	ch <- time.Now()
+	return ch
+}
+
+// newServer function to start a server answering the requests in turn with statuses, the last one repeating
+func newServer(t *testing.T, statuses []int, header http.Header) (*httptest.Server, *[]string) {
+	var mu sync.Mutex
+	var bodies []string
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		i := min(int(calls.Add(1))-1, len(statuses)-1)
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(statuses[i])
+		w.Write([]byte(http.StatusText(statuses[i])))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &bodies
+}
+
+func TestWrapRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		header    http.Header
+		wantBody  string
+		wantErr   error
+		wantCalls int
+		wantWaits []time.Duration
+	}{
+		{"retry then succeed", []int{503, 502, 200}, nil, "OK", nil, 3, []time.Duration{0, 0}},
+		{"permanent 400", []int{400}, nil, "", handler.ErrPermanent, 1, nil},
+		{"retry-after pacing", []int{429, 200}, http.Header{"Retry-After": {"2"}}, "OK", nil, 2, []time.Duration{2 * time.Second}},
+		{"retries used up", []int{500}, nil, "", handler.ErrRetryExhausted, 4, []time.Duration{0, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, bodies := newServer(t, tt.statuses, tt.header)
+			clock := &recordingClock{}
+			fh := handler.NewHandler(handler.WithRetries(3), handler.WithBackoff(handler.ConstantBackoff(0)))
+			fh.SetClock(clock)
+			req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			results, res := fh.Try(func(err error) error { return err }, New(fh, srv.Client()).WrapRequest(req))
+			if !errors.Is(res.Err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", res.Err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				var statusErr *StatusError
+				if !errors.As(res.Err, &statusErr) || statusErr.StatusCode != tt.statuses[len(tt.statuses)-1] {
+					t.Fatalf("got %v, want a *StatusError", res.Err)
+				}
+			} else if string(results[0].([]byte)) != tt.wantBody {
+				t.Fatalf("got body %q, want %q", results[0], tt.wantBody)
+			}
+			if len(*bodies) != tt.wantCalls {
+				t.Fatalf("server got %d requests, want %d", len(*bodies), tt.wantCalls)
+			}
+			// every attempt sends the whole body again
+			for _, body := range *bodies {
+				if body != "payload" {
+					t.Fatalf("server got bodies %q", *bodies)
+				}
+			}
+			if len(clock.waits) != len(tt.wantWaits) {
+				t.Fatalf("waited %v, want %v", clock.waits, tt.wantWaits)
+			}
+			for i, wait := range clock.waits {
+				if wait != tt.wantWaits[i] {
+					t.Fatalf("waited %v, want %v", clock.waits, tt.wantWaits)
+				}
+			}
+		})
+	}
+}
+
+func TestWrapRequestReturnResponse(t *testing.T) {
+	srv, _ := newServer(t, []int{200}, http.Header{"X-Id": {"7"}})
+	fh := handler.NewHandler()
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := New(fh, nil, ReturnResponse()).WrapRequest(req)()
+	if res.IsErr() {
+		t.Fatal(res.Err)
+	}
+	resp := res.Values[0].(*http.Response)
+	body, _ := io.ReadAll(resp.Body)
+	if resp.Header.Get("X-Id") != "7" || string(body) != "OK" {
+		t.Fatalf("got header %q and body %q", resp.Header.Get("X-Id"), body)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(tt.header); got != tt.want {
+			t.Errorf("retryAfter(%q) = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if got := retryAfter(date); got < 58*time.Minute || got > time.Hour {
+		t.Errorf("retryAfter(%q) = %s, want about an hour", date, got)
+	}
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// IDMode type to choose how execution and batch IDs are generated
+type IDMode int
+
+const (
+	// IDsOff generates no IDs, the default
+	IDsOff IDMode = iota
+	// IDsCounter numbers batches and executions per handler, as in b3 and e12
+	IDsCounter
+	// IDsRandom uses short random hex strings, unique across handlers and processes
+	IDsRandom
+)
+
+// ExecutionIDs struct to identify one run of a function and the batch it belongs to
+type ExecutionIDs struct {
+	Batch     string
+	Execution string
+}
+
+func (ids ExecutionIDs) String() string {
+	switch {
+	case ids.Batch == "":
+		return ids.Execution
+	case ids.Execution == "":
+		return ids.Batch
+	}
+	return ids.Batch + "/" + ids.Execution
+}
+
+// idsKey is the context key under which the IDs of the current execution are stored
+type idsKey struct{}
+
+// ExecutionIDsFrom function to return the IDs stored in the context of a running function
+func ExecutionIDsFrom(ctx context.Context) (ExecutionIDs, bool) {
+	ids, ok := ctx.Value(idsKey{}).(ExecutionIDs)
+	return ids, ok
+}
+
+// SetExecutionIDs method to give every batch and every run of a function an ID, stable across retries,
+// which is added to the log lines and the errors the handler creates for it
+func (fhi *FunctionHandlerImpl) SetExecutionIDs(mode IDMode) {
+	fhi.configure(func(s *settings) { s.idMode = mode })
+}
+
+// newID method to generate an ID with the given prefix
+func (fhi *FunctionHandlerImpl) newID(prefix string) string {
+	if fhi.settings().idMode == IDsRandom {
+		var b [4]byte
+		if _, err := rand.Read(b[:]); err == nil {
+			return prefix + hex.EncodeToString(b[:])
+		}
+	}
+	return prefix + strconv.FormatUint(fhi.lastID.Add(1), 10)
+}
+
+// withBatchID method to store a new batch ID in ctx when IDs are enabled
+func (fhi *FunctionHandlerImpl) withBatchID(ctx context.Context) context.Context {
+	if fhi.settings().idMode == IDsOff {
+		return ctx
+	}
+	return context.WithValue(ctx, idsKey{}, ExecutionIDs{Batch: fhi.newID("b")})
+}
+
+// withExecutionID method to store a new execution ID next to the batch ID in ctx when IDs are enabled
+func (fhi *FunctionHandlerImpl) withExecutionID(ctx context.Context) context.Context {
+	if fhi.settings().idMode == IDsOff {
+		return ctx
+	}
+	ids, _ := ExecutionIDsFrom(ctx)
+	ids.Execution = fhi.newID("e")
+	return context.WithValue(ctx, idsKey{}, ids)
+}
+
+// errorfIn method to create an error like errorf, adding the IDs of the execution running under ctx
+func (fhi *FunctionHandlerImpl) errorfIn(ctx context.Context, format string, a ...any) error {
+	cfg := fhi.settings()
+	ids, ok := ExecutionIDsFrom(ctx)
+	if !ok {
+		return fhi.errorf(format, a...)
+	}
+	if cfg.name != "" {
+		return prefixErrorf(fmt.Sprintf("%s [%s]", cfg.name, ids), format, a)
+	}
+	return prefixErrorf(fmt.Sprintf("[%s]", ids), format, a)
+}
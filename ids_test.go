@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExecutionIDsStableAcrossRetries(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    IDMode
+		wantIDs bool
+	}{
+		{"counter", IDsCounter, true},
+		{"random", IDsRandom, true},
+		{"off", IDsOff, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler(WithRetries(2), WithBackoff(ConstantBackoff(0)))
+			fh.SetExecutionIDs(tt.mode)
+			var mu sync.Mutex
+			events := map[string][]HookEvent{}
+			record := func(e HookEvent) {
+				mu.Lock()
+				defer mu.Unlock()
+				events[e.Function] = append(events[e.Function], e)
+			}
+			fh.OnStart(record)
+			fh.OnRetry(record)
+			fh.OnFailure(record)
+			fh.OnSuccess(record)
+			seen := map[string][]ExecutionIDs{}
+			attempt := func(ctx context.Context, name string) {
+				ids, _ := ExecutionIDsFrom(ctx)
+				mu.Lock()
+				defer mu.Unlock()
+				seen[name] = append(seen[name], ids)
+			}
+			failing := fh.WrapNamed("failing", func(ctx context.Context) error {
+				attempt(ctx, "failing")
+				return errBoom
+			})
+			flaky := fh.WrapNamed("flaky", func(ctx context.Context) error {
+				attempt(ctx, "flaky")
+				if len(seen["flaky"]) < 2 {
+					return errBoom
+				}
+				return nil
+			})
+			var infos []ExecutionInfo
+			_, res := fh.Try(func(err error, info ExecutionInfo) error {
+				infos = append(infos, info)
+				return err
+			}, flaky, failing)
+			if !errors.Is(res.Err, errBoom) || len(infos) != 1 {
+				t.Fatalf("Try = %v with %d handler calls, want the failure of failing once", res.Err, len(infos))
+			}
+			if len(events["failing"]) != 3+2+1 || len(events["flaky"]) != 2+1+1 {
+				t.Fatalf("hooks fired %d times for failing and %d for flaky, want 6 and 4", len(events["failing"]), len(events["flaky"]))
+			}
+			execIDs := map[string]string{}
+			for name, evs := range events {
+				want := seen[name][0]
+				if (want.Execution != "") != tt.wantIDs || (want.Batch != "") != tt.wantIDs {
+					t.Fatalf("%s ran with IDs %+v, want IDs %v", name, want, tt.wantIDs)
+				}
+				for _, ids := range seen[name] {
+					if ids != want {
+						t.Fatalf("%s ran with IDs %v, then %v: a retry must keep them", name, want, ids)
+					}
+				}
+				for _, e := range evs {
+					if e.ExecutionID != want.Execution || e.BatchID != want.Batch {
+						t.Fatalf("%s hook for attempt %d got IDs %s/%s, want %v", name, e.Attempt, e.BatchID, e.ExecutionID, want)
+					}
+				}
+				execIDs[name] = want.Execution
+			}
+			if info := infos[0]; info.ExecutionID != seen["failing"][0].Execution || info.BatchID != seen["failing"][0].Batch {
+				t.Fatalf("ExecutionInfo IDs = %s/%s, want %v", info.BatchID, info.ExecutionID, seen["failing"][0])
+			}
+			if tt.wantIDs && (execIDs["failing"] == execIDs["flaky"] || seen["failing"][0].Batch != seen["flaky"][0].Batch) {
+				t.Fatalf("IDs %v and %v, want one batch with two executions", seen["failing"][0], seen["flaky"][0])
+			}
+		})
+	}
+}
+
+func TestExecutionIDsOnTimeout(t *testing.T) {
+	fh := NewHandler(WithTimeout(10 * time.Millisecond))
+	fh.SetExecutionIDs(IDsCounter)
+	timedOut := make(chan HookEvent, 1)
+	fh.OnTimeout(func(e HookEvent) { timedOut <- e })
+	started := make(chan ExecutionIDs, 1)
+	fn := fh.WrapFunction(func(ctx context.Context) error {
+		ids, _ := ExecutionIDsFrom(ctx)
+		started <- ids
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if _, res := fh.Try(func(err error) error { return err }, fn); !errors.Is(res.Err, ErrTimeout) {
+		t.Fatalf("Try = %v, want %v", res.Err, ErrTimeout)
+	}
+	ids, e := <-started, <-timedOut
+	if ids.Execution == "" || e.ExecutionID != ids.Execution || e.BatchID != ids.Batch {
+		t.Fatalf("OnTimeout got IDs %s/%s, want %v", e.BatchID, e.ExecutionID, ids)
+	}
+}
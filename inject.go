@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"context"
+	"reflect"
+)
+
+var (
+	// doneChanType is the reflect type of the done channel a function can take as its first parameter
+	doneChanType = reflect.TypeOf((<-chan struct{})(nil))
+	// contextType is the reflect type of the context a function can take as its first parameter
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+// injected function to return how many leading parameters of funcType the handler supplies itself when
+// it is called with numArgs arguments. A first parameter that is not covered by the arguments is injected
+// when it is a context.Context, which receives the execution's context with its timeout deadline and its
+// batch's cancellation, or a <-chan struct{}, which receives that context's done channel.
+func injected(funcType reflect.Type, numArgs int) int {
+	if funcType.NumIn() != numArgs+1 {
+		return 0
+	}
+	if in := funcType.In(0); in == doneChanType || in == contextType {
+		return 1
+	}
+	return 0
+}
+
+// injectedFor function to return injected for function, or 0 when it is not a function
+func injectedFor(function interface{}, numArgs int) int {
+	if funcType := reflect.TypeOf(function); funcType != nil && funcType.Kind() == reflect.Func {
+		return injected(funcType, numArgs)
+	}
+	return 0
+}
+
+// injectInto function to fill the injected leading inputs of funcType from the execution's context
+func injectInto(funcType reflect.Type, inputs []reflect.Value, ctx context.Context) {
+	if len(inputs) != 1 {
+		return
+	}
+	if funcType.In(0) == contextType {
+		inputs[0] = reflect.ValueOf(&ctx).Elem()
+		return
+	}
+	inputs[0] = reflect.ValueOf(ctx.Done())
+}
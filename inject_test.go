@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDoneChannelInjection(t *testing.T) {
+	errOwn := errors.New("stopped early")
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		cancel  bool // cancel the batch shortly after it started
+		wait    bool // the function waits for done before returning errOwn
+		wantErr error
+	}{
+		{"closed on timeout", 20 * time.Millisecond, false, true, ErrTimeout},
+		{"closed on batch cancel", 0, true, true, context.Canceled},
+		{"own error when it exits in time", time.Second, false, false, errOwn},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler(WithTimeout(tt.timeout))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				time.AfterFunc(20*time.Millisecond, cancel)
+			}
+			returned := make(chan struct{})
+			fn := fh.WrapFunction(func(done <-chan struct{}) error {
+				defer close(returned)
+				if tt.wait {
+					select {
+					case <-done:
+					case <-time.After(time.Minute):
+						return errors.New("done was never closed")
+					}
+				}
+				return errOwn
+			})
+			_, res := fh.TryContext(ctx, func(err error) error { return err }, fn)
+			if !errors.Is(res.Err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", res.Err, tt.wantErr)
+			}
+			if tt.wantErr == errOwn && errors.Is(res.Err, ErrTimeout) {
+				t.Fatalf("got %v, want the function's own error without a timeout", res.Err)
+			}
+			select {
+			case <-returned:
+			case <-time.After(time.Second):
+				t.Fatal("the function did not return once done was closed")
+			}
+		})
+	}
+}
+
+func TestDoneChannelOpenWhileRunning(t *testing.T) {
+	fh := NewHandler(WithTimeout(time.Second))
+	fn := fh.WrapFunction(func(done <-chan struct{}) (bool, error) {
+		time.Sleep(10 * time.Millisecond)
+		select {
+		case <-done:
+			return false, nil
+		default:
+			return true, nil
+		}
+	})
+	if results, res := fh.Try(func(err error) error { return err }, fn); res.IsErr() || results[0] != true {
+		t.Fatalf("got %v %v, want done still open when the function finished before the timeout", results, res.Err)
+	}
+}
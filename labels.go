@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// SetPprofLabels method to run every function inside pprof.Do with the labels easyhandler_func and
+// easyhandler_handler, so CPU profiles attribute samples, retries included, to the function that caused them
+func (fhi *FunctionHandlerImpl) SetPprofLabels(enabled bool) {
+	fhi.configure(func(s *settings) { s.pprofLabels = enabled })
+}
+
+// labelled method to call run under the function's pprof labels when SetPprofLabels is enabled
+func (fhi *FunctionHandlerImpl) labelled(ctx context.Context, name string, run func(ctx context.Context) Result[any]) Result[any] {
+	cfg := fhi.settings()
+	if !cfg.pprofLabels {
+		return run(ctx)
+	}
+	var res Result[any]
+	pprof.Do(ctx, pprof.Labels("easyhandler_func", name, "easyhandler_handler", cfg.name), func(ctx context.Context) {
+		res = run(ctx)
+	})
+	return res
+}
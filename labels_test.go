@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"runtime/pprof"
+	"sync"
+	"testing"
+)
+
+func TestPprofLabels(t *testing.T) {
+	tests := []struct {
+		name        string
+		enabled     bool
+		failures    int // attempts failing before one succeeds
+		wantFunc    string
+		wantHandler string
+	}{
+		{"off", false, 0, "", ""},
+		{"on", true, 0, "fetch", "api"},
+		{"on for every retry", true, 2, "fetch", "api"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler(WithName("api"), WithRetries(tt.failures), WithBackoff(ConstantBackoff(0)))
+			fh.SetPprofLabels(tt.enabled)
+			var mu sync.Mutex
+			var seen [][2]string
+			attempt := 0
+			fn := fh.WrapNamed("fetch", func(ctx context.Context) error {
+				function, _ := pprof.Label(ctx, "easyhandler_func")
+				handler, _ := pprof.Label(ctx, "easyhandler_handler")
+				mu.Lock()
+				defer mu.Unlock()
+				seen = append(seen, [2]string{function, handler})
+				if attempt++; attempt <= tt.failures {
+					return errBoom
+				}
+				return nil
+			})
+			if _, res := fh.Try(func(err error) error { return err }, fn); res.IsErr() {
+				t.Fatalf("Try = %v", res.Err)
+			}
+			if len(seen) != tt.failures+1 {
+				t.Fatalf("ran %d times, want %d", len(seen), tt.failures+1)
+			}
+			for i, labels := range seen {
+				if labels != [2]string{tt.wantFunc, tt.wantHandler} {
+					t.Fatalf("attempt %d labelled %v, want %q and %q", i+1, labels, tt.wantFunc, tt.wantHandler)
+				}
+			}
+		})
+	}
+}
+
+func TestPprofLabelsKeepTheError(t *testing.T) {
+	fh := NewHandler()
+	fh.SetPprofLabels(true)
+	_, res := fh.Try(func(err error) error { return err }, fh.WrapFunction(func() error { return errBoom }))
+	if !errors.Is(res.Err, errBoom) {
+		t.Fatalf("Try = %v, want %v", res.Err, errBoom)
+	}
+}
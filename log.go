@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy type to choose what asynchronous logging does when its buffer is full
+type OverflowPolicy int
+
+const (
+	// OverflowDrop drops the line and counts it in DroppedLogs
+	OverflowDrop OverflowPolicy = iota
+	// OverflowBlock waits until the buffer has room
+	OverflowBlock
+)
+
+// asyncLogger struct to write log lines from a dedicated goroutine
+type asyncLogger struct {
+	mu      sync.RWMutex // held for writing while closing, so no line is sent on a closed channel
+	lines   chan logLine
+	done    chan struct{}
+	closed  bool
+	dropped atomic.Int64
+}
+
+// logLine struct to hold one buffered line, or a flush marker when flushed is set
+type logLine struct {
+	text    string
+	flushed chan struct{}
+}
+
+// newAsyncLogger function to start a logger buffering up to buffer lines
+func newAsyncLogger(buffer int) *asyncLogger {
+	l := &asyncLogger{lines: make(chan logLine, buffer), done: make(chan struct{})}
+	go func() {
+		defer close(l.done)
+		for line := range l.lines {
+			if line.flushed != nil {
+				close(line.flushed)
+				continue
+			}
+			log.Print(line.text)
+		}
+	}()
+	return l
+}
+
+// emit method to queue a line, reporting false when the logger is closed and the caller must write it
+func (l *asyncLogger) emit(text string, policy OverflowPolicy) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.closed {
+		return false
+	}
+	if policy == OverflowBlock {
+		l.lines <- logLine{text: text}
+		return true
+	}
+	select {
+	case l.lines <- logLine{text: text}:
+	default:
+		l.dropped.Add(1)
+	}
+	return true
+}
+
+// flush method to wait until every line queued before the call is written
+func (l *asyncLogger) flush() {
+	l.mu.RLock()
+	if l.closed {
+		l.mu.RUnlock()
+		return
+	}
+	flushed := make(chan struct{})
+	l.lines <- logLine{flushed: flushed}
+	l.mu.RUnlock()
+	<-flushed
+}
+
+// close method to write the remaining lines and stop the goroutine
+func (l *asyncLogger) close() {
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.lines)
+	}
+	l.mu.Unlock()
+	<-l.done
+}
+
+// Logger interface to receive the handler's log records with key-value attributes, satisfied by *slog.Logger
+type Logger interface {
+	Error(msg string, args ...any)
+	Warn(msg string, args ...any)
+}
+
+// SetLogger method to send errors and warnings to logger instead of the log package, with the handler name,
+// execution IDs, error and source location as attributes. Asynchronous logging does not apply to it; nil
+// switches back to the log package.
+func (fhi *FunctionHandlerImpl) SetLogger(logger Logger) {
+	fhi.configure(func(s *settings) { s.logSink = logger })
+}
+
+// getLogSink method to return the handler's Logger, or the nearest ancestor's
+func (fhi *FunctionHandlerImpl) getLogSink() Logger {
+	for h := fhi; h != nil; h = h.parent {
+		if logSink := h.settings().logSink; logSink != nil {
+			return logSink
+		}
+	}
+	return nil
+}
+
+// logAttrs method to prepend the handler name to the attributes of a log record
+func (fhi *FunctionHandlerImpl) logAttrs(attrs []any) []any {
+	cfg := fhi.settings()
+	if cfg.name == "" {
+		return attrs
+	}
+	return append([]any{"handler", cfg.name}, attrs...)
+}
+
+// SetAsyncLogging method to write log lines from a background goroutine through a buffer of the given size,
+// so failing functions do not wait on a slow log sink. Lines keep their order. A buffer of 0 or less
+// switches back to synchronous logging, the default. Call Close before exiting to write buffered lines.
+func (fhi *FunctionHandlerImpl) SetAsyncLogging(buffer int) {
+	var logger *asyncLogger
+	if buffer > 0 {
+		logger = newAsyncLogger(buffer)
+	}
+	if old := fhi.logger.Swap(logger); old != nil {
+		old.close()
+	}
+}
+
+// SetLogOverflow method to set what asynchronous logging does when its buffer is full, OverflowDrop by default
+func (fhi *FunctionHandlerImpl) SetLogOverflow(policy OverflowPolicy) {
+	fhi.configure(func(s *settings) { s.logOverflow = policy })
+}
+
+// DroppedLogs method to return how many log lines asynchronous logging dropped because its buffer was full
+func (fhi *FunctionHandlerImpl) DroppedLogs() int64 {
+	if logger := fhi.getLogger(); logger != nil {
+		return logger.dropped.Load()
+	}
+	return 0
+}
+
+// Flush method to wait until every log line emitted so far is written
+func (fhi *FunctionHandlerImpl) Flush() {
+	if logger := fhi.getLogger(); logger != nil {
+		logger.flush()
+	}
+}
+
+// Close method to write the buffered log lines and stop asynchronous logging; later lines are written synchronously.
+// Handlers created by Child are closed too.
+func (fhi *FunctionHandlerImpl) Close() {
+	fhi.closeChildren()
+	if logger := fhi.logger.Swap(nil); logger != nil {
+		logger.close()
+	}
+}
+
+// output method to write a log line, through the asynchronous logger when one is set
+func (fhi *FunctionHandlerImpl) output(text string) {
+	if logger := fhi.getLogger(); logger != nil && logger.emit(text, fhi.settings().logOverflow) {
+		return
+	}
+	log.Print(text)
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"fmt"
+	"go/token"
+	"reflect"
+)
+
+// WrapMethod method to create a function like WrapMethodByName that reports a missing method or mismatched
+// arguments as the error of every call instead of right away, as WrapFunction does. Methods with pointer
+// receivers need receiver to be a pointer; methods promoted from embedded fields are found like any other.
+func (fhi *FunctionHandlerImpl) WrapMethod(receiver interface{}, method string, args ...interface{}) func() Result[any] {
+	fn, err := fhi.WrapMethodByName(receiver, method, args...)
+	if err != nil {
+		return bind(&wrapped{name: fmt.Sprintf("%T.%s", receiver, method), args: args, run: func(*execution) Result[any] {
+			return Err[any](err)
+		}})
+	}
+	return fn
+}
+
+// WrapMethodByName method to create a function like WrapFunction that calls the exported method of receiver
+// with the given name. The arguments are checked against the method's signature right away, and a missing
+// or unexported method or a nil receiver is returned as an error wrapping ErrMethodNotFound.
+func (fhi *FunctionHandlerImpl) WrapMethodByName(receiver interface{}, method string, args ...interface{}) (func() Result[any], error) {
+	recv := reflect.ValueOf(receiver)
+	if !recv.IsValid() || (recv.Kind() == reflect.Pointer || recv.Kind() == reflect.Interface) && recv.IsNil() {
+		err := fhi.errorf("%w: nil receiver %T for method %s", ErrMethodNotFound, receiver, method)
+		fhi.LogError(err)
+		return nil, err
+	}
+	if !token.IsExported(method) {
+		err := fhi.errorf("%w: %s of %T is not exported", ErrMethodNotFound, method, receiver)
+		fhi.LogError(err)
+		return nil, err
+	}
+	methodValue := recv.MethodByName(method)
+	if !methodValue.IsValid() {
+		if _, ok := reflect.PointerTo(recv.Type()).MethodByName(method); ok && recv.Kind() != reflect.Pointer {
+			err := fhi.errorf("%w: %s of %T has a pointer receiver, pass a pointer", ErrMethodNotFound, method, receiver)
+			fhi.LogError(err)
+			return nil, err
+		}
+		err := fhi.errorf("%w: %T has no method %s", ErrMethodNotFound, receiver, method)
+		fhi.LogError(err)
+		return nil, err
+	}
+	if err := fhi.checkArgs(methodValue.Type(), withoutOptions(methodValue.Interface(), args)); err != nil {
+		fhi.LogError(err)
+		return nil, err
+	}
+	w := fhi.wrapFunction(methodValue.Interface(), args)
+	w.name = recv.Type().String() + "." + method
+	w.canceler, _ = receiver.(Canceler)
+	return bind(w), nil
+}
+
+// withoutOptions function to return the arguments of function without the trailing options
+func withoutOptions(function interface{}, args []interface{}) []interface{} {
+	args, _ = splitOptions(function, args)
+	return args
+}
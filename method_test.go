@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+)
+
+// account struct to call methods on by name
+type account struct {
+	balance int
+}
+
+func (a *account) Deposit(amount int) int {
+	a.balance += amount
+	return a.balance
+}
+
+func (a account) Balance() int { return a.balance }
+
+func (a account) owner() string { return "unexported" }
+
+func TestWrapMethodByName(t *testing.T) {
+	fh := NewHandler()
+	var nilAccount *account
+	tests := []struct {
+		name     string
+		receiver interface{}
+		method   string
+		args     []interface{}
+		want     error
+	}{
+		{"pointer receiver", &account{balance: 1}, "Deposit", []interface{}{2}, nil},
+		{"value receiver", account{balance: 1}, "Balance", nil, nil},
+		{"value method through a pointer", &account{balance: 1}, "Balance", nil, nil},
+		{"pointer method on a value", account{}, "Deposit", []interface{}{2}, ErrMethodNotFound},
+		{"missing method", &account{}, "Withdraw", []interface{}{2}, ErrMethodNotFound},
+		{"unexported method", account{}, "owner", nil, ErrMethodNotFound},
+		{"nil receiver", nilAccount, "Deposit", []interface{}{2}, ErrMethodNotFound},
+		{"nil interface", nil, "Deposit", []interface{}{2}, ErrMethodNotFound},
+		{"wrong argument type", &account{}, "Deposit", []interface{}{"two"}, ErrArgTypeMismatch},
+		{"missing argument", &account{}, "Deposit", nil, ErrArgCountMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := fh.WrapMethodByName(tt.receiver, tt.method, tt.args...)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("WrapMethodByName = %v, want %v", err, tt.want)
+			}
+			if (fn == nil) != (tt.want != nil) {
+				t.Fatalf("WrapMethodByName returned a function: %v, want one only without error", fn != nil)
+			}
+			if fn == nil {
+				return
+			}
+			if res := fn(); res.IsErr() || len(res.Values) != 1 {
+				t.Fatalf("calling the method = %v, %v; want its value", res.Values, res.Err)
+			}
+		})
+	}
+}
+
+// savings struct to promote the methods of an embedded account
+type savings struct {
+	*account
+}
+
+func TestWrapMethod(t *testing.T) {
+	fh := NewHandler()
+	acct := &account{}
+	tests := []struct {
+		name     string
+		fn       func() Result[any]
+		want     any
+		wantErr  error
+		wantName string
+	}{
+		{"bound receiver keeps its state", fh.WrapMethod(acct, "Deposit", 5), 5, nil, "*handler.account.Deposit"},
+		{"same receiver again", fh.WrapMethod(acct, "Deposit", 2), 7, nil, "*handler.account.Deposit"},
+		{"promoted method", fh.WrapMethod(savings{acct}, "Deposit", 3), 10, nil, "handler.savings.Deposit"},
+		{"missing method reported when called", fh.WrapMethod(acct, "Withdraw", 1), nil, ErrMethodNotFound, "*handler.account.Withdraw"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if name := fh.Describe(tt.fn).Name; name != tt.wantName {
+				t.Fatalf("named %q, want %q", name, tt.wantName)
+			}
+			results, res := fh.Try(func(err error) error { return err }, tt.fn)
+			if !errors.Is(res.Err, tt.wantErr) {
+				t.Fatalf("Try = %v, want %v", res.Err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (len(results) != 1 || results[0] != tt.want) {
+				t.Fatalf("Try = %v, want %v", results, tt.want)
+			}
+		})
+	}
+}
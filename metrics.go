@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Outcome of a function execution as reported to Metrics
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeError   Outcome = "error"
+	OutcomeTimeout Outcome = "timeout"
+	OutcomePanic   Outcome = "panic"
+	// OutcomeInvalidResult is a function that returned values a result validator rejected
+	OutcomeInvalidResult Outcome = "invalid_result"
+	// OutcomeAbandoned is a function whose batch no longer needed its result, such as after a quorum was reached
+	OutcomeAbandoned Outcome = "abandoned"
+	// OutcomeSkipped is a scheduled run that did not start because the previous one was still running
+	OutcomeSkipped Outcome = "skipped"
+)
+
+// Metrics interface to receive measurements of the functions a handler runs.
+// handler is the name set with SetName and function the name of the function, either may be empty.
+type Metrics interface {
+	Started(handler, function string)
+	Retried(handler, function string)
+	Finished(handler, function string, outcome Outcome, took time.Duration)
+}
+
+// SetMetrics method to report every execution of the handler to metrics
+func (fhi *FunctionHandlerImpl) SetMetrics(metrics Metrics) {
+	fhi.configure(func(s *settings) { s.metrics = metrics })
+}
+
+// outcomeOf function to classify the final result of an execution run with ctx
+func outcomeOf(ctx context.Context, res Result[any]) Outcome {
+	var panicErr *PanicError
+	switch {
+	case res.IsOk():
+		return OutcomeSuccess
+	case errors.Is(context.Cause(ctx), ErrAbandoned):
+		return OutcomeAbandoned
+	case errors.Is(res.Err, ErrInvalidResult):
+		return OutcomeInvalidResult
+	case errors.Is(res.Err, ErrTimeout):
+		return OutcomeTimeout
+	case errors.As(res.Err, &panicErr):
+		return OutcomePanic
+	default:
+		return OutcomeError
+	}
+}
@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ExecutionMode type to select how Try runs the functions of a batch
+type ExecutionMode int
+
+const (
+	// ModeSequential runs the functions one after another
+	ModeSequential ExecutionMode = iota
+	// ModeParallel runs the functions concurrently and settles them once all have finished
+	ModeParallel
+	// ModeFailFast runs the functions concurrently and stops the batch at the first function
+	// that is still failing after its retries and the error handler, without waiting for the rest.
+	// The rest are cancelled with a cause wrapping ErrAbandoned and the failing function's error.
+	ModeFailFast
+	// ModeCollectErrors runs the functions concurrently without calling the error handler. Try returns the
+	// values of the successful functions together with every failure joined with errors.Join, each prefixed
+	// with the function's name.
+	ModeCollectErrors
+)
+
+func (m ExecutionMode) String() string {
+	switch m {
+	case ModeSequential:
+		return "sequential"
+	case ModeParallel:
+		return "parallel"
+	case ModeFailFast:
+		return "fail-fast"
+	case ModeCollectErrors:
+		return "collect-errors"
+	}
+	return fmt.Sprintf("ExecutionMode(%d)", int(m))
+}
+
+// MarshalText method to encode the mode as its name
+func (m ExecutionMode) MarshalText() ([]byte, error) {
+	if _, ok := strategies[m]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidMode, m)
+	}
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText method to decode a mode from its name
+func (m *ExecutionMode) UnmarshalText(text []byte) error {
+	for mode := range strategies {
+		if mode.String() == string(text) {
+			*m = mode
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrInvalidMode, text)
+}
+
+// concurrent method to report whether the mode runs functions at the same time
+func (m ExecutionMode) concurrent() bool {
+	return m != ModeSequential
+}
+
+// strategy is how one execution mode runs a batch: it returns the values of the successful
+// functions, or the error that aborts the batch. ModeCollectErrors returns both.
+type strategy func(ctx context.Context, fhi *FunctionHandlerImpl, handler HandlerValues, funcs []func() Result[any]) ([]any, error)
+
+// strategies maps every valid execution mode to its strategy
+var strategies = map[ExecutionMode]strategy{
+	ModeSequential:    runSequential,
+	ModeParallel:      runParallel,
+	ModeFailFast:      runFailFast,
+	ModeCollectErrors: runCollectErrors,
+}
+
+// SetMode method to set the execution mode used by Try. An invalid mode makes Try fail.
+func (fhi *FunctionHandlerImpl) SetMode(mode ExecutionMode) {
+	fhi.configure(func(s *settings) { s.mode = mode })
+}
+
+// Mode method to return the execution mode used by Try
+func (fhi *FunctionHandlerImpl) Mode() ExecutionMode {
+	return fhi.settings().mode
+}
+
+// strategy method to look up the strategy for the configured mode
+func (fhi *FunctionHandlerImpl) strategy() (strategy, error) {
+	cfg := fhi.settings()
+	run, ok := strategies[cfg.mode]
+	if !ok {
+		return nil, fhi.errorf("%w: %s", ErrInvalidMode, cfg.mode)
+	}
+	return run, nil
+}
+
+// runSequential function to run and settle the functions one after another
+func runSequential(ctx context.Context, fhi *FunctionHandlerImpl, handler HandlerValues, funcs []func() Result[any]) ([]any, error) {
+	results := []any{}
+	flush := fhi.newFlusher()
+	for i, fn := range funcs {
+		if ctx.Err() != nil {
+			return nil, fhi.batchCancelled(ctx)
+		}
+		res := fhi.runFunction(ctx, fn)
+		if ctx.Err() != nil {
+			return nil, fhi.batchCancelled(ctx)
+		}
+		res, err := fhi.settle(ctx, handler, i, fn, res)
+		if err == nil {
+			err = flush.add(res)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if res.IsOk() {
+			results = fhi.collect(results, res.Values)
+		}
+	}
+	if err := flush.done(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// runParallel function to run the functions concurrently and settle each as it finishes, returning once all
+// have finished. After an error handler or flush error the others are still waited for, but no longer settled.
+func runParallel(ctx context.Context, fhi *FunctionHandlerImpl, handler HandlerValues, funcs []func() Result[any]) ([]any, error) {
+	results := fhi.gatherer()
+	flush := fhi.newFlusher()
+	var abort error
+	for o := range fhi.runAll(ctx, funcs) {
+		if abort != nil || ctx.Err() != nil {
+			continue
+		}
+		res, err := fhi.settle(ctx, handler, o.index, o.fn, o.res)
+		if err == nil {
+			err = flush.add(res)
+		}
+		if err != nil {
+			abort = err
+			continue
+		}
+		if res.IsOk() {
+			results.add(o.index, res.Values)
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, fhi.batchCancelled(ctx)
+	}
+	if abort != nil {
+		return nil, abort
+	}
+	if err := flush.done(); err != nil {
+		return nil, err
+	}
+	return results.values(), nil
+}
+
+// runAll method to run the functions concurrently, sending each outcome as it finishes on the returned
+// channel, which is closed once all have finished or were not started because ctx ended
+func (fhi *FunctionHandlerImpl) runAll(ctx context.Context, funcs []func() Result[any]) <-chan outcome {
+	resultCh := make(chan outcome, len(funcs))
+	wait := fhi.dispatch(funcs, ctx.Done(), func(i int, fn func() Result[any]) {
+		if res, ok := fhi.runStaggered(ctx, i, fn, nil); ok {
+			resultCh <- outcome{index: i, fn: fn, res: res}
+		}
+	})
+	go func() {
+		wait()
+		close(resultCh)
+	}()
+	return resultCh
+}
+
+// runFailFast function to run the functions concurrently and settle each as it finishes, returning
+// at the first failure. Functions still waiting for their stagger delay are then not started.
+func runFailFast(parent context.Context, fhi *FunctionHandlerImpl, handler HandlerValues, funcs []func() Result[any]) ([]any, error) {
+	results := fhi.gatherer()
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil) // after an earlier cancel this keeps its cause
+	// done is closed on return so goroutines still running after a failure can drop their result
+	done := make(chan struct{})
+	defer close(done)
+	resultCh := make(chan outcome)
+	fhi.dispatch(funcs, done, func(i int, fn func() Result[any]) {
+		res, ok := fhi.runStaggered(ctx, i, fn, done)
+		if !ok {
+			return
+		}
+		select {
+		case resultCh <- outcome{index: i, fn: fn, res: res}:
+		case <-done:
+		}
+	})
+	flush := fhi.newFlusher()
+	for range funcs {
+		var o outcome
+		select {
+		case o = <-resultCh:
+		case <-parent.Done():
+		}
+		if parent.Err() != nil {
+			return nil, fhi.batchCancelled(parent)
+		}
+		res, err := fhi.settle(ctx, handler, o.index, o.fn, o.res)
+		if err == nil {
+			err = flush.add(res)
+		}
+		if err != nil {
+			cancel(fmt.Errorf("%w: batch aborted: %w", ErrAbandoned, err))
+			return nil, err
+		}
+		if res.IsErr() {
+			cancel(fmt.Errorf("%w: %s failed: %w", ErrAbandoned, nameOf(o.fn, describe(o.fn)), res.Err))
+			return nil, res.Err
+		}
+		results.add(o.index, res.Values)
+	}
+	if err := flush.done(); err != nil {
+		return nil, err
+	}
+	return results.values(), nil
+}
+
+// runCollectErrors function to run the functions concurrently like runParallel without calling the error
+// handler, returning the values of the successful functions next to every failure
+func runCollectErrors(ctx context.Context, fhi *FunctionHandlerImpl, _ HandlerValues, funcs []func() Result[any]) ([]any, error) {
+	results := fhi.gatherer()
+	var failed failures
+	flush := fhi.newFlusher()
+	var abort error
+	for o := range fhi.runAll(ctx, funcs) {
+		if abort != nil || ctx.Err() != nil {
+			continue
+		}
+		if err := flush.add(o.res); err != nil {
+			abort = err
+			continue
+		}
+		if o.res.IsOk() {
+			results.add(o.index, o.res.Values)
+		} else {
+			failed.add(o.index, o.res)
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, fhi.batchCancelled(ctx)
+	}
+	if abort != nil {
+		return nil, abort
+	}
+	if err := flush.done(); err != nil {
+		return nil, err
+	}
+	return results.values(), failed.err()
+}
+
+// failures struct to collect the failed results of a batch under ModeCollectErrors
+type failures struct {
+	byIndex map[int]error
+}
+
+// add method to record the failure of the function at index, prefixed with its name
+func (f *failures) add(index int, res Result[any]) {
+	if f.byIndex == nil {
+		f.byIndex = map[int]error{}
+	}
+	f.byIndex[index] = fmt.Errorf("%s: %w", executionInfo(index, res).Name, res.Err)
+}
+
+// err method to join the failures in the order of their functions, nil when there are none
+func (f *failures) err() error {
+	indexes := make([]int, 0, len(f.byIndex))
+	for index := range f.byIndex {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	errs := make([]error, len(indexes))
+	for i, index := range indexes {
+		errs[i] = f.byIndex[index]
+	}
+	return errors.Join(errs...)
+}
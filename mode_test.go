@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestCancelCauses checks that a function stopped with its batch sees why through context.Cause, and that the
+// cause wraps the error that triggered it
+func TestCancelCauses(t *testing.T) {
+	errCaller := errors.New("caller gave up")
+	tests := []struct {
+		name string
+		// setup configures the handler and returns the batch's context and a function to run next to waiter
+		setup     func(fh *FunctionHandlerImpl, started <-chan struct{}) (context.Context, context.CancelFunc, func() Result[any])
+		wantCause []error
+	}{
+		{
+			name: "fail-fast",
+			setup: func(fh *FunctionHandlerImpl, started <-chan struct{}) (context.Context, context.CancelFunc, func() Result[any]) {
+				fh.SetMode(ModeFailFast)
+				return context.Background(), func() {}, fh.WrapFunction(func() error {
+					<-started
+					return errBoom
+				})
+			},
+			wantCause: []error{ErrAbandoned, errBoom},
+		},
+		{
+			name: "deadline",
+			setup: func(fh *FunctionHandlerImpl, _ <-chan struct{}) (context.Context, context.CancelFunc, func() Result[any]) {
+				ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+				return ctx, cancel, fh.WrapFunction(func() {})
+			},
+			wantCause: []error{context.DeadlineExceeded},
+		},
+		{
+			name: "caller cancel",
+			setup: func(fh *FunctionHandlerImpl, started <-chan struct{}) (context.Context, context.CancelFunc, func() Result[any]) {
+				ctx, cancel := context.WithCancelCause(context.Background())
+				go func() {
+					<-started
+					cancel(errCaller)
+				}()
+				return ctx, func() { cancel(nil) }, fh.WrapFunction(func() {})
+			},
+			wantCause: []error{errCaller},
+		},
+		{
+			name: "function timeout",
+			setup: func(fh *FunctionHandlerImpl, _ <-chan struct{}) (context.Context, context.CancelFunc, func() Result[any]) {
+				fh.SetTimeout(20 * time.Millisecond)
+				return context.Background(), func() {}, fh.WrapFunction(func() {})
+			},
+			wantCause: []error{ErrTimeout},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler(WithMode(ModeParallel))
+			started := make(chan struct{})
+			causes := make(chan error, 1)
+			waiter := fh.WrapFunction(func(ctx context.Context) error {
+				close(started)
+				<-ctx.Done()
+				causes <- context.Cause(ctx)
+				return context.Cause(ctx)
+			})
+			ctx, cancel, other := tt.setup(fh, started)
+			defer cancel()
+			_, res := fh.TryContext(ctx, func(err error) error { return err }, waiter, other)
+			if res.IsOk() {
+				t.Fatal("batch succeeded")
+			}
+			var cause error
+			select {
+			case cause = <-causes:
+			case <-time.After(time.Second):
+				t.Fatal("waiter was not stopped")
+			}
+			for _, want := range tt.wantCause {
+				if !errors.Is(cause, want) {
+					t.Fatalf("waiter saw %v, want it to wrap %v", cause, want)
+				}
+			}
+			if !errors.Is(res.Err, tt.wantCause[len(tt.wantCause)-1]) {
+				t.Fatalf("batch failed with %v, want it to wrap %v", res.Err, tt.wantCause)
+			}
+		})
+	}
+}
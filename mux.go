@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"errors"
+	"reflect"
+)
+
+// HandlerMux struct to route failures to error handlers by error type or errors.Is target. It is passed to
+// Try in place of an error handler; the first matching route handles a failure, and a failure no route
+// matches goes to the default set with Default, then to the handler's SetDefaultHandler.
+type HandlerMux struct {
+	routes         []muxRoute
+	defaultHandler interface{}
+}
+
+// muxRoute struct to pair a route's condition with its error handler
+type muxRoute struct {
+	match   func(err error) bool
+	handler interface{}
+}
+
+// handlerRoute struct to pair a route's condition with its wrapped error handler
+type handlerRoute struct {
+	match   func(err error) bool
+	handler HandlerValues
+}
+
+// NewHandlerMux function to create a HandlerMux without routes
+func NewHandlerMux() *HandlerMux {
+	return &HandlerMux{}
+}
+
+// On method to route failures that errors.As can convert to the type of target, such as &net.OpError{}.
+// The handler can take that type to receive the converted error.
+func (m *HandlerMux) On(target error, handler interface{}) *HandlerMux {
+	targetType := reflect.TypeOf(target)
+	m.routes = append(m.routes, muxRoute{match: func(err error) bool {
+		return errors.As(err, reflect.New(targetType).Interface())
+	}, handler: handler})
+	return m
+}
+
+// OnIs method to route failures matching target with errors.Is, such as context.DeadlineExceeded
+func (m *HandlerMux) OnIs(target error, handler interface{}) *HandlerMux {
+	m.routes = append(m.routes, muxRoute{match: func(err error) bool {
+		return errors.Is(err, target)
+	}, handler: handler})
+	return m
+}
+
+// Default method to set the error handler for failures no route matches
+func (m *HandlerMux) Default(handler interface{}) *HandlerMux {
+	m.defaultHandler = handler
+	return m
+}
+
+// wrapMux method to wrap the handlers of every route, failing when one of them is not a valid error handler
+func (fhi *FunctionHandlerImpl) wrapMux(m *HandlerMux) Result[HandlerValues] {
+	routes := make([]handlerRoute, 0, len(m.routes)+1)
+	add := func(match func(err error) bool, handler interface{}) error {
+		wrapped := fhi.WrapErrorHandler(handler)
+		if wrapped.IsErr() {
+			return wrapped.Err
+		}
+		routes = append(routes, handlerRoute{match: match, handler: wrapped.Values[0]})
+		return nil
+	}
+	for _, route := range m.routes {
+		if err := add(route.match, route.handler); err != nil {
+			return Err[HandlerValues](err)
+		}
+	}
+	if m.defaultHandler != nil {
+		if err := add(func(error) bool { return true }, m.defaultHandler); err != nil {
+			return Err[HandlerValues](err)
+		}
+	}
+	return Ok(HandlerValues{routes: routes})
+}
+
+// route method to return the handler of the first route matching err
+func (hv HandlerValues) route(err error) (HandlerValues, bool) {
+	for _, route := range hv.routes {
+		if route.match(err) {
+			return route.handler, true
+		}
+	}
+	return HandlerValues{}, false
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// apiError type to fail functions with a concrete error type
+type apiError struct{ code int }
+
+func (e *apiError) Error() string { return fmt.Sprintf("api error %d", e.code) }
+
+// quotaError type to fail functions with a second concrete error type
+type quotaError struct{}
+
+// errRouted is the errors.Is target of the mux in the tests
+var errRouted = errors.New("routed")
+
+func (quotaError) Error() string { return "quota exceeded" }
+
+func TestTypedErrorHandlers(t *testing.T) {
+	errOther := errors.New("other")
+	tests := []struct {
+		name         string
+		handler      func(calls *[]string) interface{}
+		withDefault  bool
+		fails        []error
+		wantCalls    []string
+		wantAbortErr error
+	}{
+		{
+			name:      "typed handler gets the unwrapped error",
+			handler:   typedHandler,
+			fails:     []error{fmt.Errorf("call: %w", &apiError{code: 503})},
+			wantCalls: []string{"api 503"},
+		},
+		{
+			name:         "non-matching failure without a default aborts",
+			handler:      typedHandler,
+			fails:        []error{errOther},
+			wantAbortErr: errOther,
+		},
+		{
+			name:        "non-matching failure goes to the default handler",
+			handler:     typedHandler,
+			withDefault: true,
+			fails:       []error{errOther},
+			wantCalls:   []string{"default other"},
+		},
+		{
+			name:        "mixed failures in one batch",
+			handler:     typedHandler,
+			withDefault: true,
+			fails:       []error{&apiError{code: 429}, errOther, &apiError{code: 500}},
+			wantCalls:   []string{"api 429", "default other", "api 500"},
+		},
+		{
+			name:      "mux routes by type",
+			handler:   muxHandler,
+			fails:     []error{fmt.Errorf("call: %w", quotaError{})},
+			wantCalls: []string{"quota"},
+		},
+		{
+			name:      "mux routes by errors.Is",
+			handler:   muxHandler,
+			fails:     []error{fmt.Errorf("call: %w", errRouted)},
+			wantCalls: []string{"is routed"},
+		},
+		{
+			name:      "mux falls back to its default",
+			handler:   muxHandler,
+			fails:     []error{errBoom},
+			wantCalls: []string{"mux default boom"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			fh := NewHandler()
+			if tt.withDefault {
+				fh.SetDefaultHandler(func(err error) error {
+					calls = append(calls, "default "+err.Error())
+					return nil
+				})
+			}
+			funcs := []func() Result[any]{fh.WrapFunction(func() int { return 1 })}
+			for _, fail := range tt.fails {
+				funcs = append(funcs, fh.WrapFunction(func() error { return fail }))
+			}
+			_, res := fh.Try(tt.handler(&calls), funcs...)
+			if tt.wantAbortErr != nil {
+				if !errors.Is(res.Err, tt.wantAbortErr) {
+					t.Fatalf("got %v, want %v", res.Err, tt.wantAbortErr)
+				}
+			} else if res.IsErr() {
+				t.Fatal(res.Err)
+			}
+			if fmt.Sprint(calls) != fmt.Sprint(tt.wantCalls) {
+				t.Fatalf("handlers called %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// typedHandler function to return an error handler taking *apiError
+func typedHandler(calls *[]string) interface{} {
+	return func(e *apiError) error {
+		*calls = append(*calls, fmt.Sprintf("api %d", e.code))
+		return nil
+	}
+}
+
+// muxHandler function to return a HandlerMux with typed, errors.Is and default routes
+func muxHandler(calls *[]string) interface{} {
+	return NewHandlerMux().
+		On(&apiError{}, typedHandler(calls)).
+		On(quotaError{}, func(quotaError) error {
+			*calls = append(*calls, "quota")
+			return nil
+		}).
+		OnIs(errRouted, func(err error) error {
+			*calls = append(*calls, "is "+errRouted.Error())
+			return nil
+		}).
+		Default(func(err error) error {
+			*calls = append(*calls, "mux default "+err.Error())
+			return nil
+		})
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingLogger struct to keep the attributes of every message logged through it
+type recordingLogger struct {
+	mu    sync.Mutex
+	attrs [][]any
+}
+
+func (l *recordingLogger) Error(msg string, args ...any) { l.record(args) }
+func (l *recordingLogger) Warn(msg string, args ...any)  { l.record(args) }
+
+func (l *recordingLogger) record(args []any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.attrs = append(l.attrs, args)
+}
+
+// logged method to return the attributes of the messages logged so far; a timed out attempt may still log
+func (l *recordingLogger) logged() [][]any {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.attrs)
+}
+
+func TestHandlerNameInRetryExhaustedError(t *testing.T) {
+	fh := NewHandler(WithName("billing"), WithRetries(2), WithBackoff(ConstantBackoff(0)), WithLogger(&recordingLogger{}))
+	_, res := fh.Try(func(err error) error { return err }, fh.WrapFunction(func() error { return errors.New("boom") }))
+	if !errors.Is(res.Err, ErrRetryExhausted) {
+		t.Fatalf("Try = %v, want %v", res.Err, ErrRetryExhausted)
+	}
+	if !strings.HasPrefix(res.Err.Error(), "billing") {
+		t.Fatalf("error %q does not start with the handler name", res.Err)
+	}
+}
+
+func TestHandlerNameInLogs(t *testing.T) {
+	logger := &recordingLogger{}
+	fh := NewHandler(WithName("billing"), WithLogger(logger))
+	fh.LogError(errors.New("boom"))
+	if len(logger.attrs) != 1 {
+		t.Fatalf("logged %d messages, want 1", len(logger.attrs))
+	}
+	attrs := logger.attrs[0]
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if attrs[i] == "handler" && attrs[i+1] == "billing" {
+			return
+		}
+	}
+	t.Fatalf("attributes %v do not name the handler", attrs)
+}
+
+func TestHandlerNameNotRepeated(t *testing.T) {
+	pass := func(err error) error { return err }
+	block := func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }
+	tests := []struct {
+		name       string
+		opts       []Option
+		fn         interface{}
+		handler    func(err error) error
+		want       error
+		wantPrefix bool
+	}{
+		{"timeout", []Option{WithTimeout(5 * time.Millisecond)}, block, pass, ErrTimeout, true},
+		{"attempt timeouts retried", []Option{func(fh *FunctionHandlerImpl) { fh.SetAttemptTimeout(5 * time.Millisecond) }, WithRetries(1), WithBackoff(ConstantBackoff(0))}, block, pass, ErrTimeout, true},
+		{"retries exhausted", []Option{WithRetries(2), WithBackoff(ConstantBackoff(0))}, func() error { return errBoom }, pass, ErrRetryExhausted, true},
+		{"handler error", nil, func() error { return errBoom }, func(err error) error { return fmt.Errorf("handled: %w", err) }, errBoom, false},
+	}
+	for _, tt := range tests {
+		for _, ids := range []IDMode{IDsOff, IDsCounter} {
+			t.Run(fmt.Sprintf("%s/ids %d", tt.name, ids), func(t *testing.T) {
+				logger := &recordingLogger{}
+				fh := NewHandler(append(tt.opts, WithName("billing"), WithLogger(logger))...)
+				fh.SetExecutionIDs(ids)
+				_, res := fh.Try(tt.handler, fh.WrapFunction(tt.fn))
+				if !errors.Is(res.Err, tt.want) {
+					t.Fatalf("Try = %v, want %v", res.Err, tt.want)
+				}
+				if got := strings.HasPrefix(res.Err.Error(), "billing"); got != tt.wantPrefix {
+					t.Fatalf("error %q starts with the handler name: %v, want %v", res.Err, got, tt.wantPrefix)
+				}
+				if n := strings.Count(res.Err.Error(), "billing"); n > 1 {
+					t.Fatalf("error %q names the handler %d times", res.Err, n)
+				}
+				logged := logger.logged()
+				if len(logged) == 0 {
+					t.Fatal("nothing was logged")
+				}
+				for _, attrs := range logged {
+					named := false
+					for i := 0; i+1 < len(attrs); i += 2 {
+						switch attrs[i] {
+						case "handler":
+							named = attrs[i+1] == "billing"
+						case "error":
+							if msg := fmt.Sprint(attrs[i+1]); strings.Count(msg, "billing") > 1 {
+								t.Fatalf("logged error %q names the handler more than once", msg)
+							}
+						}
+					}
+					if !named {
+						t.Fatalf("attributes %v do not name the handler", attrs)
+					}
+				}
+			})
+		}
+	}
+}
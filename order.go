@@ -0,0 +1,52 @@
+package handler
+
+import "sort"
+
+// SetOrderedResults method to return the values of a parallel or fail-fast batch in the order the functions were
+// passed instead of the order they finished in; for TryChan that is the order they were received. Sequential
+// batches are always in order. TryQuorum keeps the order in which its quorum succeeded.
+func (fhi *FunctionHandlerImpl) SetOrderedResults(ordered bool) {
+	fhi.configure(func(s *settings) { s.orderedResults = ordered })
+}
+
+// gathered struct to collect the values of a batch's successful functions, by function index when ordered
+type gathered struct {
+	fhi     *FunctionHandlerImpl
+	results []any
+	byIndex map[int][]any
+}
+
+// gatherer method to start collecting the values of a batch
+func (fhi *FunctionHandlerImpl) gatherer() *gathered {
+	g := &gathered{fhi: fhi, results: []any{}}
+	if fhi.settings().orderedResults {
+		g.byIndex = map[int][]any{}
+	}
+	return g
+}
+
+// add method to collect the values of the successful function at index
+func (g *gathered) add(index int, values []any) {
+	if g.byIndex == nil {
+		g.results = g.fhi.collect(g.results, values)
+		return
+	}
+	g.byIndex[index] = values
+}
+
+// values method to return the collected values, in function order when ordered
+func (g *gathered) values() []any {
+	if g.byIndex == nil {
+		return g.results
+	}
+	indexes := make([]int, 0, len(g.byIndex))
+	for index := range g.byIndex {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	results := []any{}
+	for _, index := range indexes {
+		results = g.fhi.collect(results, g.byIndex[index])
+	}
+	return results
+}
@@ -0,0 +1,71 @@
+// Package otelhandler traces the functions run by easyhandler handlers with OpenTelemetry.
+// It lives in its own module so the handler package itself keeps zero dependencies.
+package otelhandler
+
+import (
+	"context"
+
+	handler "github.com/Spongebob959/handler"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation is the name of the OpenTelemetry tracer spans are started with
+const instrumentation = "github.com/Spongebob959/handler/otelhandler"
+
+// Tracer struct to implement handler.Tracer with a span for every run of a function and a child span for every
+// attempt
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// NewTracer function to create a tracer starting spans from tp, or from the global provider when tp is nil
+func NewTracer(tp trace.TracerProvider) *Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return &Tracer{tracer: tp.Tracer(instrumentation)}
+}
+
+// Register function to create a tracer from tp and set it on every handler
+func Register(tp trace.TracerProvider, handlers ...*handler.FunctionHandlerImpl) *Tracer {
+	t := NewTracer(tp)
+	for _, h := range handlers {
+		h.SetTracer(t)
+	}
+	return t
+}
+
+// StartRun method to start the span of a run of function
+func (t *Tracer) StartRun(ctx context.Context, handlerName, function string) (context.Context, func(err error)) {
+	return t.start(ctx, spanName(function), attribute.String("easyhandler.handler", handlerName),
+		attribute.String("easyhandler.function", function))
+}
+
+// StartAttempt method to start the span of an attempt of function, a child of the run's span
+func (t *Tracer) StartAttempt(ctx context.Context, handlerName, function string, attempt int) (context.Context, func(err error)) {
+	return t.start(ctx, spanName(function)+" attempt", attribute.String("easyhandler.handler", handlerName),
+		attribute.String("easyhandler.function", function), attribute.Int("easyhandler.attempt", attempt))
+}
+
+// start method to start a span called name, returning a function to end it with its error
+func (t *Tracer) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, func(err error)) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// spanName function to name the spans of function, which may have no name
+func spanName(function string) string {
+	if function == "" {
+		return "easyhandler"
+	}
+	return function
+}
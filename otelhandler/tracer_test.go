@@ -0,0 +1,119 @@
+package otelhandler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	handler "github.com/Spongebob959/handler"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordedSpan struct to hold what was recorded on a span
+type recordedSpan struct {
+	noop.Span
+	name   string
+	parent *recordedSpan
+	attrs  map[attribute.Key]attribute.Value
+	status codes.Code
+	err    error
+	ended  bool
+}
+
+func (s *recordedSpan) SetStatus(code codes.Code, _ string)           { s.status = code }
+func (s *recordedSpan) RecordError(err error, _ ...trace.EventOption) { s.err = err }
+func (s *recordedSpan) End(...trace.SpanEndOption)                    { s.ended = true }
+func (s *recordedSpan) IsRecording() bool                             { return !s.ended }
+func (s *recordedSpan) TracerProvider() trace.TracerProvider          { return nil }
+
+func (s *recordedSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, a := range kv {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+// recordingProvider struct to implement trace.TracerProvider by keeping every span started
+type recordingProvider struct {
+	noop.TracerProvider
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (p *recordingProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return recordingTracer{provider: p}
+}
+
+// recordingTracer struct to start recorded spans, children of the recorded span in the context
+type recordingTracer struct {
+	noop.Tracer
+	provider *recordingProvider
+}
+
+func (t recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	span := &recordedSpan{name: name, attrs: map[attribute.Key]attribute.Value{}}
+	cfg := trace.NewSpanStartConfig(opts...)
+	span.SetAttributes(cfg.Attributes()...)
+	span.parent, _ = trace.SpanFromContext(ctx).(*recordedSpan)
+	t.provider.mu.Lock()
+	t.provider.spans = append(t.provider.spans, span)
+	t.provider.mu.Unlock()
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func TestTracer(t *testing.T) {
+	errBoom := errors.New("boom")
+	tests := []struct {
+		name      string
+		failRuns  int
+		wantSpans []string // the span names, the run's first
+		wantErr   bool
+	}{
+		{"success", 0, []string{"fetch", "fetch attempt"}, false},
+		{"success after a retry", 1, []string{"fetch", "fetch attempt", "fetch attempt"}, false},
+		{"failure", 2, []string{"fetch", "fetch attempt", "fetch attempt"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &recordingProvider{}
+			fh := handler.NewHandler(handler.WithName("svc"), handler.WithRetries(1), handler.WithBackoff(handler.ConstantBackoff(time.Millisecond)))
+			Register(provider, fh)
+			runs := 0
+			fn := fh.WrapNamed("fetch", func() error {
+				if runs++; runs <= tt.failRuns {
+					return errBoom
+				}
+				return nil
+			})
+			fh.Try(func(err error) error { return nil }, fn)
+			if len(provider.spans) != len(tt.wantSpans) {
+				t.Fatalf("started %d spans, want %d", len(provider.spans), len(tt.wantSpans))
+			}
+			run := provider.spans[0]
+			for i, span := range provider.spans {
+				if span.name != tt.wantSpans[i] || !span.ended {
+					t.Fatalf("span %d is %q, ended %v; want %q ended", i, span.name, span.ended, tt.wantSpans[i])
+				}
+				if span.attrs["easyhandler.handler"].AsString() != "svc" || span.attrs["easyhandler.function"].AsString() != "fetch" {
+					t.Fatalf("span %q has attributes %v, want the handler and function", span.name, span.attrs)
+				}
+				if i == 0 {
+					continue
+				}
+				if span.parent != run || span.attrs["easyhandler.attempt"].AsInt64() != int64(i) {
+					t.Fatalf("attempt span %d has parent %v and attributes %v, want the run's child for attempt %d", i, span.parent, span.attrs, i)
+				}
+				if failed := i <= tt.failRuns; (span.status == codes.Error) != failed || errors.Is(span.err, errBoom) != failed {
+					t.Fatalf("attempt span %d has status %v and error %v, want failed %v", i, span.status, span.err, failed)
+				}
+			}
+			if (run.status == codes.Error) != tt.wantErr || (run.err != nil) != tt.wantErr {
+				t.Fatalf("run span has status %v and error %v, want failed %v", run.status, run.err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PendingExecution struct to describe a function of a batch that has not succeeded yet, in a serializable form.
+// Args holds the JSON encoding of each argument, Attempts how many attempts were started so far.
+type PendingExecution struct {
+	Index    int               `json:"index"`
+	Name     string            `json:"name"`
+	Args     []json.RawMessage `json:"args"`
+	Attempts int               `json:"attempts"`
+}
+
+// StatePersister interface to store the unfinished functions of a batch, so a restarted process can resume it
+type StatePersister interface {
+	SaveState(batchID string, pending []PendingExecution) error
+	LoadState(batchID string) ([]PendingExecution, error)
+}
+
+// Checkpoint type to select when the state of a persistent batch is saved
+type Checkpoint int
+
+const (
+	// CheckpointOnComplete saves the state after each function completes
+	CheckpointOnComplete Checkpoint = 1 << iota
+	// CheckpointBeforeRetry saves the state before each retry of a function
+	CheckpointBeforeRetry
+)
+
+// SetStatePersister method to set where TryPersistent and ResumeBatch save the state of their batches and at
+// which checkpoints; the state is also saved when the batch starts and when it finishes.
+func (fhi *FunctionHandlerImpl) SetStatePersister(persister StatePersister, checkpoints Checkpoint) {
+	fhi.configure(func(s *settings) {
+		s.persister = persister
+		s.checkpoints = checkpoints
+	})
+}
+
+// TryPersistent method to run the calls of registered functions as a batch like Try, saving the unfinished
+// ones under batchID with the state persister. The arguments must encode to JSON. A nil registry means the
+// handler's registry, as in RunByName.
+func (fhi *FunctionHandlerImpl) TryPersistent(batchID string, handler interface{}, registry *Registry, calls ...NamedCall) ([]any, Result[any]) {
+	pending := make([]PendingExecution, len(calls))
+	for i, call := range calls {
+		pending[i] = PendingExecution{Index: i, Name: call.Name, Args: make([]json.RawMessage, len(call.Args))}
+		for j, arg := range call.Args {
+			data, err := json.Marshal(arg)
+			if err != nil {
+				err = fhi.errorf("argument %d of %q cannot be persisted: %w", j, call.Name, err)
+				fhi.LogError(err)
+				return nil, Err[any](err)
+			}
+			pending[i].Args[j] = data
+		}
+	}
+	return fhi.runPersistent(batchID, handler, registry, pending)
+}
+
+// ResumeBatch method to load the unfinished functions of the batch saved under batchID and run only those,
+// with the same checkpoints and registry fallback as TryPersistent
+func (fhi *FunctionHandlerImpl) ResumeBatch(batchID string, handler interface{}, registry *Registry) ([]any, Result[any]) {
+	cfg := fhi.settings()
+	if cfg.persister == nil {
+		err := fhi.errorf("%w", ErrNoPersister)
+		fhi.LogError(err)
+		return nil, Err[any](err)
+	}
+	pending, err := cfg.persister.LoadState(batchID)
+	if err != nil {
+		err = fhi.errorf("loading batch %q: %w", batchID, err)
+		fhi.LogError(err)
+		return nil, Err[any](err)
+	}
+	if len(pending) == 0 {
+		return []any{}, Ok[any](nil)
+	}
+	return fhi.runPersistent(batchID, handler, registry, pending)
+}
+
+// batchState struct to track the unfinished functions of a persistent batch
+type batchState struct {
+	fhi     *FunctionHandlerImpl
+	id      string
+	mu      sync.Mutex
+	pending map[int]PendingExecution
+	saveMu  sync.Mutex // held across a snapshot and its save, so an older snapshot is never saved last
+}
+
+// runPersistent method to resolve the pending executions against the registry and run them as one batch
+func (fhi *FunctionHandlerImpl) runPersistent(batchID string, handler interface{}, registry *Registry, pending []PendingExecution) ([]any, Result[any]) {
+	cfg := fhi.settings()
+	if cfg.persister == nil {
+		err := fhi.errorf("%w", ErrNoPersister)
+		fhi.LogError(err)
+		return nil, Err[any](err)
+	}
+	if registry == nil {
+		registry = cfg.registry
+	}
+	if registry == nil {
+		registry = DefaultRegistry
+	}
+	state := &batchState{fhi: fhi, id: batchID, pending: map[int]PendingExecution{}}
+	funcs := make([]func() Result[any], len(pending))
+	for i, p := range pending {
+		function, args, err := resolve(registry, p)
+		if err != nil {
+			err = fhi.errorf("batch %q: %w", batchID, err)
+			fhi.LogError(err)
+			return nil, Err[any](err)
+		}
+		funcs[i] = state.wrap(p, fhi.wrapFunction(function, args))
+		state.pending[p.Index] = p
+	}
+	if err := state.save(); err != nil {
+		return nil, Err[any](err)
+	}
+	results, res := fhi.Try(handler, funcs...)
+	if res.IsOk() {
+		state.mu.Lock()
+		clear(state.pending)
+		state.mu.Unlock()
+		if err := state.save(); err != nil {
+			return results, Err[any](err)
+		}
+	}
+	return results, res
+}
+
+// wrap method to describe a pending execution that records its retries and completion in the state.
+// Only a success finishes it: a failed function stays pending, so resuming the batch runs it again.
+func (s *batchState) wrap(p PendingExecution, w *wrapped) func() Result[any] {
+	return bind(&wrapped{name: p.Name, function: w.function, args: w.args,
+		run: func(exec *execution) Result[any] {
+			checkpoint := CheckpointBeforeRetry
+			if exec.attempt == 1 {
+				checkpoint = 0 // the first attempt is covered by the save at the start of the batch
+			}
+			s.update(p.Index, checkpoint, func(p *PendingExecution) { p.Attempts++ })
+			return w.run(exec)
+		},
+		onResult: func(res Result[any]) {
+			if res.IsOk() {
+				s.update(p.Index, CheckpointOnComplete, nil)
+				return
+			}
+			s.update(p.Index, CheckpointOnComplete, func(p *PendingExecution) {}) // a failure stays pending
+		},
+	})
+}
+
+// update method to change or, with a nil change, remove a pending execution and save at the checkpoint
+func (s *batchState) update(index int, checkpoint Checkpoint, change func(p *PendingExecution)) {
+	s.mu.Lock()
+	if change == nil {
+		delete(s.pending, index)
+	} else if p, ok := s.pending[index]; ok {
+		change(&p)
+		s.pending[index] = p
+	}
+	s.mu.Unlock()
+	if s.fhi.settings().checkpoints&checkpoint != 0 {
+		s.save() // a failed checkpoint is logged; the batch itself goes on
+	}
+}
+
+// save method to hand the pending executions, ordered by index, to the state persister
+func (s *batchState) save() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	s.mu.Lock()
+	pending := make([]PendingExecution, 0, len(s.pending))
+	for _, p := range s.pending {
+		pending = append(pending, p)
+	}
+	s.mu.Unlock()
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Index < pending[j].Index })
+	if err := s.fhi.settings().persister.SaveState(s.id, pending); err != nil {
+		err = s.fhi.errorf("saving batch %q: %w", s.id, err)
+		s.fhi.LogError(err)
+		return err
+	}
+	return nil
+}
+
+// resolve function to look up the function of a pending execution and decode its arguments
+func resolve(registry *Registry, p PendingExecution) (interface{}, []interface{}, error) {
+	function, err := registry.lookup(p.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	args, err := decodeArgs(reflect.TypeOf(function), p.Args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%q: %w", p.Name, err)
+	}
+	return function, args, nil
+}
+
+// decodeArgs function to decode JSON arguments into the parameter types of funcType
+func decodeArgs(funcType reflect.Type, data []json.RawMessage) ([]interface{}, error) {
+	n := injected(funcType, len(data))
+	if len(data) != funcType.NumIn()-n {
+		return nil, fmt.Errorf("%w: got %d, want %d", ErrArgCountMismatch, len(data), funcType.NumIn())
+	}
+	args := make([]interface{}, len(data))
+	for i, raw := range data {
+		paramType := funcType.In(n + i)
+		if funcType.IsVariadic() && n+i == funcType.NumIn()-1 {
+			paramType = paramType.Elem()
+		}
+		arg := reflect.New(paramType)
+		if err := json.Unmarshal(raw, arg.Interface()); err != nil {
+			return nil, fmt.Errorf("%w: argument %d: %w", ErrArgTypeMismatch, i, err)
+		}
+		args[i] = arg.Elem().Interface()
+	}
+	return args, nil
+}
+
+// FilePersister struct to save the state of each batch as a JSON file in Dir, named after the batch ID
+type FilePersister struct {
+	Dir string
+}
+
+// SaveState method to write the pending executions of a batch, removing the file once none are left
+func (f FilePersister) SaveState(batchID string, pending []PendingExecution) error {
+	path, err := f.path(batchID)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	// write to a temporary file of its own first, so neither a crash nor a concurrent save leaves a half
+	// written state behind
+	tmp, err := os.CreateTemp(f.Dir, batchID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadState method to read the pending executions of a batch, none when it has no file
+func (f FilePersister) LoadState(batchID string) ([]PendingExecution, error) {
+	path, err := f.path(batchID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pending []PendingExecution
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+// path method to return the file of a batch, rejecting IDs that are not a plain file name
+func (f FilePersister) path(batchID string) (string, error) {
+	if batchID == "" || batchID == "." || batchID == ".." || strings.ContainsAny(batchID, `/\`) {
+		return "", fmt.Errorf("invalid batch ID %q: must be a plain file name", batchID)
+	}
+	return filepath.Join(f.Dir, batchID+".json"), nil
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingPersister keeps every saved state, in the order the saves completed
+type recordingPersister struct {
+	mu    sync.Mutex
+	saves [][]PendingExecution
+}
+
+func (p *recordingPersister) SaveState(batchID string, pending []PendingExecution) error {
+	time.Sleep(time.Millisecond) // widen the window in which saves could overtake each other
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.saves = append(p.saves, pending)
+	return nil
+}
+
+func (p *recordingPersister) LoadState(batchID string) ([]PendingExecution, error) {
+	return nil, nil
+}
+
+func TestPersistentSavesNeverGoBackwards(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register("noop", func(int) {}); err != nil {
+		t.Fatal(err)
+	}
+	persister := &recordingPersister{}
+	fh := NewHandler(WithMode(ModeParallel))
+	fh.SetStatePersister(persister, CheckpointOnComplete)
+	calls := make([]NamedCall, 20)
+	for i := range calls {
+		calls[i] = NamedCall{Name: "noop", Args: []interface{}{i}}
+	}
+	if _, res := fh.TryPersistent("batch", func(err error) error { return err }, registry, calls...); res.IsErr() {
+		t.Fatal(res.Err)
+	}
+	for i := 1; i < len(persister.saves); i++ {
+		if len(persister.saves[i]) > len(persister.saves[i-1]) {
+			t.Fatalf("save %d has %d pending after a save with %d", i, len(persister.saves[i]), len(persister.saves[i-1]))
+		}
+	}
+	if last := persister.saves[len(persister.saves)-1]; len(last) != 0 {
+		t.Fatalf("last save has %d pending, want 0", len(last))
+	}
+}
+
+func TestPersistentNilRegistryFallsBackToDefault(t *testing.T) {
+	name := "persist-test-default-registry"
+	if err := Register(name, func() {}); err != nil && !errors.Is(err, ErrDuplicateName) {
+		t.Fatal(err)
+	}
+	fh := NewHandler()
+	fh.SetStatePersister(&recordingPersister{}, 0)
+	if _, res := fh.TryPersistent("batch", func(err error) error { return err }, nil, NamedCall{Name: name}); res.IsErr() {
+		t.Fatal(res.Err)
+	}
+}
+
+func TestFilePersisterConcurrentSaves(t *testing.T) {
+	persister := FilePersister{Dir: t.TempDir()}
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- persister.SaveState("batch", []PendingExecution{{Index: i, Name: "f"}})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	pending, err := persister.LoadState("batch")
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("LoadState = %v, %v; want one pending execution", pending, err)
+	}
+	leftovers, _ := filepath.Glob(filepath.Join(persister.Dir, "*.tmp"))
+	if len(leftovers) > 0 {
+		t.Fatalf("temporary files left behind: %v", leftovers)
+	}
+}
+
+func TestFilePersisterRejectsPathIDs(t *testing.T) {
+	dir := t.TempDir()
+	persister := FilePersister{Dir: filepath.Join(dir, "state")}
+	if err := os.Mkdir(persister.Dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"", ".", "..", "../x", "a/b", `a\b`} {
+		if err := persister.SaveState(id, []PendingExecution{{Name: "f"}}); err == nil {
+			t.Errorf("SaveState(%q) succeeded, want an error", id)
+		}
+		if _, err := persister.LoadState(id); err == nil {
+			t.Errorf("LoadState(%q) succeeded, want an error", id)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "x.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("a file was written outside the directory: %v", err)
+	}
+}
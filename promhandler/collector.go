@@ -0,0 +1,123 @@
+// Package promhandler exposes the executions of easyhandler handlers as Prometheus metrics.
+// It lives in its own module so the handler package itself keeps zero dependencies.
+package promhandler
+
+import (
+	"time"
+
+	handler "github.com/Spongebob959/handler"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unknown is the label value used when a handler or function has no name
+const unknown = "unknown"
+
+// Collector struct to implement prometheus.Collector and handler.Metrics, along with handler.CircuitMetrics
+// and handler.BatchMetrics
+type Collector struct {
+	executions *prometheus.CounterVec
+	retries    *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+	inflight   *prometheus.GaugeVec
+	circuits   *prometheus.GaugeVec
+	batchWait  *prometheus.HistogramVec
+}
+
+// NewCollector function to create a collector that still has to be registered and set on handlers
+func NewCollector() *Collector {
+	return &Collector{
+		executions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "easyhandler_executions_total",
+			Help: "Functions run by a handler, by outcome such as success, error or timeout.",
+		}, []string{"handler", "func", "outcome"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "easyhandler_retries_total",
+			Help: "Retry attempts made by a handler.",
+		}, []string{"handler", "func"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "easyhandler_duration_seconds",
+			Help:    "Time taken by functions run by a handler, including retries.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"handler", "func"}),
+		inflight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "easyhandler_inflight",
+			Help: "Functions currently being run by a handler.",
+		}, []string{"handler"}),
+		circuits: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "easyhandler_circuit_state",
+			Help: "State of a function's circuit breaker: 0 closed, 1 open, 2 half-open.",
+		}, []string{"handler", "func"}),
+		batchWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "easyhandler_batch_wait_seconds",
+			Help:    "Time batches waited for a slot under a concurrent batch limit.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"handler"}),
+	}
+}
+
+// Register function to create a collector, register it with reg and report the handlers' executions to it
+func Register(reg prometheus.Registerer, handlers ...*handler.FunctionHandlerImpl) (*Collector, error) {
+	c := NewCollector()
+	if err := reg.Register(c); err != nil {
+		return nil, err
+	}
+	for _, h := range handlers {
+		h.SetMetrics(c)
+	}
+	return c, nil
+}
+
+// Describe method to implement prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.executions.Describe(ch)
+	c.retries.Describe(ch)
+	c.duration.Describe(ch)
+	c.inflight.Describe(ch)
+	c.circuits.Describe(ch)
+	c.batchWait.Describe(ch)
+}
+
+// Collect method to implement prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.executions.Collect(ch)
+	c.retries.Collect(ch)
+	c.duration.Collect(ch)
+	c.inflight.Collect(ch)
+	c.circuits.Collect(ch)
+	c.batchWait.Collect(ch)
+}
+
+// Started method to implement handler.Metrics
+func (c *Collector) Started(handlerName, function string) {
+	c.inflight.WithLabelValues(label(handlerName)).Inc()
+}
+
+// Retried method to implement handler.Metrics
+func (c *Collector) Retried(handlerName, function string) {
+	c.retries.WithLabelValues(label(handlerName), label(function)).Inc()
+}
+
+// Finished method to implement handler.Metrics
+func (c *Collector) Finished(handlerName, function string, outcome handler.Outcome, took time.Duration) {
+	c.inflight.WithLabelValues(label(handlerName)).Dec()
+	c.executions.WithLabelValues(label(handlerName), label(function), string(outcome)).Inc()
+	c.duration.WithLabelValues(label(handlerName), label(function)).Observe(took.Seconds())
+}
+
+// CircuitChanged method to implement handler.CircuitMetrics
+func (c *Collector) CircuitChanged(handlerName, function string, state handler.CircuitState) {
+	c.circuits.WithLabelValues(label(handlerName), label(function)).Set(float64(state))
+}
+
+// BatchWaited method to implement handler.BatchMetrics
+func (c *Collector) BatchWaited(handlerName string, position int, waited time.Duration) {
+	c.batchWait.WithLabelValues(label(handlerName)).Observe(waited.Seconds())
+}
+
+// label function to replace an empty name with "unknown"
+func label(name string) string {
+	if name == "" {
+		return unknown
+	}
+	return name
+}
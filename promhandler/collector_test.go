@@ -0,0 +1,69 @@
+package promhandler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	handler "github.com/Spongebob959/handler"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	fh := handler.NewHandler(handler.WithName("billing"), handler.WithRetries(1), handler.WithBackoff(handler.ConstantBackoff(0)))
+	c, err := Register(reg, fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errBoom := errors.New("boom")
+	fh.Try(func(err error) error { return nil },
+		fh.WrapNamed("charge", func() error { return nil }),
+		fh.WrapNamed("refund", func() error { return errBoom }),
+	)
+
+	want := `
+# HELP easyhandler_executions_total Functions run by a handler, by outcome such as success, error or timeout.
+# TYPE easyhandler_executions_total counter
+easyhandler_executions_total{func="charge",handler="billing",outcome="success"} 1
+easyhandler_executions_total{func="refund",handler="billing",outcome="error"} 1
+# HELP easyhandler_retries_total Retry attempts made by a handler.
+# TYPE easyhandler_retries_total counter
+easyhandler_retries_total{func="refund",handler="billing"} 1
+# HELP easyhandler_inflight Functions currently being run by a handler.
+# TYPE easyhandler_inflight gauge
+easyhandler_inflight{handler="billing"} 0
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"easyhandler_executions_total", "easyhandler_retries_total", "easyhandler_inflight"); err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.CollectAndCount(c, "easyhandler_duration_seconds"); n != 2 {
+		t.Fatalf("got %d duration series, want 2", n)
+	}
+	problems, err := testutil.CollectAndLint(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) > 0 {
+		t.Fatalf("lint problems: %v", problems)
+	}
+}
+
+func TestCollectorStates(t *testing.T) {
+	c := NewCollector()
+	c.Started("", "")
+	c.Finished("", "", handler.OutcomeTimeout, 0)
+	if got := testutil.ToFloat64(c.executions.WithLabelValues(unknown, unknown, string(handler.OutcomeTimeout))); got != 1 {
+		t.Fatalf("executions without names = %v, want 1 labelled %s", got, unknown)
+	}
+	c.CircuitChanged("", "fetch", handler.CircuitOpen)
+	c.BatchWaited("billing", 2, 0)
+	if got := testutil.ToFloat64(c.circuits.WithLabelValues(unknown, "fetch")); got != float64(handler.CircuitOpen) {
+		t.Fatalf("circuit state = %v, want %v", got, float64(handler.CircuitOpen))
+	}
+	if n := testutil.CollectAndCount(c, "easyhandler_batch_wait_seconds"); n != 1 {
+		t.Fatalf("got %d batch wait series, want 1", n)
+	}
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// TryQuorum method to run the functions concurrently and succeed as soon as k of them have succeeded, with the
+// values of those k. The functions still running are then abandoned: their injected done channel is closed and
+// they are reported to the metrics as OutcomeAbandoned. Once too many have failed to reach k, the batch fails
+// with their joined errors. Failures are passed to the error handler as in Try, but still count as failures.
+func (fhi *FunctionHandlerImpl) TryQuorum(k int, handler interface{}, funcs ...func() Result[any]) ([]any, Result[any]) {
+	release, err := fhi.acquireBatch(context.Background())
+	if err != nil {
+		fhi.LogError(err)
+		return nil, Err[any](err)
+	}
+	defer release()
+	handlerFunc := fhi.WrapErrorHandler(handler)
+	if handlerFunc.IsErr() {
+		return nil, Err[any](handlerFunc.Err)
+	}
+	switch {
+	case len(funcs) == 0:
+		err = fhi.errorf("%w", ErrNoFunctions)
+	case k <= 0 || k > len(funcs):
+		err = fhi.errorf("%w: quorum of %d with %d functions", ErrInvalidQuorum, k, len(funcs))
+	default:
+		err = fhi.checkRetryBound(context.Background())
+	}
+	if err != nil {
+		fhi.LogError(err)
+		return nil, Err[any](err)
+	}
+	results, err := fhi.runQuorum(fhi.withBatchID(context.Background()), k, handlerFunc.Values[0], funcs)
+	if err != nil {
+		return nil, Err[any](err)
+	}
+	return results, Ok[any](nil)
+}
+
+// runQuorum method to run the functions concurrently and settle each as it finishes, returning once k have
+// succeeded or more than len(funcs)-k have failed
+func (fhi *FunctionHandlerImpl) runQuorum(parent context.Context, k int, handler HandlerValues, funcs []func() Result[any]) ([]any, error) {
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil) // after an earlier cancel this keeps its cause
+	// done is closed on return so goroutines still running can drop their result
+	done := make(chan struct{})
+	defer close(done)
+	resultCh := make(chan outcome)
+	fhi.dispatch(funcs, done, func(i int, fn func() Result[any]) {
+		res, ok := fhi.runStaggered(ctx, i, fn, done)
+		if !ok {
+			return
+		}
+		select {
+		case resultCh <- outcome{index: i, fn: fn, res: res}:
+		case <-done:
+		}
+	})
+	results := []any{}
+	var errs []error
+	succeeded := 0
+	for range funcs {
+		o := <-resultCh
+		res, err := fhi.settle(ctx, handler, o.index, o.fn, o.res)
+		if err != nil {
+			cancel(fmt.Errorf("%w: batch aborted: %w", ErrAbandoned, err))
+			return nil, err
+		}
+		if res.IsErr() {
+			errs = append(errs, res.Err)
+			if len(errs) > len(funcs)-k {
+				err := fhi.errorfIn(ctx, "quorum of %d of %d unreachable after %d failures: %w", k, len(funcs), len(errs), errors.Join(errs...))
+				cancel(fmt.Errorf("%w: %w", ErrAbandoned, err))
+				return nil, err
+			}
+			continue
+		}
+		results = fhi.collect(results, res.Values)
+		if succeeded++; succeeded == k {
+			cancel(fmt.Errorf("%w: quorum of %d of %d reached", ErrAbandoned, k, len(funcs)))
+			return results, nil
+		}
+	}
+	return results, nil
+}
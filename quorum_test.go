@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTryQuorumPassesTheIndexToTheErrorHandler(t *testing.T) {
+	fh := NewHandler()
+	var mu sync.Mutex
+	var indices []int
+	handler := func(err error, info ExecutionInfo) error {
+		mu.Lock()
+		defer mu.Unlock()
+		indices = append(indices, info.Index)
+		return nil
+	}
+	fail := fh.WrapFunction(func() error { return errors.New("failed") })
+	slow := fh.WrapFunction(func() int { time.Sleep(50 * time.Millisecond); return 1 })
+	if _, res := fh.TryQuorum(1, handler, fail, fail, slow); res.IsErr() {
+		t.Fatal(res.Err)
+	}
+	sort.Ints(indices)
+	if len(indices) != 2 || indices[0] != 0 || indices[1] != 1 {
+		t.Fatalf("error handler got indices %v, want [0 1]", indices)
+	}
+}
+
+func TestTryQuorum(t *testing.T) {
+	fh := NewHandler()
+	fail := fh.WrapFunction(func() error { return errors.New("failed") })
+	ok := fh.WrapFunction(func() int { return 1 })
+	ignore := func(err error) error { return nil }
+	tests := []struct {
+		name    string
+		k       int
+		funcs   []func() Result[any]
+		wantErr bool
+	}{
+		{"reached", 2, []func() Result[any]{ok, fail, ok}, false},
+		{"unreachable", 2, []func() Result[any]{fail, fail, ok}, true},
+		{"k too large", 4, []func() Result[any]{ok, ok, ok}, true},
+		{"k zero", 0, []func() Result[any]{ok}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, res := fh.TryQuorum(tt.k, ignore, tt.funcs...)
+			if res.IsErr() != tt.wantErr {
+				t.Fatalf("TryQuorum = %v, want error %v", res.Err, tt.wantErr)
+			}
+			if !tt.wantErr && len(results) != tt.k {
+				t.Fatalf("TryQuorum returned %v, want %d values", results, tt.k)
+			}
+		})
+	}
+}
+
+// TestTryQuorumReturnsEarly pins the quorum semantics: TryQuorum(n, ...) returns as soon as n functions have
+// succeeded, or as soon as so many have failed that n can no longer be reached, without waiting for the rest
+func TestTryQuorumReturnsEarly(t *testing.T) {
+	const slowFor = 500 * time.Millisecond
+	tests := []struct {
+		name      string
+		n         int
+		fast      []error // outcomes of the functions returning at once, nil for a success
+		slow      int     // functions returning a success after slowFor unless abandoned
+		want      error
+		wantEarly bool
+	}{
+		{"quorum reached", 2, []error{nil, nil}, 1, nil, true},
+		{"quorum reached despite a failure", 2, []error{nil, errBoom, nil}, 1, nil, true},
+		{"quorum impossible", 2, []error{errBoom, errBoom}, 1, errBoom, true},
+		{"quorum needs the slow function", 3, []error{nil, nil}, 1, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler(WithLogger(&recordingLogger{}))
+			var funcs []func() Result[any]
+			for i, err := range tt.fast {
+				funcs = append(funcs, fh.WrapFunction(func() (int, error) { return i, err }))
+			}
+			started, abandoned := make(chan struct{}, tt.slow), make(chan struct{}, tt.slow)
+			for i := 0; i < tt.slow; i++ {
+				funcs = append(funcs, fh.WrapFunction(func(done <-chan struct{}) int {
+					started <- struct{}{}
+					select {
+					case <-done:
+						abandoned <- struct{}{}
+					case <-time.After(slowFor):
+					}
+					return -1
+				}))
+			}
+			start := time.Now()
+			results, res := fh.TryQuorum(tt.n, func(err error) error { return nil }, funcs...)
+			took := time.Since(start)
+			if !errors.Is(res.Err, tt.want) {
+				t.Fatalf("TryQuorum = %v, want %v", res.Err, tt.want)
+			}
+			if tt.want == nil && len(results) != tt.n {
+				t.Fatalf("TryQuorum = %v, want the values of %d successes", results, tt.n)
+			}
+			if early := took < slowFor; early != tt.wantEarly {
+				t.Fatalf("TryQuorum took %s, returning early: %v, want %v", took, early, tt.wantEarly)
+			}
+			if len(started) > 0 && tt.wantEarly { // a slow function not started yet is never started
+				select {
+				case <-abandoned:
+				case <-time.After(time.Second):
+					t.Fatal("the slow function was not abandoned")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter interface to pace the attempts of a handler, satisfied by *rate.Limiter of golang.org/x/time/rate
+type RateLimiter interface {
+	// Wait blocks until an attempt may start, returning an error when ctx ends first
+	Wait(ctx context.Context) error
+}
+
+// SetRateLimit method to start at most rps attempts per second, with bursts of up to burst, across all the
+// batches of the handler and its children. Attempts beyond the limit wait for their turn, bounded by their
+// context. rps <= 0 removes the limit.
+func (fhi *FunctionHandlerImpl) SetRateLimit(rps float64, burst int) {
+	fhi.configure(func(s *settings) { s.rateLimiter = fhi.newRateLimiter(rps, burst) })
+}
+
+// newRateLimiter method to create the token bucket of SetRateLimit, nil when there is no limit
+func (fhi *FunctionHandlerImpl) newRateLimiter(rps float64, burst int) RateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	burst = max(burst, 1)
+	return &tokenBucket{fhi: fhi, rate: rps, burst: float64(burst), tokens: float64(burst)}
+}
+
+// SetRateLimiter method to pace attempts with limiter instead of the built-in token bucket; nil removes the limit
+func (fhi *FunctionHandlerImpl) SetRateLimiter(limiter RateLimiter) {
+	fhi.configure(func(s *settings) { s.rateLimiter = limiter })
+}
+
+// getRateLimiter method to return the handler's rate limiter, or the nearest ancestor's
+func (fhi *FunctionHandlerImpl) getRateLimiter() RateLimiter {
+	for h := fhi; h != nil; h = h.parent {
+		if rateLimiter := h.settings().rateLimiter; rateLimiter != nil {
+			return rateLimiter
+		}
+	}
+	return nil
+}
+
+// tokenBucket struct to implement RateLimiter with a token bucket on the handler's clock
+type tokenBucket struct {
+	fhi    *FunctionHandlerImpl
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64 // negative when attempts are waiting for tokens not added yet
+	last   time.Time
+}
+
+// Wait method to take a token, waiting until it is added when the bucket is empty
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	clock := b.fhi.getClock()
+	b.mu.Lock()
+	now := clock.Now()
+	if !b.last.IsZero() {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	b.tokens--
+	wait := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-clock.After(wait):
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens++ // give the reserved token back
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
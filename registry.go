@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Registry struct to hold functions under names, so batches can be described by data instead of closures.
+// It is safe for concurrent use.
+type Registry struct {
+	mu    sync.RWMutex
+	funcs map[string]interface{}
+}
+
+// NamedCall struct to describe a call of a registered function
+type NamedCall struct {
+	Name string
+	Args []interface{}
+}
+
+// NewRegistry function to create an empty registry
+func NewRegistry() *Registry {
+	return &Registry{funcs: map[string]interface{}{}}
+}
+
+// DefaultRegistry is the process-wide registry used by RunByName when the handler has none of its own
+var DefaultRegistry = NewRegistry()
+
+// Register function to add function to the default registry under name
+func Register(name string, function interface{}) error {
+	return DefaultRegistry.Register(name, function)
+}
+
+// Register method to add function under name; the name must not be taken yet
+func (r *Registry) Register(name string, function interface{}) error {
+	if reflect.TypeOf(function) == nil || reflect.TypeOf(function).Kind() != reflect.Func {
+		return fmt.Errorf("%w: %q is %T", ErrNotAFunction, name, function)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.funcs[name]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateName, name)
+	}
+	r.funcs[name] = function
+	return nil
+}
+
+// Lookup method to return the function registered under name
+func (r *Registry) Lookup(name string) (interface{}, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	function, ok := r.funcs[name]
+	return function, ok
+}
+
+// Names method to return the registered names in sorted order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.funcs))
+	for name := range r.funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Schema method to return the types of the arguments a call of the function registered under name takes, without
+// the context.Context or <-chan struct{} injected as its first parameter. A variadic function's last type is a slice.
+func (r *Registry) Schema(name string) ([]reflect.Type, bool) {
+	function, ok := r.Lookup(name)
+	if !ok {
+		return nil, false
+	}
+	funcType := reflect.TypeOf(function)
+	first := 0
+	if funcType.NumIn() > 0 {
+		first = injected(funcType, funcType.NumIn()-1)
+	}
+	types := make([]reflect.Type, 0, funcType.NumIn()-first)
+	for i := first; i < funcType.NumIn(); i++ {
+		types = append(types, funcType.In(i))
+	}
+	return types, true
+}
+
+// lookup method to return the function registered under name, or an error wrapping ErrUnknownFunction
+func (r *Registry) lookup(name string) (interface{}, error) {
+	function, ok := r.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFunction, name)
+	}
+	return function, nil
+}
+
+// SetRegistry method to set the registry RunByName resolves names against; nil means DefaultRegistry
+func (fhi *FunctionHandlerImpl) SetRegistry(registry *Registry) {
+	fhi.configure(func(s *settings) { s.registry = registry })
+}
+
+// SetSkipUnknownNames method to make RunByName log and skip calls of unregistered names instead of failing
+func (fhi *FunctionHandlerImpl) SetSkipUnknownNames(skip bool) {
+	fhi.configure(func(s *settings) { s.skipUnknownNames = skip })
+}
+
+// RunByName method to run the calls as one batch like Try, each resolved against the handler's registry.
+// Every call is resolved and its arguments checked before anything runs, and the batch fails with all the
+// problems joined when one is unknown or does not match its function's parameters.
+func (fhi *FunctionHandlerImpl) RunByName(handler interface{}, calls []NamedCall) ([]any, Result[any]) {
+	cfg := fhi.settings()
+	registry := cfg.registry
+	if registry == nil {
+		registry = DefaultRegistry
+	}
+	funcs := make([]func() Result[any], 0, len(calls))
+	var errs []error
+	for _, call := range calls {
+		function, err := registry.lookup(call.Name)
+		if err != nil && cfg.skipUnknownNames {
+			fhi.logWarn("skipping call: %v", err)
+			continue
+		}
+		if err == nil {
+			err = fhi.checkArgs(reflect.TypeOf(function), call.Args)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", call.Name, err))
+			continue
+		}
+		funcs = append(funcs, fhi.WrapNamed(call.Name, function, call.Args...))
+	}
+	if len(errs) > 0 {
+		err := fhi.errorf("%w", errors.Join(errs...))
+		fhi.LogError(err)
+		return nil, Err[any](err)
+	}
+	return fhi.Try(handler, funcs...)
+}
+
+// TryNamed method to run the calls as one batch like RunByName
+func (fhi *FunctionHandlerImpl) TryNamed(handler interface{}, calls ...NamedCall) ([]any, Result[any]) {
+	return fhi.RunByName(handler, calls)
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRegistryRegister(t *testing.T) {
+	r := NewRegistry()
+	tests := []struct {
+		name     string
+		function interface{}
+		want     error
+	}{
+		{"add", func(a, b int) int { return a + b }, nil},
+		{"add", func() {}, ErrDuplicateName},
+		{"answer", 42, ErrNotAFunction},
+		{"missing", nil, ErrNotAFunction},
+		{"greet", func(name string) string { return "hi " + name }, nil},
+	}
+	for _, tt := range tests {
+		if err := r.Register(tt.name, tt.function); !errors.Is(err, tt.want) {
+			t.Fatalf("Register(%q, %T) = %v, want %v", tt.name, tt.function, err, tt.want)
+		}
+	}
+	if names := r.Names(); !slices.Equal(names, []string{"add", "greet"}) {
+		t.Fatalf("Names = %v, want [add greet]", names)
+	}
+	if _, ok := r.Lookup("answer"); ok {
+		t.Fatal("Lookup found a name that failed to register")
+	}
+}
+
+func TestRegistryConcurrentUse(t *testing.T) {
+	r := NewRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			r.Register(fmt.Sprint("f", i%10), func() {})
+		}()
+		go func() {
+			defer wg.Done()
+			r.Names()
+			r.Lookup("f0")
+		}()
+	}
+	wg.Wait()
+	if len(r.Names()) != 10 {
+		t.Fatalf("registered %v, want each of the 10 names once", r.Names())
+	}
+}
+
+func TestRunByName(t *testing.T) {
+	tests := []struct {
+		name    string
+		skip    bool
+		calls   []NamedCall
+		want    string
+		wantErr []error
+		wantRan bool
+	}{
+		{"resolved", false, []NamedCall{{"double", []interface{}{2}}, {"double", []interface{}{5}}}, "[4 10]", nil, true},
+		{"unknown name fails before running", false, []NamedCall{{"double", []interface{}{2}}, {"triple", []interface{}{2}}}, "", []error{ErrUnknownFunction}, false},
+		{"every problem reported", false, []NamedCall{{"triple", nil}, {"double", []interface{}{"two"}}}, "", []error{ErrUnknownFunction, ErrArgTypeMismatch}, false},
+		{"unknown name skipped", true, []NamedCall{{"triple", []interface{}{2}}, {"double", []interface{}{3}}}, "[6]", nil, true},
+		{"bad arguments not skipped", true, []NamedCall{{"double", nil}}, "", []error{ErrArgCountMismatch}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			ran := false
+			r.Register("double", func(n int) int {
+				ran = true
+				return 2 * n
+			})
+			fh := NewHandler(WithLogger(&recordingLogger{}))
+			fh.SetRegistry(r)
+			fh.SetSkipUnknownNames(tt.skip)
+			results, res := fh.RunByName(func(err error) error { return err }, tt.calls)
+			for _, want := range tt.wantErr {
+				if !errors.Is(res.Err, want) {
+					t.Fatalf("RunByName = %v, want %v", res.Err, want)
+				}
+			}
+			if tt.wantErr == nil && (res.IsErr() || fmt.Sprint(results) != tt.want) {
+				t.Fatalf("RunByName = %v, %v; want %s", results, res.Err, tt.want)
+			}
+			if ran != tt.wantRan {
+				t.Fatalf("ran a function: %v, want %v", ran, tt.wantRan)
+			}
+		})
+	}
+}
+
+func TestRunByNameUsesDefaultRegistry(t *testing.T) {
+	name := "registry_test.square"
+	// the process-wide registry keeps the function when the test runs again
+	if err := Register(name, func(n int) int { return n * n }); err != nil && !errors.Is(err, ErrDuplicateName) {
+		t.Fatal(err)
+	}
+	fh := NewHandler()
+	results, res := fh.RunByName(func(err error) error { return err }, []NamedCall{{name, []interface{}{3}}})
+	if res.IsErr() || fmt.Sprint(results) != "[9]" {
+		t.Fatalf("RunByName = %v, %v; want [9]", results, res.Err)
+	}
+}
+
+func TestRegistrySchema(t *testing.T) {
+	r := NewRegistry()
+	r.Register("plain", func(a int, b string) {})
+	r.Register("injected", func(ctx context.Context, id int) error { return nil })
+	r.Register("variadic", func(sep string, parts ...string) string { return "" })
+	r.Register("none", func() {})
+	tests := []struct {
+		name   string
+		want   []reflect.Type
+		wantOk bool
+	}{
+		{"plain", []reflect.Type{reflect.TypeOf(0), reflect.TypeOf("")}, true},
+		{"injected", []reflect.Type{reflect.TypeOf(0)}, true},
+		{"variadic", []reflect.Type{reflect.TypeOf(""), reflect.TypeOf([]string(nil))}, true},
+		{"none", []reflect.Type{}, true},
+		{"missing", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := r.Schema(tt.name)
+			if ok != tt.wantOk || !slices.Equal(got, tt.want) {
+				t.Fatalf("Schema = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestTryNamed(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		failures int // calls of flaky failing before one succeeds
+		want     error
+	}{
+		{"retried with the handler's settings", []Option{WithRetries(2), WithBackoff(ConstantBackoff(0))}, 2, nil},
+		{"retries used up", []Option{WithRetries(1), WithBackoff(ConstantBackoff(0))}, 2, ErrRetryExhausted},
+		{"timed out", []Option{WithTimeout(10 * time.Millisecond)}, -1, ErrTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			calls := 0
+			r.Register("flaky", func(v string) (string, error) {
+				if tt.failures < 0 {
+					time.Sleep(100 * time.Millisecond)
+				}
+				if calls++; calls <= tt.failures {
+					return "", errBoom
+				}
+				return v, nil
+			})
+			fh := NewHandler(append(tt.opts, WithLogger(&recordingLogger{}))...)
+			fh.SetRegistry(r)
+			results, res := fh.TryNamed(func(err error) error { return err }, NamedCall{"flaky", []interface{}{"ok"}})
+			if !errors.Is(res.Err, tt.want) {
+				t.Fatalf("TryNamed = %v, want %v", res.Err, tt.want)
+			}
+			if tt.want == nil && fmt.Sprint(results) != "[ok]" {
+				t.Fatalf("TryNamed = %v, want [ok]", results)
+			}
+		})
+	}
+}
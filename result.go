@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Result struct to hold values or an error
+type Result[T any] struct {
+	Values []T
+	Err    error
+
+	info *ExecutionInfo // only set on the failed result of a run, for the error handler
+}
+
+// Ok function to create a Result with values
+func Ok[T any](values ...T) Result[T] {
+	return Result[T]{Values: values}
+}
+
+// Err function to create a Result with an error
+func Err[T any](err error) Result[T] {
+	return Result[T]{Err: err}
+}
+
+// Methods to check if the Result contains an error or values
+func (r Result[T]) IsOk() bool {
+	return r.Err == nil
+}
+
+func (r Result[T]) IsErr() bool {
+	return r.Err != nil
+}
+
+// Unwrap method to return the values and the error
+func (r Result[T]) Unwrap() ([]T, error) {
+	return r.Values, r.Err
+}
+
+// ToScalar method to convert a Result holding exactly one value, or an error, into a ScalarResult
+func (r Result[T]) ToScalar() (ScalarResult[T], error) {
+	if r.IsErr() {
+		return ErrOne[T](r.Err), nil
+	}
+	if len(r.Values) != 1 {
+		return ScalarResult[T]{}, fmt.Errorf("%w: got %d values", ErrNotScalar, len(r.Values))
+	}
+	return OkOne(r.Values[0]), nil
+}
+
+// Map method to apply f to every value of an Ok Result, passing an error through unchanged.
+// Use MapResult to change the value type. Like every method of Result it takes a value receiver so calls chain.
+func (r Result[T]) Map(f func(T) T) Result[T] {
+	return MapResult(r, f)
+}
+
+// AndThen method to pass the values of an Ok Result to f and return its Result, passing an error through unchanged.
+// Use FlatMap to change the value type.
+func (r Result[T]) AndThen(f func(values []T) Result[T]) Result[T] {
+	return FlatMap(r, f)
+}
+
+// UnwrapOr method to return the values, or fallback when the Result holds an error
+func (r Result[T]) UnwrapOr(fallback []T) []T {
+	if r.IsErr() {
+		return fallback
+	}
+	return r.Values
+}
+
+// UnwrapOrElse method to return the values, or the values f computes from the error
+func (r Result[T]) UnwrapOrElse(f func(err error) []T) []T {
+	if r.IsErr() {
+		return f(r.Err)
+	}
+	return r.Values
+}
+
+// MustUnwrap method to return the values, panicking with the error when the Result holds one
+func (r Result[T]) MustUnwrap() []T {
+	if r.IsErr() {
+		panic(r.Err)
+	}
+	return r.Values
+}
+
+// Expect method to return the values, panicking with the error wrapped in msg when the Result holds one
+func (r Result[T]) Expect(msg string) []T {
+	if r.IsErr() {
+		panic(fmt.Errorf("%s: %w", msg, r.Err))
+	}
+	return r.Values
+}
+
+// FlatMap function to pass the values of an Ok Result to f and return its Result, passing an error through unchanged
+func FlatMap[T, U any](r Result[T], f func(values []T) Result[U]) Result[U] {
+	if r.IsErr() {
+		return Err[U](r.Err)
+	}
+	return f(r.Values)
+}
+
+// MapResult function to apply f to every value of an Ok Result, passing an error through unchanged
+func MapResult[T, U any](r Result[T], f func(T) U) Result[U] {
+	if r.IsErr() {
+		return Err[U](r.Err)
+	}
+	values := make([]U, len(r.Values))
+	for i, value := range r.Values {
+		values[i] = f(value)
+	}
+	return Ok(values...)
+}
+
+// Flatten function to collapse a Result of Results. The outer error is returned as is; otherwise the values
+// of the inner Results are concatenated in order. If any inner Result holds an error, the inner errors are
+// joined with errors.Join and the values of the Ok inner Results are dropped.
+func Flatten[T any](r Result[Result[T]]) Result[T] {
+	if r.IsErr() {
+		return Err[T](r.Err)
+	}
+	var errs []error
+	values := []T{}
+	for _, inner := range r.Values {
+		if inner.IsErr() {
+			errs = append(errs, inner.Err)
+			continue
+		}
+		values = append(values, inner.Values...)
+	}
+	if len(errs) > 0 {
+		return Err[T](errors.Join(errs...))
+	}
+	return Ok(values...)
+}
+
+// Zip function to combine two Results: Ok with the values of a followed by those of b when both are Ok,
+// otherwise Err joining the errors that occurred with errors.Join
+func Zip[T, U any](a Result[T], b Result[U]) Result[any] {
+	if err := errors.Join(a.Err, b.Err); err != nil {
+		return Err[any](err)
+	}
+	values := make([]any, 0, len(a.Values)+len(b.Values))
+	for _, value := range a.Values {
+		values = append(values, value)
+	}
+	for _, value := range b.Values {
+		values = append(values, value)
+	}
+	return Ok(values...)
+}
+
+// CombineAll function to combine Results like Zip: Ok with all values in order when every Result is Ok,
+// otherwise Err joining every error. An Ok without values counts as a success.
+func CombineAll(rs ...Result[any]) Result[any] {
+	var errs []error
+	values := []any{}
+	for _, r := range rs {
+		if r.IsErr() {
+			errs = append(errs, r.Err)
+			continue
+		}
+		values = append(values, r.Values...)
+	}
+	if len(errs) > 0 {
+		return Err[any](errors.Join(errs...))
+	}
+	return Ok(values...)
+}
+
+// ScalarResult struct to hold a single value or an error
+type ScalarResult[T any] struct {
+	Value T
+	Err   error
+}
+
+// OkOne function to create a ScalarResult with a value
+func OkOne[T any](value T) ScalarResult[T] {
+	return ScalarResult[T]{Value: value}
+}
+
+// ErrOne function to create a ScalarResult with an error
+func ErrOne[T any](err error) ScalarResult[T] {
+	return ScalarResult[T]{Err: err}
+}
+
+// Methods to check if the ScalarResult contains an error or a value
+func (s ScalarResult[T]) IsOk() bool {
+	return s.Err == nil
+}
+
+func (s ScalarResult[T]) IsErr() bool {
+	return s.Err != nil
+}
+
+// Unwrap method to return the value and the error
+func (s ScalarResult[T]) Unwrap() (T, error) {
+	return s.Value, s.Err
+}
+
+// ToResult method to convert the ScalarResult into a Result with one value, or the error
+func (s ScalarResult[T]) ToResult() Result[T] {
+	if s.IsErr() {
+		return Err[T](s.Err)
+	}
+	return Ok(s.Value)
+}
+
+// Map method to apply f to the value of an Ok ScalarResult, passing an error through unchanged.
+// Use MapScalar to change the value type.
+func (s ScalarResult[T]) Map(f func(T) T) ScalarResult[T] {
+	return MapScalar(s, f)
+}
+
+// AndThen method to pass the value of an Ok ScalarResult to f and return its ScalarResult, passing an error
+// through unchanged. Use FlatMapScalar to change the value type.
+func (s ScalarResult[T]) AndThen(f func(value T) ScalarResult[T]) ScalarResult[T] {
+	return FlatMapScalar(s, f)
+}
+
+// UnwrapOr method to return the value, or fallback when the ScalarResult holds an error
+func (s ScalarResult[T]) UnwrapOr(fallback T) T {
+	if s.IsErr() {
+		return fallback
+	}
+	return s.Value
+}
+
+// UnwrapOrElse method to return the value, or the value f computes from the error
+func (s ScalarResult[T]) UnwrapOrElse(f func(err error) T) T {
+	if s.IsErr() {
+		return f(s.Err)
+	}
+	return s.Value
+}
+
+// MustUnwrap method to return the value, panicking with the error when the ScalarResult holds one
+func (s ScalarResult[T]) MustUnwrap() T {
+	if s.IsErr() {
+		panic(s.Err)
+	}
+	return s.Value
+}
+
+// Expect method to return the value, panicking with the error wrapped in msg when the ScalarResult holds one
+func (s ScalarResult[T]) Expect(msg string) T {
+	if s.IsErr() {
+		panic(fmt.Errorf("%s: %w", msg, s.Err))
+	}
+	return s.Value
+}
+
+// FlatMapScalar function to pass the value of an Ok ScalarResult to f and return its ScalarResult, passing an
+// error through unchanged
+func FlatMapScalar[T, U any](s ScalarResult[T], f func(value T) ScalarResult[U]) ScalarResult[U] {
+	if s.IsErr() {
+		return ErrOne[U](s.Err)
+	}
+	return f(s.Value)
+}
+
+// MapScalar function to apply f to the value of an Ok ScalarResult, passing an error through unchanged
+func MapScalar[T, U any](s ScalarResult[T], f func(T) U) ScalarResult[U] {
+	if s.IsErr() {
+		return ErrOne[U](s.Err)
+	}
+	return OkOne(f(s.Value))
+}
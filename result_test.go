@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestResultCombinators(t *testing.T) {
+	double := func(v int) int { return v * 2 }
+	sum := func(values []int) Result[int] {
+		total := 0
+		for _, v := range values {
+			total += v
+		}
+		return Ok(total)
+	}
+	tests := []struct {
+		name   string
+		result Result[int]
+		want   []int
+		err    error
+	}{
+		{"map", Ok(1, 2).Map(double), []int{2, 4}, nil},
+		{"map error", Err[int](errBoom).Map(double), nil, errBoom},
+		{"and then", Ok(1, 2).Map(double).AndThen(sum), []int{6}, nil},
+		{"and then error", Err[int](errBoom).AndThen(sum), nil, errBoom},
+		{"and then failing", Ok(1).AndThen(func([]int) Result[int] { return Err[int](errBoom) }), nil, errBoom},
+		{"map result", MapResult(Ok("1", "2"), func(s string) int { n, _ := strconv.Atoi(s); return n }), []int{1, 2}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := tt.result.Unwrap()
+			if !errors.Is(err, tt.err) || !slices.Equal(values, tt.want) {
+				t.Fatalf("got %v, %v; want %v, %v", values, err, tt.want, tt.err)
+			}
+			fallback := []int{-1}
+			want := tt.want
+			if tt.err != nil {
+				want = fallback
+			}
+			if got := tt.result.UnwrapOr(fallback); !slices.Equal(got, want) {
+				t.Fatalf("UnwrapOr = %v, want %v", got, want)
+			}
+			if got := tt.result.UnwrapOrElse(func(error) []int { return fallback }); !slices.Equal(got, want) {
+				t.Fatalf("UnwrapOrElse = %v, want %v", got, want)
+			}
+			requirePanic(t, tt.err, func() { tt.result.MustUnwrap() })
+			requirePanic(t, tt.err, func() { tt.result.Expect("summing") })
+		})
+	}
+}
+
+func TestScalarResultCombinators(t *testing.T) {
+	double := func(v int) int { return v * 2 }
+	positive := func(v int) ScalarResult[int] {
+		if v <= 0 {
+			return ErrOne[int](errBoom)
+		}
+		return OkOne(v)
+	}
+	tests := []struct {
+		name   string
+		result ScalarResult[int]
+		want   int
+		err    error
+	}{
+		{"map", OkOne(2).Map(double), 4, nil},
+		{"map error", ErrOne[int](errBoom).Map(double), 0, errBoom},
+		{"and then", OkOne(2).Map(double).AndThen(positive), 4, nil},
+		{"and then failing", OkOne(0).AndThen(positive), 0, errBoom},
+		{"and then error", ErrOne[int](errBoom).AndThen(positive), 0, errBoom},
+		{"flat map scalar", FlatMapScalar(OkOne("3"), func(s string) ScalarResult[int] { n, _ := strconv.Atoi(s); return OkOne(n) }), 3, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.result.IsErr() != (tt.err != nil) || tt.result.IsOk() == (tt.err != nil) {
+				t.Fatalf("IsErr = %v, want %v", tt.result.IsErr(), tt.err != nil)
+			}
+			value, err := tt.result.Unwrap()
+			if !errors.Is(err, tt.err) || value != tt.want {
+				t.Fatalf("got %v, %v; want %v, %v", value, err, tt.want, tt.err)
+			}
+			want := tt.want
+			if tt.err != nil {
+				want = -1
+			}
+			if got := tt.result.UnwrapOr(-1); got != want {
+				t.Fatalf("UnwrapOr = %v, want %v", got, want)
+			}
+			if got := tt.result.UnwrapOrElse(func(error) int { return -1 }); got != want {
+				t.Fatalf("UnwrapOrElse = %v, want %v", got, want)
+			}
+			requirePanic(t, tt.err, func() { tt.result.MustUnwrap() })
+			requirePanic(t, tt.err, func() { tt.result.Expect("checking") })
+			if res := tt.result.ToResult(); !errors.Is(res.Err, tt.err) || (tt.err == nil && !slices.Equal(res.Values, []int{tt.want})) {
+				t.Fatalf("ToResult = %v, %v; want %v, %v", res.Values, res.Err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+// requirePanic function to check that f panics with an error wrapping want, or does not panic when want is nil
+func requirePanic(t *testing.T, want error, f func()) {
+	t.Helper()
+	var recovered any
+	func() {
+		defer func() { recovered = recover() }()
+		f()
+	}()
+	if want == nil {
+		if recovered != nil {
+			t.Fatalf("panicked with %v, want no panic", recovered)
+		}
+		return
+	}
+	if err, ok := recovered.(error); !ok || !errors.Is(err, want) {
+		t.Fatalf("panicked with %v, want %v", recovered, want)
+	}
+}
+
+func TestZipAndCombineAll(t *testing.T) {
+	errOther := errors.New("other")
+	tests := []struct {
+		name   string
+		result Result[any]
+		want   []any
+		errs   []error
+	}{
+		{"zip both ok", Zip(Ok(1, 2), Ok("a")), []any{1, 2, "a"}, nil},
+		{"zip first failed", Zip(Err[int](errBoom), Ok("a")), nil, []error{errBoom}},
+		{"zip both failed", Zip(Err[int](errBoom), Err[string](errOther)), nil, []error{errBoom, errOther}},
+		{"combine all ok", CombineAll(Ok[any](1), Ok[any](), Ok[any]("a", "b")), []any{1, "a", "b"}, nil},
+		{"combine none", CombineAll(), []any{}, nil},
+		{"combine failures", CombineAll(Ok[any](1), Err[any](errBoom), Err[any](errOther)), nil, []error{errBoom, errOther}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, err := range tt.errs {
+				if !errors.Is(tt.result.Err, err) {
+					t.Fatalf("got %v, want it to wrap %v", tt.result.Err, err)
+				}
+			}
+			if tt.errs == nil && (tt.result.IsErr() || !slices.Equal(tt.result.Values, tt.want)) {
+				t.Fatalf("got %v, %v; want %v", tt.result.Values, tt.result.Err, tt.want)
+			}
+			if tt.errs != nil && tt.result.Values != nil {
+				t.Fatalf("got values %v with the error", tt.result.Values)
+			}
+		})
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	errOther := errors.New("other")
+	tests := []struct {
+		name   string
+		result Result[Result[int]]
+		want   []int
+		errs   []error
+	}{
+		{"inner values in order", Ok(Ok(1, 2), Ok[int](), Ok(3)), []int{1, 2, 3}, nil},
+		{"no inner results", Ok[Result[int]](), []int{}, nil},
+		{"outer error", Err[Result[int]](errBoom), nil, []error{errBoom}},
+		{"inner errors joined, values dropped", Ok(Ok(1), Err[int](errBoom), Err[int](errOther)), nil, []error{errBoom, errOther}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Flatten(tt.result)
+			for _, err := range tt.errs {
+				if !errors.Is(res.Err, err) {
+					t.Fatalf("got %v, want it to wrap %v", res.Err, err)
+				}
+			}
+			if tt.errs == nil && (res.IsErr() || !slices.Equal(res.Values, tt.want)) {
+				t.Fatalf("got %v, %v; want %v", res.Values, res.Err, tt.want)
+			}
+			if tt.errs != nil && res.Values != nil {
+				t.Fatalf("got values %v with the error", res.Values)
+			}
+		})
+	}
+}
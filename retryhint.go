@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RetryAfterError struct to hold a failure together with how long to wait before retrying it
+type RetryAfterError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("%v (retry after %s)", e.Err, e.After)
+}
+
+// Unwrap method to expose the failure
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter function to return err with a hint that the next attempt should wait d instead of the backoff,
+// such as a server's Retry-After header. A hint of zero or less is ignored.
+func RetryAfter(err error, d time.Duration) error {
+	return &RetryAfterError{Err: err, After: d}
+}
+
+// retryHint function to return the wait hinted by a RetryAfterError in err
+func retryHint(err error) (time.Duration, bool) {
+	var hint *RetryAfterError
+	if errors.As(err, &hint) && hint.After > 0 {
+		return hint.After, true
+	}
+	return 0, false
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+)
+
+// Scan method to assign each value to the matching destination pointer, like database/sql's Rows.Scan.
+// A value must be assignable or convertible to the destination's type. A conversion must keep the value: a
+// number must fit the destination exactly and a slice converted to an array must have the array's length. A
+// nil value leaves the destination at its zero value unless SetScanNilError is enabled. The counts of values
+// and destinations must match.
+func (fhi *FunctionHandlerImpl) Scan(values []any, dests ...any) error {
+	if len(values) != len(dests) {
+		err := fhi.errorf("%w: got %d values for %d destinations", ErrScanMismatch, len(values), len(dests))
+		fhi.LogError(err)
+		return err
+	}
+	for i, dest := range dests {
+		destValue := reflect.ValueOf(dest)
+		if destValue.Kind() != reflect.Pointer || destValue.IsNil() {
+			err := fhi.errorf("%w: destination %d is %T, want a non-nil pointer", ErrScanMismatch, i, dest)
+			fhi.LogError(err)
+			return err
+		}
+		target := destValue.Elem()
+		if values[i] == nil {
+			if fhi.settings().scanNilError {
+				err := fhi.errorf("%w: value %d is nil, want %s", ErrScanMismatch, i, target.Type())
+				fhi.LogError(err)
+				return err
+			}
+			target.SetZero()
+			continue
+		}
+		value := reflect.ValueOf(values[i])
+		if value.Type().AssignableTo(target.Type()) {
+			target.Set(value)
+			continue
+		}
+		if !convertible(value.Type(), target.Type()) {
+			err := fhi.errorf("%w: value %d is %T, want %s", ErrScanMismatch, i, values[i], target.Type())
+			fhi.LogError(err)
+			return err
+		}
+		if err := checkConversion(value, target.Type()); err != nil {
+			err = fhi.errorf("%w: value %d: %w", ErrScanMismatch, i, err)
+			fhi.LogError(err)
+			return err
+		}
+		target.Set(value.Convert(target.Type()))
+	}
+	return nil
+}
+
+// SetScanNilError method to make Scan fail on a nil value instead of zeroing the destination
+func (fhi *FunctionHandlerImpl) SetScanNilError(nilError bool) {
+	fhi.configure(func(s *settings) { s.scanNilError = nilError })
+}
+
+// convertible function to report whether from converts to to without changing the value's meaning.
+// Integers convert to strings in Go as runes, which is never what a scan wants.
+func convertible(from, to reflect.Type) bool {
+	if to.Kind() == reflect.String && from.Kind() != reflect.String {
+		switch from.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return false
+		}
+	}
+	return from.ConvertibleTo(to)
+}
+
+// checkConversion function to report why converting value to to would panic or lose information.
+// value must be convertible to to.
+func checkConversion(value reflect.Value, to reflect.Type) error {
+	from := value.Type()
+	if from.Kind() == reflect.Slice {
+		n := -1
+		switch {
+		case to.Kind() == reflect.Array:
+			n = to.Len()
+		case to.Kind() == reflect.Pointer && to.Elem().Kind() == reflect.Array:
+			n = to.Elem().Len()
+		}
+		if n >= 0 && value.Len() != n {
+			return fmt.Errorf("%d elements do not fit %s", value.Len(), to)
+		}
+		return nil
+	}
+	if !numeric(from.Kind()) || !numeric(to.Kind()) {
+		return nil
+	}
+	var exact *big.Float
+	switch {
+	case isInt(from.Kind()):
+		exact = new(big.Float).SetInt64(value.Int())
+	case isUint(from.Kind()):
+		exact = new(big.Float).SetUint64(value.Uint())
+	default:
+		f := value.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			if isFloat(to.Kind()) {
+				return nil // both float types represent them
+			}
+			return fmt.Errorf("%v does not fit %s", f, to)
+		}
+		exact = new(big.Float).SetFloat64(f)
+	}
+	converted := value.Convert(to)
+	var got *big.Float
+	switch {
+	case isInt(to.Kind()):
+		got = new(big.Float).SetInt64(converted.Int())
+	case isUint(to.Kind()):
+		got = new(big.Float).SetUint64(converted.Uint())
+	default:
+		got = new(big.Float).SetFloat64(converted.Float())
+	}
+	if got.Cmp(exact) != 0 {
+		return fmt.Errorf("%v does not fit %s", value, to)
+	}
+	return nil
+}
+
+// numeric function to report whether kind is an integer or floating-point kind
+func numeric(kind reflect.Kind) bool {
+	return isInt(kind) || isUint(kind) || isFloat(kind)
+}
+
+// isInt function to report whether kind is a signed integer kind
+func isInt(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Int64
+}
+
+// isUint function to report whether kind is an unsigned integer kind
+func isUint(kind reflect.Kind) bool {
+	return kind >= reflect.Uint && kind <= reflect.Uintptr
+}
+
+// isFloat function to report whether kind is a floating-point kind
+func isFloat(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
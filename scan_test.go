@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	type userID int64
+	tests := []struct {
+		name    string
+		value   any
+		dest    any // a pointer to a zero destination
+		want    any // the destination's value after the scan, unused when wantErr is set
+		wantErr bool
+	}{
+		{"assignable", 42, new(int), 42, false},
+		{"named type", int64(7), new(userID), userID(7), false},
+		{"widening", int32(-5), new(int64), int64(-5), false},
+		{"narrowing that fits", int64(200), new(uint8), uint8(200), false},
+		{"narrowing overflow", int64(300), new(uint8), nil, true},
+		{"negative to unsigned", -1, new(uint), nil, true},
+		{"unsigned overflowing signed", uint64(math.MaxUint64), new(int64), nil, true},
+		{"whole float to int", 3.0, new(int), 3, false},
+		{"fractional float to int", 3.5, new(int), nil, true},
+		{"NaN to int", math.NaN(), new(int), nil, true},
+		{"exact float narrowing", 0.5, new(float32), float32(0.5), false},
+		{"lossy float narrowing", 0.1, new(float32), nil, true},
+		{"int to float beyond precision", int64(1<<53 + 1), new(float64), nil, true},
+		{"int to string", 65, new(string), nil, true},
+		{"slice to array", []int{1, 2, 3}, new([3]int), [3]int{1, 2, 3}, false},
+		{"short slice to array", []int{1, 2}, new([3]int), nil, true},
+		{"long slice to array", []int{1, 2, 3, 4}, new([3]int), nil, true},
+		{"short slice to array pointer", []int{1}, new(*[2]int), nil, true},
+		{"unrelated type", "x", new(int), nil, true},
+	}
+	fh := NewHandler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fh.Scan([]any{tt.value}, tt.dest)
+			if tt.wantErr {
+				if !errors.Is(err, ErrScanMismatch) {
+					t.Fatalf("Scan = %v, want %v", err, ErrScanMismatch)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := reflect.ValueOf(tt.dest).Elem().Interface(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("scanned %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanNil(t *testing.T) {
+	fh := NewHandler()
+	n := 5
+	if err := fh.Scan([]any{nil}, &n); err != nil || n != 0 {
+		t.Fatalf("Scan(nil) = %v with %d, want the zero value", err, n)
+	}
+	fh.SetScanNilError(true)
+	if err := fh.Scan([]any{nil}, &n); !errors.Is(err, ErrScanMismatch) {
+		t.Fatalf("Scan(nil) = %v, want %v", err, ErrScanMismatch)
+	}
+	if err := fh.Scan([]any{1, 2}, &n); !errors.Is(err, ErrScanMismatch) {
+		t.Fatalf("Scan with a count mismatch = %v, want %v", err, ErrScanMismatch)
+	}
+}
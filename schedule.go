@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EntryID identifies a batch scheduled with a Scheduler
+type EntryID int
+
+// ScheduleMetrics interface that a Metrics implementation can also implement to observe scheduled runs
+type ScheduleMetrics interface {
+	// ScheduledRun reports a run of a scheduled batch and how long it took. A run skipped because the
+	// previous one was still running is reported with OutcomeSkipped.
+	ScheduledRun(handler string, entry EntryID, outcome Outcome, took time.Duration)
+}
+
+// Scheduler struct to run batches of the handler on a schedule
+type Scheduler struct {
+	fhi     *FunctionHandlerImpl
+	mu      sync.Mutex
+	entries map[EntryID]*scheduleEntry
+	lastID  EntryID
+	wake    chan struct{}
+	stop    context.CancelFunc
+	running sync.WaitGroup
+}
+
+// scheduleEntry struct to hold a scheduled batch and when it runs next
+type scheduleEntry struct {
+	every   time.Duration
+	handler interface{}
+	funcs   []func() Result[any]
+	next    time.Time
+	busy    bool
+}
+
+// NewScheduler method to create a scheduler whose batches run with this handler's settings and clock
+func (fhi *FunctionHandlerImpl) NewScheduler() *Scheduler {
+	return &Scheduler{fhi: fhi, entries: map[EntryID]*scheduleEntry{}, wake: make(chan struct{}, 1)}
+}
+
+// scheduleSpecs maps the predefined specs to their interval
+var scheduleSpecs = map[string]time.Duration{
+	"@hourly": time.Hour,
+	"@daily":  24 * time.Hour,
+	"@weekly": 7 * 24 * time.Hour,
+}
+
+// parseSchedule function to parse a spec of the form "@every 5m" or one of the predefined specs
+func parseSchedule(spec string) (time.Duration, error) {
+	if every, ok := scheduleSpecs[spec]; ok {
+		return every, nil
+	}
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q: %w", ErrInvalidSchedule, spec, err)
+		}
+		if every <= 0 {
+			return 0, fmt.Errorf("%w: %q is not positive", ErrInvalidSchedule, spec)
+		}
+		return every, nil
+	}
+	return 0, fmt.Errorf("%w: %q, want @every <duration>, @hourly, @daily or @weekly", ErrInvalidSchedule, spec)
+}
+
+// Schedule method to run the functions as one batch with the error handler at every interval of spec, the
+// first time one interval from now. A run is skipped when the previous run of the entry is still going.
+func (s *Scheduler) Schedule(spec string, handler interface{}, funcs ...func() Result[any]) (EntryID, error) {
+	every, err := parseSchedule(spec)
+	if err == nil && len(funcs) == 0 {
+		err = ErrNoFunctions
+	}
+	if err != nil {
+		err = s.fhi.errorf("%w", err)
+		s.fhi.LogError(err)
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastID++
+	s.entries[s.lastID] = &scheduleEntry{every: every, handler: handler, funcs: funcs, next: s.fhi.getClock().Now().Add(every)}
+	s.notify()
+	return s.lastID, nil
+}
+
+// Remove method to unschedule an entry; a run in progress is not interrupted
+func (s *Scheduler) Remove(id EntryID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, id)
+	s.notify()
+}
+
+// Start method to run the schedule in the background until ctx is cancelled or Stop is called.
+// Cancelling ctx also cancels the batches that are running.
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	if s.stop != nil {
+		s.stop()
+	}
+	s.stop = cancel
+	s.mu.Unlock()
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		s.loop(ctx)
+	}()
+}
+
+// Stop method to stop the schedule and wait for the batches that are running to return
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if s.stop != nil {
+		s.stop()
+		s.stop = nil
+	}
+	s.mu.Unlock()
+	s.running.Wait()
+}
+
+// notify method to wake the loop so it sees a changed schedule; s.mu must be held
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// loop method to wait for the next due entry and run every entry that is due, until ctx is done
+func (s *Scheduler) loop(ctx context.Context) {
+	clock := s.fhi.getClock()
+	for {
+		s.mu.Lock()
+		now := clock.Now()
+		var next time.Time
+		for id, e := range s.entries {
+			if !e.next.After(now) {
+				s.fire(ctx, id, e)
+				for !e.next.After(now) {
+					e.next = e.next.Add(e.every) // runs missed while the loop was busy are not caught up
+				}
+			}
+			if next.IsZero() || e.next.Before(next) {
+				next = e.next
+			}
+		}
+		s.mu.Unlock()
+		var timer <-chan time.Time
+		if !next.IsZero() {
+			timer = clock.After(next.Sub(now))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-timer:
+		}
+	}
+}
+
+// fire method to start a run of an entry unless its previous run is still going; s.mu must be held
+func (s *Scheduler) fire(ctx context.Context, id EntryID, e *scheduleEntry) {
+	cfg := s.fhi.settings()
+	metrics, _ := s.fhi.getMetrics().(ScheduleMetrics)
+	if e.busy {
+		s.fhi.logWarn("scheduled batch %d skipped, its previous run is still going", id)
+		if metrics != nil {
+			metrics.ScheduledRun(cfg.name, id, OutcomeSkipped, 0)
+		}
+		return
+	}
+	e.busy = true
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		start := s.fhi.getClock().Now()
+		res := s.run(ctx, e)
+		if metrics != nil {
+			metrics.ScheduledRun(cfg.name, id, outcomeOf(ctx, res), s.fhi.getClock().Now().Sub(start))
+		}
+		s.mu.Lock()
+		e.busy = false
+		s.mu.Unlock()
+	}()
+}
+
+// run method to run the batch of an entry like Group.Run
+func (s *Scheduler) run(ctx context.Context, e *scheduleEntry) Result[any] {
+	release, err := s.fhi.acquireBatch(ctx)
+	if err != nil {
+		s.fhi.LogError(err)
+		return Err[any](err)
+	}
+	defer release()
+	_, res := s.fhi.tryFuncs(ctx, e.handler, e.funcs)
+	return res
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scheduleMetrics struct to record the outcomes of scheduled runs
+type scheduleMetrics struct {
+	countingMetrics
+	mu       sync.Mutex
+	outcomes []Outcome
+}
+
+func (m *scheduleMetrics) ScheduledRun(handler string, entry EntryID, outcome Outcome, took time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.outcomes = append(m.outcomes, outcome)
+}
+
+func (m *scheduleMetrics) count(outcome Outcome) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, o := range m.outcomes {
+		if o == outcome {
+			n++
+		}
+	}
+	return n
+}
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"@every 5m", 5 * time.Minute, false},
+		{"@every  90s ", 90 * time.Second, false},
+		{"@hourly", time.Hour, false},
+		{"@daily", 24 * time.Hour, false},
+		{"@weekly", 7 * 24 * time.Hour, false},
+		{"@every 0s", 0, true},
+		{"@every -1m", 0, true},
+		{"@every soon", 0, true},
+		{"*/5 * * * *", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parseSchedule(tt.spec)
+			if (err != nil) != tt.wantErr || (tt.wantErr && !errors.Is(err, ErrInvalidSchedule)) || got != tt.want {
+				t.Fatalf("parseSchedule = %s, %v; want %s, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	tests := []struct {
+		name        string
+		work        time.Duration // how long every run takes
+		wantRuns    int           // at least, in the 100ms the schedule runs
+		wantSkipped bool
+	}{
+		{"runs at every interval", 0, 5, false},
+		{"skips runs while the previous one is going", 35 * time.Millisecond, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler()
+			metrics := &scheduleMetrics{}
+			fh.SetMetrics(metrics)
+			s := fh.NewScheduler()
+			var runs atomic.Int32
+			if _, err := s.Schedule("@every 10ms", func(err error) error { return err }, fh.WrapFunction(func() {
+				runs.Add(1)
+				time.Sleep(tt.work)
+			})); err != nil {
+				t.Fatalf("Schedule = %v", err)
+			}
+			before := runtime.NumGoroutine()
+			s.Start(context.Background())
+			time.Sleep(100 * time.Millisecond)
+			s.Stop()
+			stopped := runs.Load()
+			time.Sleep(30 * time.Millisecond)
+			if runs.Load() != stopped {
+				t.Fatal("the schedule ran after Stop")
+			}
+			if int(stopped) < tt.wantRuns || int(stopped) > 10 {
+				t.Fatalf("ran %d times in 100ms every 10ms, want at least %d", stopped, tt.wantRuns)
+			}
+			if skipped := metrics.count(OutcomeSkipped) > 0; skipped != tt.wantSkipped {
+				t.Fatalf("runs skipped: %v, want %v", skipped, tt.wantSkipped)
+			}
+			// a run going or starting when Stop cancels the schedule is reported with the cancel's outcome
+			if succeeded := metrics.count(OutcomeSuccess); succeeded < int(stopped)-1 || succeeded > int(stopped) {
+				t.Fatalf("reported %d successful runs, want the %d runs", succeeded, stopped)
+			}
+			requireGoroutines(t, before)
+		})
+	}
+}
+
+func TestSchedulerRemove(t *testing.T) {
+	fh := NewHandler()
+	s := fh.NewScheduler()
+	var kept, removed atomic.Int32
+	pass := func(err error) error { return err }
+	if _, err := s.Schedule("@every 5ms", pass, fh.WrapFunction(func() { kept.Add(1) })); err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.Schedule("@every 5ms", pass, fh.WrapFunction(func() { removed.Add(1) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Remove(id)
+	s.Start(context.Background())
+	time.Sleep(30 * time.Millisecond)
+	s.Stop()
+	if kept.Load() == 0 || removed.Load() != 0 {
+		t.Fatalf("kept entry ran %d times and removed one %d, want some and none", kept.Load(), removed.Load())
+	}
+	if _, err := s.Schedule("@every 5ms", pass); !errors.Is(err, ErrNoFunctions) {
+		t.Fatalf("Schedule without functions = %v, want %v", err, ErrNoFunctions)
+	}
+}
@@ -0,0 +1,40 @@
+package handler
+
+import "context"
+
+// Settled struct to report how one function of a TryAll batch ended: its values, or its error, and how the
+// run went
+type Settled struct {
+	ExecutionInfo
+	Values []any
+	Err    error
+}
+
+// TryAll method to run the functions concurrently until every one of them has settled, and report each one in
+// order. No error handler is involved and no failure stops the others; the error is only set when the batch
+// cannot start, such as when there are no functions.
+func (fhi *FunctionHandlerImpl) TryAll(funcs ...func() Result[any]) ([]Settled, error) {
+	release, err := fhi.acquireBatch(context.Background())
+	if err != nil {
+		fhi.LogError(err)
+		return nil, err
+	}
+	defer release()
+	if len(funcs) == 0 {
+		err = fhi.errorf("%w", ErrNoFunctions)
+	} else {
+		err = fhi.checkRetryBound(context.Background())
+	}
+	if err != nil {
+		fhi.LogError(err)
+		return nil, err
+	}
+	ctx := fhi.withBatchID(context.Background())
+	settled := make([]Settled, len(funcs))
+	wait := fhi.dispatch(funcs, nil, func(i int, fn func() Result[any]) {
+		res, _ := fhi.runStaggered(ctx, i, fn, nil) // ctx never ends, so every function runs or is skipped
+		settled[i] = Settled{ExecutionInfo: executionInfo(i, res), Values: res.Values, Err: res.Err}
+	})
+	wait()
+	return settled, nil
+}
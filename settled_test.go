@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTryAll(t *testing.T) {
+	fh := NewHandler(WithMode(ModeFailFast), WithRetries(1), WithBackoff(ConstantBackoff(0)), WithTimeout(20*time.Millisecond))
+	tests := []struct {
+		name         string
+		fn           func() Result[any]
+		want         []any
+		wantErr      error
+		wantAttempts int
+	}{
+		{"success", fh.WrapNamed("ok", func() int { return 1 }), []any{1}, nil, 1},
+		{"failure after its retry", fh.WrapNamed("fail", func() error { return errBoom }), nil, errBoom, 2},
+		{"timeout", fh.WrapNamed("stuck", func(done <-chan struct{}) { <-done }), nil, ErrTimeout, 1},
+		{"slow success after the failure", fh.WrapNamed("slow", func() int { time.Sleep(5 * time.Millisecond); return 2 }), []any{2}, nil, 1},
+	}
+	funcs := make([]func() Result[any], len(tests))
+	for i, tt := range tests {
+		funcs[i] = tt.fn
+	}
+	settled, err := fh.TryAll(funcs...)
+	if err != nil || len(settled) != len(tests) {
+		t.Fatalf("TryAll = %d settled, %v; want %d, no error", len(settled), err, len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := settled[i]
+			if !errors.Is(s.Err, tt.wantErr) || (tt.wantErr == nil && !slices.Equal(s.Values, tt.want)) {
+				t.Fatalf("settled %v, %v; want %v, %v", s.Values, s.Err, tt.want, tt.wantErr)
+			}
+			if s.Index != i || s.Attempts != tt.wantAttempts || s.TimedOut != errors.Is(tt.wantErr, ErrTimeout) {
+				t.Fatalf("settled as %+v, want index %d after %d attempts", s.ExecutionInfo, i, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestTryAllNoFunctions(t *testing.T) {
+	if settled, err := NewHandler().TryAll(); !errors.Is(err, ErrNoFunctions) || settled != nil {
+		t.Fatalf("TryAll = %v, %v; want %v", settled, err, ErrNoFunctions)
+	}
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// forceKey is the context key under which NotifyStop stores the channel closed by a second signal
+type forceKey struct{}
+
+// NotifyStop function to derive a context that is cancelled with ErrInterrupted when one of sigs arrives,
+// for batches run by TryChan or Group.Run. The batch then stops starting functions, cancels the running ones
+// and waits for them to return so their deferred cleanup runs; a second signal makes it return at once.
+// Without sigs every incoming signal counts, as with signal.Notify. Call stop to release the signals.
+func NotifyStop(parent context.Context, sigs ...os.Signal) (ctx context.Context, stop context.CancelFunc) {
+	force := make(chan struct{})
+	ctx, cancel := context.WithCancelCause(context.WithValue(parent, forceKey{}, (<-chan struct{})(force)))
+	ch := make(chan os.Signal, 2)
+	signal.Notify(ch, sigs...)
+	stopped := make(chan struct{})
+	go func() {
+		defer signal.Stop(ch)
+		select {
+		case sig := <-ch:
+			cancel(fmt.Errorf("%w: %s", ErrInterrupted, sig))
+		case <-ctx.Done():
+			return
+		case <-stopped:
+			return
+		}
+		select {
+		case <-ch:
+			close(force)
+		case <-stopped:
+		}
+	}()
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			close(stopped)
+			cancel(context.Canceled)
+		})
+	}
+}
+
+// forceOf function to return the channel closed by a second NotifyStop signal, or nil outside NotifyStop
+func forceOf(ctx context.Context) <-chan struct{} {
+	force, _ := ctx.Value(forceKey{}).(<-chan struct{})
+	return force
+}
+
+// runInterruptible method to run fn, giving up with finished false when a second NotifyStop signal arrives
+func (fhi *FunctionHandlerImpl) runInterruptible(ctx context.Context, fn func() Result[any]) (res Result[any], finished bool) {
+	force := forceOf(ctx)
+	if force == nil {
+		return fhi.runFunction(ctx, fn), true
+	}
+	ch := make(chan Result[any], 1)
+	go func() {
+		ch <- fhi.runFunction(ctx, fn)
+	}()
+	select {
+	case res := <-ch:
+		return res, true
+	case <-force:
+		return Result[any]{}, false
+	}
+}
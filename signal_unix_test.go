@@ -0,0 +1,73 @@
+//go:build unix
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestNotifyStop(t *testing.T) {
+	tests := []struct {
+		name        string
+		signals     int
+		honourCtx   bool // the function returns once its context is cancelled, after its cleanup
+		want        error
+		wantCleanup bool
+	}{
+		{"a signal waits for the cleanup", 1, true, ErrInterrupted, true},
+		{"a second signal returns at once", 2, false, ErrInterrupted, false},
+		{"stop without a signal", 0, true, context.Canceled, true},
+	}
+	for _, mode := range []ExecutionMode{ModeSequential, ModeParallel} {
+		for _, tt := range tests {
+			t.Run(mode.String()+"/"+tt.name, func(t *testing.T) {
+				fh := NewHandler(WithMode(mode))
+				ctx, stop := NotifyStop(context.Background(), syscall.SIGUSR1)
+				defer stop()
+				started, release := make(chan struct{}), make(chan struct{})
+				defer close(release)
+				var cleaned atomic.Bool
+				in := make(chan func() Result[any], 1)
+				in <- fh.WrapFunction(func(ctx context.Context) error {
+					close(started)
+					if tt.honourCtx {
+						<-ctx.Done()
+					} else {
+						<-release
+					}
+					time.Sleep(20 * time.Millisecond) // the function's cleanup
+					cleaned.Store(true)
+					return ctx.Err()
+				})
+				close(in)
+				go func() {
+					<-started
+					if tt.signals == 0 {
+						stop()
+					}
+					for i := 0; i < tt.signals; i++ {
+						syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+						time.Sleep(20 * time.Millisecond) // the signal is handled before the next one
+					}
+				}()
+				start := time.Now()
+				_, res := fh.TryChan(ctx, func(err error) error { return err }, in)
+				if !errors.Is(res.Err, tt.want) {
+					t.Fatalf("TryChan = %v, want %v", res.Err, tt.want)
+				}
+				if cleaned.Load() != tt.wantCleanup {
+					t.Fatalf("the function's cleanup ran: %v, want %v", cleaned.Load(), tt.wantCleanup)
+				}
+				if took := time.Since(start); took > time.Second {
+					t.Fatalf("TryChan took %s after the signals", took)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Package sqlhandler runs handler batches inside database/sql transactions.
+package sqlhandler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	handler "github.com/Spongebob959/handler"
+)
+
+// ErrParallelTx is returned when a transaction batch is run by a handler in a concurrent mode,
+// because a *sql.Tx must not be used by several goroutines at once
+var ErrParallelTx = errors.New("transaction batches must run sequentially")
+
+// Option type to configure how transaction batches run
+type Option func(*Runner)
+
+// Retries function to run a failed batch up to n more times, each time in a fresh transaction
+func Retries(n int) Option {
+	return func(r *Runner) {
+		r.retries = n
+	}
+}
+
+// TxOptions function to set the options the transactions are started with
+func TxOptions(opts *sql.TxOptions) Option {
+	return func(r *Runner) {
+		r.txOptions = opts
+	}
+}
+
+// Runner struct to run batches of a handler inside transactions of a database
+type Runner struct {
+	fh        handler.FunctionHandler
+	db        *sql.DB
+	retries   int
+	txOptions *sql.TxOptions
+}
+
+// New function to create a runner for batches of fh against db
+func New(fh handler.FunctionHandler, db *sql.DB, opts ...Option) *Runner {
+	r := &Runner{fh: fh, db: db}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// TryTx method to begin a transaction, build the batch's functions from it and run them sequentially
+// with the error handler. The transaction is committed when the batch succeeds and rolled back when it
+// fails or panics; a rollback or commit error is joined to the batch error.
+func (r *Runner) TryTx(ctx context.Context, errorHandler interface{}, build func(tx *sql.Tx) []func() handler.Result[any]) ([]any, handler.Result[any]) {
+	if mode := r.fh.Mode(); mode != handler.ModeSequential {
+		return nil, handler.Err[any](fmt.Errorf("%w, handler mode is %s", ErrParallelTx, mode))
+	}
+	var results []any
+	var res handler.Result[any]
+	for i := 0; i <= r.retries; i++ {
+		if results, res = r.tryTx(ctx, errorHandler, build); res.IsOk() || ctx.Err() != nil {
+			break
+		}
+	}
+	return results, res
+}
+
+// tryTx method to run the batch once in a fresh transaction
+func (r *Runner) tryTx(ctx context.Context, errorHandler interface{}, build func(tx *sql.Tx) []func() handler.Result[any]) (results []any, res handler.Result[any]) {
+	tx, err := r.db.BeginTx(ctx, r.txOptions)
+	if err != nil {
+		return nil, handler.Err[any](fmt.Errorf("begin transaction: %w", err))
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+	funcs := build(tx)
+	in := make(chan func() handler.Result[any], len(funcs))
+	for _, fn := range funcs {
+		in <- fn
+	}
+	close(in)
+	results, res = r.fh.TryChan(ctx, errorHandler, in)
+	if res.IsErr() {
+		if err := tx.Rollback(); err != nil {
+			return results, handler.Err[any](errors.Join(res.Err, fmt.Errorf("rollback: %w", err)))
+		}
+		return results, res
+	}
+	if err := tx.Commit(); err != nil {
+		return results, handler.Err[any](fmt.Errorf("commit: %w", err))
+	}
+	return results, res
+}
@@ -0,0 +1,157 @@
+package sqlhandler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	handler "github.com/Spongebob959/handler"
+)
+
+var errBoom = errors.New("boom")
+
+// fakeDB struct to record the transactions and statements of a fake database/sql driver
+type fakeDB struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (db *fakeDB) record(event string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.events = append(db.events, event)
+}
+
+func (db *fakeDB) log() string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return strings.Join(db.events, ", ")
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{db}, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return fakeDriver{db} }
+
+type fakeDriver struct{ db *fakeDB }
+
+func (d fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{d.db}, nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) {
+	c.db.record("begin")
+	return fakeTx{c.db}, nil
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.db.record(s.query)
+	return driver.RowsAffected(1), nil
+}
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("queries are not supported")
+}
+
+type fakeTx struct{ db *fakeDB }
+
+func (tx fakeTx) Commit() error {
+	tx.db.record("commit")
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.db.record("rollback")
+	return nil
+}
+
+// exec function to wrap a statement run in tx, failing with fail when it is set
+func exec(fh handler.FunctionHandler, tx *sql.Tx, query string, fail error) func() handler.Result[any] {
+	return fh.WrapFunction(func(ctx context.Context) error {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+		return fail
+	})
+}
+
+func TestTryTx(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    handler.ExecutionMode
+		retries int
+		// fails lists the attempts, counting from 1, whose second statement fails
+		fails   map[int]bool
+		wantErr error
+		wantLog string
+	}{
+		{"commit on success", handler.ModeSequential, 0, nil, nil, "begin, insert, update, commit"},
+		{"rollback on failure", handler.ModeSequential, 0, map[int]bool{1: true}, errBoom, "begin, insert, update, rollback"},
+		{"fresh transaction per retry", handler.ModeSequential, 2, map[int]bool{1: true, 2: true}, nil,
+			"begin, insert, update, rollback, begin, insert, update, rollback, begin, insert, update, commit"},
+		{"retries used up", handler.ModeSequential, 1, map[int]bool{1: true, 2: true}, errBoom,
+			"begin, insert, update, rollback, begin, insert, update, rollback"},
+		{"parallel rejected", handler.ModeParallel, 0, nil, ErrParallelTx, ""},
+		{"fail-fast rejected", handler.ModeFailFast, 0, nil, ErrParallelTx, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{}
+			conn := sql.OpenDB(db)
+			defer conn.Close()
+			fh := handler.NewHandler(handler.WithMode(tt.mode))
+			attempt := 0
+			var txs []*sql.Tx
+			_, res := New(fh, conn, Retries(tt.retries)).TryTx(context.Background(), func(err error) error { return err },
+				func(tx *sql.Tx) []func() handler.Result[any] {
+					attempt++
+					txs = append(txs, tx)
+					var fail error
+					if tt.fails[attempt] {
+						fail = errBoom
+					}
+					return []func() handler.Result[any]{exec(fh, tx, "insert", nil), exec(fh, tx, "update", fail)}
+				})
+			if !errors.Is(res.Err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", res.Err, tt.wantErr)
+			}
+			if got := db.log(); got != tt.wantLog {
+				t.Fatalf("database saw %q, want %q", got, tt.wantLog)
+			}
+			for i := 1; i < len(txs); i++ {
+				if txs[i] == txs[i-1] {
+					t.Fatalf("attempt %d reused the transaction of the attempt before", i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestTryTxPanicInBuild(t *testing.T) {
+	db := &fakeDB{}
+	conn := sql.OpenDB(db)
+	defer conn.Close()
+	fh := handler.NewHandler()
+	defer func() {
+		if r := recover(); fmt.Sprint(r) != "build failed" {
+			t.Fatalf("recovered %v, want the panic of build", r)
+		}
+		if got, want := db.log(), "begin, rollback"; got != want {
+			t.Fatalf("database saw %q, want %q", got, want)
+		}
+	}()
+	New(fh, conn).TryTx(context.Background(), func(err error) error { return err },
+		func(tx *sql.Tx) []func() handler.Result[any] { panic("build failed") })
+	t.Fatal("TryTx did not panic")
+}
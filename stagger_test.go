@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock struct to record the delays waited for through the clock, firing them at once
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	delays []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.delays = append(c.delays, d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now.Add(d)
+	return ch
+}
+
+// advance method to move the clock forward, as if d passed
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func (c *fakeClock) waited() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delays := append([]time.Duration(nil), c.delays...)
+	sort.Slice(delays, func(i, j int) bool { return delays[i] < delays[j] })
+	return delays
+}
+
+func TestStagger(t *testing.T) {
+	const step = 100 * time.Millisecond
+	tests := []struct {
+		name   string
+		d      time.Duration
+		jitter float64
+	}{
+		{"off", 0, 0},
+		{"spaced", step, 0},
+		{"with jitter", step, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Now()}
+			fh := NewHandler(WithMode(ModeParallel))
+			fh.SetClock(clock)
+			fh.SetStagger(tt.d, tt.jitter)
+			funcs := make([]func() Result[any], 4)
+			for i := range funcs {
+				funcs[i] = fh.WrapFunction(func() {})
+			}
+			if _, res := fh.Try(func(err error) error { return err }, funcs...); res.IsErr() {
+				t.Fatal(res.Err)
+			}
+			delays := clock.waited()
+			if tt.d == 0 {
+				if len(delays) != 0 {
+					t.Fatalf("waited %v without a stagger", delays)
+				}
+				return
+			}
+			// each function waits its index times d plus jitter; without jitter the first one starts at once
+			first := len(funcs) - len(delays)
+			if first != 1 && (tt.jitter == 0 || first != 0) {
+				t.Fatalf("waited %v for %d functions", delays, len(funcs))
+			}
+			for i, delay := range delays {
+				least := time.Duration(first+i) * tt.d
+				most := least + time.Duration(tt.jitter*float64(tt.d))
+				if delay < least || delay > most {
+					t.Fatalf("delay %d is %s, want between %s and %s", first+i, delay, least, most)
+				}
+			}
+		})
+	}
+}
+
+func TestStaggerPastDeadline(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	fh := NewHandler(WithMode(ModeCollectErrors))
+	fh.SetClock(clock)
+	fh.SetStagger(100*time.Millisecond, 0)
+	ctx, cancel := context.WithDeadline(context.Background(), clock.Now().Add(250*time.Millisecond))
+	defer cancel()
+	var ran [4]atomic.Bool
+	funcs := make([]func() Result[any], len(ran))
+	for i := range funcs {
+		funcs[i] = fh.WrapNamed(fmt.Sprint("f", i), func() int {
+			ran[i].Store(true)
+			return i
+		})
+	}
+	// f3 would start 300ms in, after the deadline, so it is skipped without waiting
+	results, res := fh.TryContext(ctx, func(err error) error { return err }, funcs...)
+	if !errors.Is(res.Err, ErrStaggerDeadline) || !strings.Contains(res.Err.Error(), "f3") {
+		t.Fatalf("got %v, want f3 failing with %v", res.Err, ErrStaggerDeadline)
+	}
+	if len(results) != 3 || ran[3].Load() {
+		t.Fatalf("got %v with f3 run %v, want the first three values and f3 not run", results, ran[3].Load())
+	}
+	if waited := clock.waited(); len(waited) != 2 {
+		t.Fatalf("waited %v, want only the delays of f1 and f2", waited)
+	}
+}
+
+func TestStaggerInterruptedByCancel(t *testing.T) {
+	before := runtime.NumGoroutine()
+	fh := NewHandler(WithMode(ModeParallel))
+	fh.SetStagger(time.Hour, 0)
+	in := make(chan func() Result[any], 2)
+	in <- fh.WrapFunction(func() {})
+	in <- fh.WrapFunction(func() {}) // waits an hour for its stagger
+	close(in)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(20*time.Millisecond, cancel)
+	started := time.Now()
+	if _, res := fh.TryChan(ctx, func(err error) error { return err }, in); !errors.Is(res.Err, context.Canceled) {
+		t.Fatalf("got %v, want %v", res.Err, context.Canceled)
+	}
+	if took := time.Since(started); took > time.Second {
+		t.Fatalf("TryChan took %s, want it to stop with the batch", took)
+	}
+	requireGoroutines(t, before)
+}
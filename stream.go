@@ -0,0 +1,338 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// TryChan method to run functions received from a channel until it is closed and all started work is done.
+// Functions run with the configured parallelism, timeout and retries; in sequential mode the channel is
+// only read once the previous function finished. Cancelling ctx stops the batch with the context's cause,
+// returning the values collected so far; running functions see the cancellation through their context.
+// Under ModeCollectErrors the failures are returned joined next to the values, and under ModeFailFast the
+// first failure stops the batch and cancels the functions still running, as with Try.
+func (fhi *FunctionHandlerImpl) TryChan(ctx context.Context, handler interface{}, in <-chan func() Result[any]) ([]any, Result[any]) {
+	release, err := fhi.acquireBatch(ctx)
+	if err != nil {
+		fhi.LogError(err)
+		return nil, Err[any](err)
+	}
+	defer release()
+	return fhi.tryChan(ctx, handler, in)
+}
+
+// tryChan method to run the functions received from in, without taking a batch slot
+func (fhi *FunctionHandlerImpl) tryChan(ctx context.Context, handler interface{}, in <-chan func() Result[any]) ([]any, Result[any]) {
+	cfg := fhi.settings()
+	ctx = fhi.withBatchID(ctx)
+	results := fhi.gatherer()
+	handlerFunc := fhi.WrapErrorHandler(handler)
+	if handlerFunc.IsErr() {
+		return nil, Err[any](handlerFunc.Err)
+	}
+	if _, err := fhi.strategy(); err != nil {
+		fhi.LogError(err)
+		return nil, Err[any](err)
+	}
+	if err := fhi.checkRetryBound(ctx); err != nil {
+		fhi.LogError(err)
+		return nil, Err[any](err)
+	}
+	flush := fhi.newFlusher()
+	var failed failures
+	// cancelled returns the values collected so far with the reason the batch was stopped
+	cancelled := func() ([]any, Result[any]) {
+		err := fhi.batchCancelled(ctx)
+		if flushErr := flush.done(); flushErr != nil {
+			return nil, Err[any](flushErr)
+		}
+		return results.values(), Err[any](err)
+	}
+	// finished flushes the remaining results of a batch that ran to the end
+	finished := func() ([]any, Result[any]) {
+		if err := flush.done(); err != nil {
+			return nil, Err[any](err)
+		}
+		if err := failed.err(); err != nil {
+			return results.values(), Err[any](err)
+		}
+		return results.values(), Ok[any](nil)
+	}
+	if !cfg.mode.concurrent() {
+		for i := 0; ; {
+			select {
+			case <-ctx.Done():
+				return cancelled()
+			case fn, ok := <-in:
+				if !ok {
+					return finished()
+				}
+				res, finished := fhi.runInterruptible(ctx, fn)
+				if !finished || ctx.Err() != nil {
+					if res.IsOk() {
+						results.add(i, res.Values)
+					}
+					return cancelled()
+				}
+				res, err := fhi.settle(ctx, handlerFunc.Values[0], i, fn, res)
+				if err == nil {
+					err = flush.add(res)
+				}
+				if err != nil {
+					return nil, Err[any](err)
+				}
+				if res.IsOk() {
+					results.add(i, res.Values)
+				}
+				i++
+			}
+		}
+	}
+	// under ModeFailFast the functions still running are cancelled through runCtx when the batch stops early
+	runCtx, cancelRun := ctx, context.CancelCauseFunc(func(error) {})
+	if cfg.mode == ModeFailFast {
+		runCtx, cancelRun = context.WithCancelCause(ctx)
+		defer cancelRun(nil) // after an earlier cancel this keeps its cause
+	}
+	// done is closed on return so goroutines still running after an abort can drop their result
+	done := make(chan struct{})
+	defer close(done)
+	resultCh := make(chan outcome)
+	inflight := 0
+	// start runs the function received at index i; a retry requested by the handler starts without stagger.
+	// A function not started because the batch ended first still reports, so inflight stays right.
+	start := func(i int, fn func() Result[any], handlerRetries int) {
+		inflight++
+		go func() {
+			var res Result[any]
+			if handlerRetries == 0 {
+				var ok bool
+				if res, ok = fhi.runStaggered(runCtx, i, fn, done); !ok {
+					res = Err[any](context.Cause(runCtx))
+				}
+			} else {
+				res = fhi.runFunction(runCtx, fn)
+			}
+			select {
+			case resultCh <- outcome{index: i, fn: fn, res: res, handlerRetries: handlerRetries}:
+			case <-done:
+			}
+		}()
+	}
+	for i := 0; in != nil || inflight > 0; {
+		next := in
+		if cfg.maxConcurrency > 0 && inflight >= cfg.maxConcurrency {
+			next = nil // at the limit, wait for a result before taking another function
+		}
+		select {
+		case <-ctx.Done():
+			// under NotifyStop, wait for the running functions so their cleanup runs, until a second signal
+			if force := forceOf(ctx); force != nil {
+				for ; inflight > 0; inflight-- {
+					select {
+					case o := <-resultCh:
+						if o.res.IsOk() {
+							results.add(o.index, o.res.Values)
+						}
+						if err := flush.add(o.res); err != nil {
+							return nil, Err[any](err)
+						}
+					case <-force:
+						return cancelled()
+					}
+				}
+			}
+			return cancelled()
+		case fn, ok := <-next:
+			if !ok {
+				in = nil // a nil channel blocks, leaving only the results to wait for
+				continue
+			}
+			start(i, fn, 0)
+			i++
+		case o := <-resultCh:
+			inflight--
+			if o.res.IsOk() {
+				results.add(o.index, o.res.Values)
+				if err := flush.add(o.res); err != nil {
+					return nil, Err[any](err)
+				}
+				continue
+			}
+			if cfg.mode == ModeCollectErrors {
+				failed.add(o.index, o.res)
+				if err := flush.add(o.res); err != nil {
+					return nil, Err[any](err)
+				}
+				continue
+			}
+			retry, fallback, err := fhi.callHandler(handlerFunc.Values[0], o.res.Err, executionInfo(o.index, o.res))
+			if err != nil {
+				cancelRun(fmt.Errorf("%w: batch aborted: %w", ErrAbandoned, err))
+				return nil, Err[any](err)
+			}
+			if fallback != nil {
+				o.res = Ok(fallback)
+				results.add(o.index, o.res.Values)
+			}
+			// run a retry requested by the handler in the background so other results keep flowing
+			if retry && fhi.allowHandlerRetry(o.handlerRetries, o.res.Err) {
+				start(o.index, o.fn, o.handlerRetries+1)
+				continue
+			}
+			if err := flush.add(o.res); err != nil {
+				return nil, Err[any](err)
+			}
+			if cfg.mode == ModeFailFast && o.res.IsErr() {
+				cancelRun(fmt.Errorf("%w: %s failed: %w", ErrAbandoned, nameOf(o.fn, describe(o.fn)), o.res.Err))
+				return nil, Err[any](o.res.Err)
+			}
+		}
+	}
+	return finished()
+}
+
+// TryStream method to run a batch like Try, sending the result of every function on the returned channel as
+// soon as it finished and went through the error handler, failures included. An error that aborts the batch,
+// from the error handler or a fail-fast failure, is sent last. Under ModeCollectErrors failures are sent
+// without calling the error handler. The channel is closed when the batch is done and must be read until then.
+func (fhi *FunctionHandlerImpl) TryStream(handler interface{}, funcs ...func() Result[any]) <-chan Result[any] {
+	out := make(chan Result[any])
+	go func() {
+		defer close(out)
+		if err := fhi.tryStream(handler, funcs, out); err != nil {
+			out <- Err[any](err)
+		}
+	}()
+	return out
+}
+
+// tryStream method to run the batch of TryStream, returning the error that aborts it
+func (fhi *FunctionHandlerImpl) tryStream(handler interface{}, funcs []func() Result[any], out chan<- Result[any]) error {
+	cfg := fhi.settings()
+	release, err := fhi.acquireBatch(context.Background())
+	if err != nil {
+		fhi.LogError(err)
+		return err
+	}
+	defer release()
+	handlerFunc := fhi.WrapErrorHandler(handler)
+	if handlerFunc.IsErr() {
+		return handlerFunc.Err
+	}
+	if len(funcs) == 0 {
+		err = fhi.errorf("%w", ErrNoFunctions)
+	} else if _, err = fhi.strategy(); err == nil {
+		err = fhi.checkRetryBound(context.Background())
+	}
+	if err != nil {
+		fhi.LogError(err)
+		return err
+	}
+	ctx, cancel := context.WithCancelCause(fhi.withBatchID(context.Background()))
+	defer cancel(nil) // after an earlier cancel this keeps its cause
+	if !cfg.mode.concurrent() {
+		for i, fn := range funcs {
+			res, err := fhi.settle(ctx, handlerFunc.Values[0], i, fn, fhi.runFunction(ctx, fn))
+			if err != nil {
+				return err
+			}
+			out <- res
+		}
+		return nil
+	}
+	// done is closed on return so goroutines still running after an abort can drop their result
+	done := make(chan struct{})
+	defer close(done)
+	resultCh := make(chan outcome)
+	fhi.dispatch(funcs, done, func(i int, fn func() Result[any]) {
+		res, ok := fhi.runStaggered(ctx, i, fn, done)
+		if !ok {
+			return
+		}
+		select {
+		case resultCh <- outcome{index: i, fn: fn, res: res}:
+		case <-done:
+		}
+	})
+	for range funcs {
+		o := <-resultCh
+		if cfg.mode == ModeCollectErrors {
+			out <- o.res
+			continue
+		}
+		res, err := fhi.settle(ctx, handlerFunc.Values[0], o.index, o.fn, o.res)
+		if err != nil {
+			cancel(fmt.Errorf("%w: batch aborted: %w", ErrAbandoned, err))
+			return err
+		}
+		if res.IsErr() && cfg.mode == ModeFailFast {
+			cancel(fmt.Errorf("%w: %s failed: %w", ErrAbandoned, nameOf(o.fn, describe(o.fn)), res.Err))
+			return res.Err
+		}
+		out <- res
+	}
+	return nil
+}
+
+// tryFuncs method to run a batch like Try, stopping when ctx is cancelled. It does not take a batch slot,
+// so nested batches cannot deadlock on SetMaxConcurrentBatches.
+func (fhi *FunctionHandlerImpl) tryFuncs(ctx context.Context, handler interface{}, funcs []func() Result[any]) ([]any, Result[any]) {
+	if len(funcs) == 0 {
+		err := fhi.errorf("%w", ErrNoFunctions)
+		fhi.LogError(err)
+		return nil, Err[any](err)
+	}
+	in := make(chan func() Result[any], len(funcs))
+	for _, fn := range funcs {
+		in <- fn
+	}
+	close(in)
+	return fhi.tryChan(ctx, handler, in)
+}
+
+// SubTry method to package a whole batch as one function that can be passed to an outer Try.
+// The inner batch runs with this handler's settings and the given error handler; its error becomes
+// the function's error and its results the function's values. Cancellation and timeouts of the
+// outer batch reach the inner one.
+func (fhi *FunctionHandlerImpl) SubTry(handler interface{}, funcs ...func() Result[any]) func() Result[any] {
+	return bind(&wrapped{name: "SubTry", run: func(exec *execution) Result[any] {
+		results, res := fhi.tryFuncs(exec.ctx, handler, funcs)
+		if res.IsErr() {
+			return Err[any](res.Err)
+		}
+		return Ok(results...)
+	}})
+}
+
+// MergeResultChans function to fan several result channels into one, closed once every input is closed.
+// Ordering across inputs is unspecified but each input's order is kept. After ctx is cancelled no new
+// values are read and the output is closed once the values already read, at most one per input, were
+// delivered; a value read from an input is never dropped, so the output must be read until it is closed.
+func MergeResultChans(ctx context.Context, chans ...<-chan Result[any]) <-chan Result[any] {
+	out := make(chan Result[any])
+	var wg sync.WaitGroup
+	for _, ch := range chans {
+		wg.Add(1)
+		go func(ch <-chan Result[any]) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case res, ok := <-ch:
+					if !ok {
+						return
+					}
+					out <- res
+				}
+			}
+		}(ch)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+var errBoom = errors.New("boom")
+
+// produce function to send the values 0 to n-1 of name on an unbuffered channel, pausing between them, until
+// ctx ends. It reports how many sends completed, each one a value read by the receiver, once the channel is closed.
+func produce(ctx context.Context, name string, n int, pause time.Duration) (<-chan Result[any], <-chan int) {
+	ch, sent := make(chan Result[any]), make(chan int, 1)
+	go func() {
+		defer close(ch)
+		i := 0
+		defer func() { sent <- i }()
+		for ; i < n; i++ {
+			time.Sleep(pause)
+			select {
+			case ch <- Ok[any](name, i):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, sent
+}
+
+// drainMerged function to read out until it is closed, failing when an input's order is not kept, and
+// add how many values of each input were read to next, which holds the counts read before
+func drainMerged(t *testing.T, out <-chan Result[any], next map[string]int) map[string]int {
+	for res := range out {
+		name, i := res.Values[0].(string), res.Values[1].(int)
+		if i != next[name] {
+			t.Fatalf("got value %d of %s, want %d: the order of an input must be kept", i, name, next[name])
+		}
+		next[name]++
+	}
+	return next
+}
+
+func TestMergeResultChans(t *testing.T) {
+	ctx := context.Background()
+	// the inputs close at different times: at once, after a few values and after many
+	empty, _ := produce(ctx, "empty", 0, 0)
+	short, _ := produce(ctx, "short", 3, time.Millisecond)
+	long, _ := produce(ctx, "long", 20, 100*time.Microsecond)
+	got := drainMerged(t, MergeResultChans(ctx, empty, short, long), map[string]int{})
+	if got["empty"] != 0 || got["short"] != 3 || got["long"] != 20 {
+		t.Fatalf("merged %v values, want 3 short and 20 long", got)
+	}
+}
+
+func TestMergeResultChansCancelMidStream(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	a, sentA := produce(ctx, "a", 1000, 0)
+	b, sentB := produce(ctx, "b", 1000, 0)
+	out := MergeResultChans(ctx, a, b)
+	first := <-out
+	time.Sleep(5 * time.Millisecond) // the forwarding goroutines read the next values and wait to deliver them
+	cancel()
+	time.Sleep(5 * time.Millisecond)
+	got := drainMerged(t, out, map[string]int{first.Values[0].(string): 1})
+	if want := map[string]int{"a": <-sentA, "b": <-sentB}; got["a"] != want["a"] || got["b"] != want["b"] {
+		t.Fatalf("read %v values, want the %v sent", got, want)
+	}
+	if got["a"] == 1000 || got["b"] == 1000 {
+		t.Fatalf("read %v values, want the merge to stop at the cancel", got)
+	}
+	requireGoroutines(t, before)
+}
+
+func TestTryChanFailFast(t *testing.T) {
+	fh := NewHandler(WithMode(ModeFailFast))
+	ignore := func(err error) error { return nil }
+	tests := []struct {
+		name string
+		run  func(funcs ...func() Result[any]) Result[any]
+	}{
+		{"TryChan", func(funcs ...func() Result[any]) Result[any] {
+			in := make(chan func() Result[any], len(funcs))
+			for _, fn := range funcs {
+				in <- fn
+			}
+			close(in)
+			_, res := fh.TryChan(context.Background(), ignore, in)
+			return res
+		}},
+		{"SubTry", func(funcs ...func() Result[any]) Result[any] {
+			return fh.SubTry(ignore, funcs...)()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{})
+			cancelled := make(chan error, 1)
+			slow := fh.WrapFunction(func(ctx context.Context) error {
+				close(started)
+				select {
+				case <-ctx.Done():
+					cancelled <- context.Cause(ctx)
+					return ctx.Err()
+				case <-time.After(5 * time.Second):
+					return nil
+				}
+			})
+			fail := fh.WrapFunction(func() error {
+				<-started
+				return errBoom
+			})
+			start := time.Now()
+			res := tt.run(slow, fail)
+			if !errors.Is(res.Err, errBoom) {
+				t.Fatalf("batch = %v, want the first failure", res.Err)
+			}
+			if took := time.Since(start); took > time.Second {
+				t.Fatalf("batch took %s, want it to stop at the first failure", took)
+			}
+			select {
+			case cause := <-cancelled:
+				if !errors.Is(cause, ErrAbandoned) {
+					t.Fatalf("running function cancelled with %v, want %v", cause, ErrAbandoned)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("the running function was not cancelled")
+			}
+		})
+	}
+}
+
+func TestTryStream(t *testing.T) {
+	ignore := func(err error) error { return nil }
+	abort := func(err error) error { return err }
+	tests := []struct {
+		name      string
+		mode      ExecutionMode
+		handler   func(err error) error
+		want      []string // the results in the order they arrive, as values or errors
+		wantCalls int
+	}{
+		{"sequential in order", ModeSequential, ignore, []string{"[1]", "boom", "[3]"}, 1},
+		{"sequential aborted", ModeSequential, abort, []string{"[1]", "boom"}, 1},
+		{"parallel as they finish", ModeParallel, ignore, []string{"[3]", "boom", "[1]"}, 1},
+		{"parallel aborted before the slow one", ModeParallel, abort, []string{"[3]", "boom"}, 1},
+		{"collect errors without the handler", ModeCollectErrors, abort, []string{"[3]", "boom", "[1]"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := NewHandler(WithMode(tt.mode))
+			calls := 0
+			handler := func(err error) error { calls++; return tt.handler(err) }
+			funcs := []func() Result[any]{
+				fh.WrapNamed("slow", func() int { time.Sleep(100 * time.Millisecond); return 1 }),
+				fh.WrapNamed("fail", func() error { time.Sleep(20 * time.Millisecond); return errBoom }),
+				fh.WrapNamed("fast", func() int { return 3 }),
+			}
+			var got []string
+			for res := range fh.TryStream(handler, funcs...) {
+				if res.IsErr() {
+					got = append(got, res.Err.Error())
+				} else {
+					got = append(got, fmt.Sprint(res.Values))
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("streamed %v, want %v", got, tt.want)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("error handler called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestTryStreamNoFunctions(t *testing.T) {
+	var got []Result[any]
+	for res := range NewHandler().TryStream(func(err error) error { return err }) {
+		got = append(got, res)
+	}
+	if len(got) != 1 || !errors.Is(got[0].Err, ErrNoFunctions) {
+		t.Fatalf("streamed %v, want only %v", got, ErrNoFunctions)
+	}
+}
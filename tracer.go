@@ -0,0 +1,26 @@
+package handler
+
+import "context"
+
+// Tracer interface to follow the runs of functions and their attempts, such as with tracing spans. Each Start
+// method returns the context the run or attempt continues with, which reaches functions taking a context,
+// and a function to call with its final error. handler is the name set with SetName, either name may be empty.
+type Tracer interface {
+	StartRun(ctx context.Context, handler, function string) (context.Context, func(err error))
+	StartAttempt(ctx context.Context, handler, function string, attempt int) (context.Context, func(err error))
+}
+
+// SetTracer method to report every run of a function in a batch, and every attempt, to tracer
+func (fhi *FunctionHandlerImpl) SetTracer(tracer Tracer) {
+	fhi.configure(func(s *settings) { s.tracer = tracer })
+}
+
+// getTracer method to return the handler's tracer, or the nearest ancestor's
+func (fhi *FunctionHandlerImpl) getTracer() Tracer {
+	for h := fhi; h != nil; h = h.parent {
+		if tracer := h.settings().tracer; tracer != nil {
+			return tracer
+		}
+	}
+	return nil
+}
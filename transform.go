@@ -0,0 +1,54 @@
+package handler
+
+import "slices"
+
+// ArgTransformer is a function that rewrites the arguments of the named function before a call
+type ArgTransformer func(funcName string, args []interface{}) ([]interface{}, error)
+
+// UseArgTransformer method to add a transformer applied to the arguments of every function the handler calls,
+// right before each attempt and after WrapWithArgsFunc computed them. Transformers run in the order they were
+// added, each receiving the arguments the previous one returned. They get a copy, so the arguments bound by
+// the Wrap call are never changed. A transformer error wraps ErrArgTransform, fails the attempt and is never retried.
+func (fhi *FunctionHandlerImpl) UseArgTransformer(transform ArgTransformer) {
+	fhi.configure(func(s *settings) { s.argTransformers = append(slices.Clip(s.argTransformers), transform) })
+}
+
+// ResultValidator is a function that checks the values of a successful call of the named function
+type ResultValidator func(funcName string, values []any) error
+
+// UseResultValidator method to add a validator run, in the order added, on the values of every successful attempt
+// of the handler's functions. A validator error turns the attempt into a failure wrapping ErrInvalidResult,
+// which is retried and passed to the error handler like any other failure.
+func (fhi *FunctionHandlerImpl) UseResultValidator(validate ResultValidator) {
+	fhi.configure(func(s *settings) { s.resultValidators = append(slices.Clip(s.resultValidators), validate) })
+}
+
+// validateResult method to run the result validators on a successful result of the named function
+func (fhi *FunctionHandlerImpl) validateResult(name string, res Result[any]) Result[any] {
+	for _, validate := range fhi.settings().resultValidators {
+		if err := validate(name, res.Values); err != nil {
+			err = fhi.errorf("%w: %s: %w", ErrInvalidResult, name, err)
+			fhi.LogError(err)
+			return Err[any](err)
+		}
+	}
+	return res
+}
+
+// transformArgs method to pass args through the transformers for a call of the named function
+func (fhi *FunctionHandlerImpl) transformArgs(name string, args []interface{}) ([]interface{}, error) {
+	cfg := fhi.settings()
+	if len(cfg.argTransformers) == 0 {
+		return args, nil
+	}
+	args = slices.Clone(args)
+	for _, transform := range cfg.argTransformers {
+		var err error
+		if args, err = transform(name, args); err != nil {
+			err = fhi.errorf("%w: %s: %w", ErrArgTransform, name, err)
+			fhi.LogError(err)
+			return nil, err
+		}
+	}
+	return args, nil
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestArgTransformersOrder(t *testing.T) {
+	fh := NewHandler(WithRetries(1), WithBackoff(ConstantBackoff(0)))
+	var order []string
+	fh.UseArgTransformer(func(funcName string, args []interface{}) ([]interface{}, error) {
+		order = append(order, "trim")
+		args[0] = strings.TrimSpace(args[0].(string))
+		return args, nil
+	})
+	fh.UseArgTransformer(func(funcName string, args []interface{}) ([]interface{}, error) {
+		order = append(order, "tenant")
+		return append([]interface{}{"acme/" + args[0].(string)}, args[1:]...), nil
+	})
+	args := []interface{}{"  order-7 "}
+	var got []string
+	attempts := 0
+	fn := fh.WrapFunctionSlice(func(id string) error {
+		got = append(got, id)
+		if attempts++; attempts == 1 {
+			return errBoom
+		}
+		return nil
+	}, args)
+	if res := fh.RunWithRetry(context.Background(), fn); res.IsErr() {
+		t.Fatal(res.Err)
+	}
+	// both attempts see the transformed argument, the transformers running in the order they were added
+	if fmt.Sprint(got) != "[acme/order-7 acme/order-7]" || fmt.Sprint(order) != "[trim tenant trim tenant]" {
+		t.Fatalf("function got %q after transformers ran %v", got, order)
+	}
+	if args[0] != "  order-7 " {
+		t.Fatalf("caller's arguments changed to %q", args)
+	}
+}
+
+func TestArgTransformerLateArgs(t *testing.T) {
+	fh := NewHandler()
+	fh.UseArgTransformer(func(funcName string, args []interface{}) ([]interface{}, error) {
+		return []interface{}{args[0].(int) * 10}, nil
+	})
+	fn := fh.WrapWithArgsFunc(func(n int) int { return n }, func(attempt int, prevErr error) ([]interface{}, error) {
+		return []interface{}{attempt}, nil
+	})
+	if res := fn(); res.IsErr() || res.Values[0] != 10 {
+		t.Fatalf("got %v, want the computed argument transformed", res)
+	}
+}
+
+func TestArgTransformerErrorNotRetried(t *testing.T) {
+	fh := NewHandler(WithRetries(3), WithBackoff(ConstantBackoff(0)))
+	calls := 0
+	fh.UseArgTransformer(func(funcName string, args []interface{}) ([]interface{}, error) {
+		calls++
+		return nil, errBoom
+	})
+	ran := false
+	res := fh.RunWithRetry(context.Background(), fh.WrapFunction(func(string) { ran = true }, "x"))
+	if !errors.Is(res.Err, ErrArgTransform) || !errors.Is(res.Err, errBoom) {
+		t.Fatalf("got %v, want %v wrapping the transformer's error", res.Err, ErrArgTransform)
+	}
+	if ran || calls != 1 {
+		t.Fatalf("function ran %v, transformer called %d times, want no run and one call", ran, calls)
+	}
+}
+
+// outcomeMetrics struct to record the outcome of every finished function
+type outcomeMetrics struct {
+	mu       sync.Mutex
+	outcomes []Outcome
+}
+
+func (m *outcomeMetrics) Started(handler, function string) {}
+func (m *outcomeMetrics) Retried(handler, function string) {}
+func (m *outcomeMetrics) Finished(handler, function string, outcome Outcome, took time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.outcomes = append(m.outcomes, outcome)
+}
+
+func TestResultValidators(t *testing.T) {
+	positive := func(values []any) error {
+		if values[0].(int) <= 0 {
+			return fmt.Errorf("total %v is not positive", values[0])
+		}
+		return nil
+	}
+	tests := []struct {
+		name string
+		// wrap returns a function returning -1 on its first attempt and 10 after
+		wrap func(fh *FunctionHandlerImpl, fn func() int) func() Result[any]
+	}{
+		{"per function", func(fh *FunctionHandlerImpl, fn func() int) func() Result[any] {
+			return fh.WrapWithResultValidator(fn, positive)
+		}},
+		{"handler-wide", func(fh *FunctionHandlerImpl, fn func() int) func() Result[any] {
+			fh.UseResultValidator(func(funcName string, values []any) error { return positive(values) })
+			return fh.WrapFunction(fn)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pass := func(err error) error { return err }
+			fh := NewHandler(WithRetries(1), WithBackoff(ConstantBackoff(0)))
+			attempts := 0
+			fn := tt.wrap(fh, func() int {
+				if attempts++; attempts == 1 {
+					return -1
+				}
+				return 10
+			})
+			// the invalid first result is retried and the second one passes
+			if results, res := fh.Try(pass, fn); res.IsErr() || results[0] != 10 || attempts != 2 {
+				t.Fatalf("got %v %v after %d attempts, want 10 after 2", results, res.Err, attempts)
+			}
+			// without retries the invalid result reaches the error handler, reported apart from a returned error
+			metrics := &outcomeMetrics{}
+			fh.SetMetrics(metrics)
+			fh.SetRetry(0)
+			attempts = 0
+			_, res := fh.Try(pass, fn)
+			if !errors.Is(res.Err, ErrInvalidResult) {
+				t.Fatalf("got %v, want %v", res.Err, ErrInvalidResult)
+			}
+			fh.Try(pass, fh.WrapFunction(func() error { return errBoom }))
+			if fmt.Sprint(metrics.outcomes) != fmt.Sprint([]Outcome{OutcomeInvalidResult, OutcomeError}) {
+				t.Fatalf("got outcomes %v, want %s then %s", metrics.outcomes, OutcomeInvalidResult, OutcomeError)
+			}
+		})
+	}
+}
+
+func TestResultValidatorFailureRespectsRetryIf(t *testing.T) {
+	fh := NewHandler(WithRetries(3), WithBackoff(ConstantBackoff(0)))
+	fh.SetRetryIf(func(err error) bool { return !errors.Is(err, ErrInvalidResult) })
+	attempts := 0
+	fn := fh.WrapWithResultValidator(func() int {
+		attempts++
+		return 0
+	}, func([]any) error { return errors.New("empty payload") })
+	if res := fh.RunWithRetry(context.Background(), fn); !errors.Is(res.Err, ErrInvalidResult) || attempts != 1 {
+		t.Fatalf("got %v after %d attempts, want %v after 1", res.Err, attempts, ErrInvalidResult)
+	}
+}
@@ -0,0 +1,65 @@
+package handler
+
+import "runtime/debug"
+
+// Wrap0 function to create a typed function calling fn without reflection. A panic becomes a PanicError.
+func Wrap0[R any](fn func() (R, error)) func() Result[R] {
+	return func() (res Result[R]) {
+		defer recoverTyped(fn, &res)
+		return typedResult(fn())
+	}
+}
+
+// Wrap1 function to create a typed function calling fn with a without reflection
+func Wrap1[A, R any](fn func(A) (R, error), a A) func() Result[R] {
+	return func() (res Result[R]) {
+		defer recoverTyped(fn, &res)
+		return typedResult(fn(a))
+	}
+}
+
+// Wrap2 function to create a typed function calling fn with a and b without reflection
+func Wrap2[A, B, R any](fn func(A, B) (R, error), a A, b B) func() Result[R] {
+	return func() (res Result[R]) {
+		defer recoverTyped(fn, &res)
+		return typedResult(fn(a, b))
+	}
+}
+
+// Wrap3 function to create a typed function calling fn with a, b and c without reflection
+func Wrap3[A, B, C, R any](fn func(A, B, C) (R, error), a A, b B, c C) func() Result[R] {
+	return func() (res Result[R]) {
+		defer recoverTyped(fn, &res)
+		return typedResult(fn(a, b, c))
+	}
+}
+
+// Erase function to turn a typed function into one that can be passed to Try and the other batch methods
+func Erase[R any](fn func() Result[R]) func() Result[any] {
+	return func() Result[any] {
+		res := fn()
+		if res.IsErr() {
+			return Err[any](res.Err)
+		}
+		values := make([]any, len(res.Values))
+		for i, value := range res.Values {
+			values[i] = value
+		}
+		return Ok(values...)
+	}
+}
+
+// typedResult function to turn the return values of a typed function into a Result
+func typedResult[R any](value R, err error) Result[R] {
+	if err != nil {
+		return Err[R](err)
+	}
+	return Ok(value)
+}
+
+// recoverTyped function to turn a panic in the typed function fn into a PanicError in res
+func recoverTyped[R any](fn interface{}, res *Result[R]) {
+	if r := recover(); r != nil {
+		*res = Err[R](&PanicError{Value: r, Stack: debug.Stack(), FuncName: funcName(fn)})
+	}
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestTypedWrappers(t *testing.T) {
+	greet := func(name string) (string, error) {
+		if name == "" {
+			return "", errBoom
+		}
+		return "hello " + name, nil
+	}
+	tests := []struct {
+		name string
+		fn   func() Result[string]
+		want string
+		err  error
+	}{
+		{"Wrap0", Wrap0(func() (string, error) { return "zero", nil }), "zero", nil},
+		{"Wrap1", Wrap1(greet, "bob"), "hello bob", nil},
+		{"Wrap1 error", Wrap1(greet, ""), "", errBoom},
+		{"Wrap2", Wrap2(func(a string, n int) (string, error) { return a + strconv.Itoa(n), nil }, "x", 2), "x2", nil},
+		{"Wrap3", Wrap3(func(a, b string, n int) (string, error) { return fmt.Sprint(a, b, n), nil }, "a", "b", 3), "ab3", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := tt.fn()
+			if !errors.Is(res.Err, tt.err) {
+				t.Fatalf("got %v, want %v", res.Err, tt.err)
+			}
+			if tt.err == nil && !slices.Equal(res.Values, []string{tt.want}) {
+				t.Fatalf("got %v, want %q", res.Values, tt.want)
+			}
+			if tt.err != nil && len(res.Values) != 0 {
+				t.Fatalf("got values %v with the error", res.Values)
+			}
+		})
+	}
+}
+
+func TestTypedWrapperPanic(t *testing.T) {
+	explode := func(n int) (int, error) { panic("exploded") }
+	res := Wrap1(explode, 1)()
+	var panicErr *PanicError
+	if !errors.As(res.Err, &panicErr) || panicErr.Value != "exploded" || len(panicErr.Stack) == 0 {
+		t.Fatalf("got %v, want a PanicError holding the panic value and stack", res.Err)
+	}
+}
+
+func TestErase(t *testing.T) {
+	fh := NewHandler()
+	double := func(n int) (int, error) { return n * 2, nil }
+	fail := func(n int) (int, error) { return 0, errBoom }
+	tests := []struct {
+		name  string
+		funcs []func() Result[any]
+		want  []any
+		err   error
+	}{
+		{"values", []func() Result[any]{Erase(Wrap1(double, 1)), Erase(Wrap1(double, 2))}, []any{2, 4}, nil},
+		{"error", []func() Result[any]{Erase(Wrap1(double, 1)), Erase(Wrap1(fail, 2))}, nil, errBoom},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, res := fh.Try(func(err error) error { return err }, tt.funcs...)
+			if !errors.Is(res.Err, tt.err) {
+				t.Fatalf("Try = %v, want %v", res.Err, tt.err)
+			}
+			if tt.err == nil && !slices.Equal(results, tt.want) {
+				t.Fatalf("Try = %v, want %v", results, tt.want)
+			}
+		})
+	}
+}
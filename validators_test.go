@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWrapWithValidators(t *testing.T) {
+	fh := NewHandler(WithRetries(3), WithBackoff(ConstantBackoff(0)))
+	errEmpty := errors.New("userID must be non-empty")
+	var order []string
+	validator := func(name string, err error) func(args []interface{}) error {
+		return func(args []interface{}) error {
+			order = append(order, name)
+			return err
+		}
+	}
+	calls := 0
+	fetch := func(userID string) string { calls++; return "user " + userID }
+
+	fn := fh.WrapWithValidators(fetch, []func(args []interface{}) error{
+		validator("first", nil), validator("second", errEmpty), validator("third", nil),
+	}, "")
+	var handled error
+	_, res := fh.Try(func(err error) error { handled = err; return err }, fn)
+	if !errors.Is(res.Err, ErrValidation) || !errors.Is(res.Err, errEmpty) {
+		t.Fatalf("Try = %v, want %v wrapping the validator's error", res.Err, ErrValidation)
+	}
+	if !errors.Is(handled, ErrValidation) {
+		t.Fatalf("error handler got %v, want %v", handled, ErrValidation)
+	}
+	if calls != 0 {
+		t.Fatalf("the function was called %d times after a failed validation", calls)
+	}
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Fatalf("validators ran as %v, want first and second once each: a validation failure is not retried", order)
+	}
+
+	order = nil
+	fn = fh.WrapWithValidators(fetch, []func(args []interface{}) error{validator("first", nil)}, "42")
+	results, res := fh.Try(func(err error) error { return err }, fn)
+	if res.IsErr() || len(results) != 1 || results[0] != "user 42" || calls != 1 {
+		t.Fatalf("Try = %v, %v with %d calls; want the function's value", results, res.Err, calls)
+	}
+}
+
+func TestDescribeRunsValidators(t *testing.T) {
+	fh := NewHandler()
+	errEmpty := errors.New("userID must be non-empty")
+	nonEmpty := func(args []interface{}) error {
+		if args[0] == "" {
+			return errEmpty
+		}
+		return nil
+	}
+	calls := 0
+	fetch := func(userID string) string { calls++; return "user " + userID }
+	tests := []struct {
+		name        string
+		arg         string
+		wantProblem bool
+	}{
+		{"valid", "42", false},
+		{"failing validator", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := fh.Describe(fh.WrapWithValidators(fetch, []func(args []interface{}) error{nonEmpty}, tt.arg))
+			if (d.Problem != "") != tt.wantProblem {
+				t.Fatalf("Describe problem = %q, want a problem %v", d.Problem, tt.wantProblem)
+			}
+			if tt.wantProblem && !strings.Contains(d.Problem, errEmpty.Error()) {
+				t.Fatalf("Describe problem = %q, want the validator's error", d.Problem)
+			}
+			if calls != 0 {
+				t.Fatalf("Describe called the function %d times", calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"context"
+	"sync"
+)
+
+// Worker struct to run submitted functions in the background with the handler's settings
+type Worker struct {
+	fhi      *FunctionHandlerImpl
+	mu       sync.Mutex
+	jobs     chan job
+	stop     chan struct{} // closed by Stop, after which no job is accepted
+	stopOnce sync.Once
+	finished chan struct{}
+	callback func(Result[any])
+}
+
+// job struct to pair a submitted function with its future
+type job struct {
+	fn       func() Result[any]
+	future   *Future
+	callback func(Result[any])
+}
+
+// Future struct to hold the result of a submitted function once it has run
+type Future struct {
+	done chan struct{}
+	res  Result[any]
+}
+
+// Done method to return a channel closed when the result is available
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait method to block until the result is available or ctx is done
+func (f *Future) Wait(ctx context.Context) Result[any] {
+	select {
+	case <-f.done:
+		return f.res
+	case <-ctx.Done():
+		return Err[any](ctx.Err())
+	}
+}
+
+// NewWorker method to start a worker that runs submitted functions until it is stopped.
+// In parallel mode every submission runs in its own goroutine, otherwise they run one at a time.
+func (fhi *FunctionHandlerImpl) NewWorker() *Worker {
+	w := &Worker{
+		fhi:      fhi,
+		jobs:     make(chan job),
+		stop:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+	go w.loop()
+	return w
+}
+
+// SetCallback method to set a function called with the result of every later submission
+func (w *Worker) SetCallback(callback func(Result[any])) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callback = callback
+}
+
+// Submit method to queue a function, returning a future for its result or ErrWorkerStopped after Stop.
+// It blocks while the worker is busy running a previous function one at a time.
+func (w *Worker) Submit(fn func() Result[any]) (*Future, error) {
+	return w.SubmitContext(context.Background(), fn)
+}
+
+// SubmitContext method to queue a function like Submit, returning ctx's error when ctx ends before the worker
+// accepts it
+func (w *Worker) SubmitContext(ctx context.Context, fn func() Result[any]) (*Future, error) {
+	w.mu.Lock()
+	callback := w.callback
+	w.mu.Unlock()
+	future := &Future{done: make(chan struct{})}
+	select {
+	case <-w.stop: // checked first, so nothing is accepted once Stop was called
+		return nil, w.fhi.errorf("%w", ErrWorkerStopped)
+	default:
+	}
+	select {
+	case w.jobs <- job{fn: fn, future: future, callback: callback}:
+		return future, nil
+	case <-w.stop:
+		return nil, w.fhi.errorf("%w", ErrWorkerStopped)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Stop method to stop accepting work and wait for submitted functions to finish; submissions still waiting to
+// be accepted fail with ErrWorkerStopped. If ctx is done first its error is returned and the remaining work
+// keeps draining in the background.
+func (w *Worker) Stop(ctx context.Context) error {
+	w.stopOnce.Do(func() { close(w.stop) })
+	select {
+	case <-w.finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// loop method to dispatch submitted jobs until the worker is stopped
+func (w *Worker) loop() {
+	var wg sync.WaitGroup
+	for {
+		var j job
+		select {
+		case j = <-w.jobs:
+		case <-w.stop:
+			wg.Wait()
+			close(w.finished)
+			return
+		}
+		if w.fhi.settings().mode.concurrent() {
+			wg.Add(1)
+			go func(j job) {
+				defer wg.Done()
+				w.run(j)
+			}(j)
+		} else {
+			w.run(j)
+		}
+	}
+}
+
+// run method to execute a job and deliver its result
+func (w *Worker) run(j job) {
+	j.future.res = w.fhi.runFunction(context.Background(), j.fn)
+	close(j.future.done)
+	if j.callback != nil {
+		j.callback(j.future.res)
+	}
+}
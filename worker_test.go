@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWorkerStopHonoursContextWhileSubmitBlocks(t *testing.T) {
+	fh := NewHandler(WithMode(ModeSequential))
+	w := fh.NewWorker()
+	release := make(chan struct{})
+	if _, err := w.Submit(fh.WrapFunction(func() { <-release })); err != nil {
+		t.Fatal(err)
+	}
+	submitted := make(chan error, 1)
+	go func() { // blocks: the worker is busy with the first function
+		_, err := w.Submit(fh.WrapFunction(func() {}))
+		submitted <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := w.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Stop = %v, want context.DeadlineExceeded", err)
+	}
+	if took := time.Since(start); took > 500*time.Millisecond {
+		t.Fatalf("Stop took %s with a 100ms context", took)
+	}
+	select {
+	case err := <-submitted:
+		if !errors.Is(err, ErrWorkerStopped) {
+			t.Fatalf("blocked Submit = %v, want ErrWorkerStopped", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("blocked Submit did not return after Stop")
+	}
+	close(release)
+	if err := w.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkerSubmitContext(t *testing.T) {
+	fh := NewHandler(WithMode(ModeSequential))
+	w := fh.NewWorker()
+	defer w.Stop(context.Background())
+	release := make(chan struct{})
+	defer close(release)
+	if _, err := w.Submit(fh.WrapFunction(func() { <-release })); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := w.SubmitContext(ctx, fh.WrapFunction(func() {})); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SubmitContext = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestWorkerRunsSubmissionsAndLeaksNoGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for _, mode := range []ExecutionMode{ModeSequential, ModeParallel} {
+		fh := NewHandler(WithMode(mode))
+		w := fh.NewWorker()
+		futures := make([]*Future, 5)
+		for i := range futures {
+			future, err := w.Submit(fh.WrapFunction(func(n int) int { return n * 2 }, i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			futures[i] = future
+		}
+		for i, future := range futures {
+			if res := future.Wait(context.Background()); res.IsErr() || res.Values[0] != i*2 {
+				t.Fatalf("%s: future %d = %v", mode, i, res)
+			}
+		}
+		if err := w.Stop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Submit(fh.WrapFunction(func() {})); !errors.Is(err, ErrWorkerStopped) {
+			t.Fatalf("%s: Submit after Stop = %v, want ErrWorkerStopped", mode, err)
+		}
+	}
+	requireGoroutines(t, before)
+}